@@ -0,0 +1,89 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/apikey"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// newAPIKeyServer returns a server with API keys whose acme user is an admin,
+// and the bearer authorization of that user
+func newAPIKeyServer(t *testing.T) (*testServer, string) {
+	t.Helper()
+	s := newServer(t, withAPIKeys())
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+	if _, err := s.roles.AssignRole(ctx, s.acme, "admin"); err != nil {
+		t.Fatalf("assign admin role: %v", err)
+	}
+	return s, "Bearer " + accessToken(1, s.acme)
+}
+
+// createKey issues an API key as the bearer and returns it
+func createKey(t *testing.T, s *testServer, bearer, body string) handler.IssuedAPIKeyResponse {
+	t.Helper()
+	rec := serveAs(s.e, http.MethodPost, "/api/v1/api-keys", bearer, body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create key: status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var res struct {
+		Data handler.IssuedAPIKeyResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return res.Data
+}
+
+func TestAPIKeyScopesCapPermissions(t *testing.T) {
+	s, bearer := newAPIKeyServer(t)
+	key := createKey(t, s, bearer, `{"name":"export","scopes":["users:read"]}`)
+	authorization := "ApiKey " + key.Key
+	user := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+
+	if rec := serveAs(s.e, http.MethodGet, user, authorization, ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET within the key's scopes: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	// The owner is an admin, but the key only carries users:read
+	if rec := serveAs(s.e, http.MethodDelete, user, authorization, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("DELETE outside the key's scopes: status = %d, want 403: %s", rec.Code, rec.Body)
+	}
+	if rec := serveAs(s.e, http.MethodGet, "/api/v1/api-keys", authorization, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("manage keys with a key: status = %d, want 403: %s", rec.Code, rec.Body)
+	}
+	if rec := serveAs(s.e, http.MethodGet, user, bearer, ""); rec.Code != http.StatusOK {
+		t.Errorf("GET as the owner: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestRevokedOrExpiredAPIKeyIsRejected(t *testing.T) {
+	s, bearer := newAPIKeyServer(t)
+	revoked := createKey(t, s, bearer, `{"name":"revoked","scopes":["users:read"]}`)
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	expired := createKey(t, s, bearer, `{"name":"expired","scopes":["users:read"],"expires_at":"`+expiresAt+`"}`)
+
+	if rec := serveAs(s.e, http.MethodDelete, "/api/v1/api-keys/"+strconv.FormatUint(uint64(revoked.ID), 10), bearer, ""); rec.Code != http.StatusOK {
+		t.Fatalf("revoke: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	ctx := tenancy.AllTenants(context.Background())
+	if err := s.db.WithContext(ctx).Model(&apikey.APIKey{}).Where("id = ?", expired.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire key: %v", err)
+	}
+
+	for name, key := range map[string]string{"revoked": revoked.Key, "expired": expired.Key, "unknown": "bgk_000000000000_unknown"} {
+		rec := serveAs(s.e, http.MethodGet, "/api/v1/users", "ApiKey "+key, "")
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s key: status = %d, want 401: %s", name, rec.Code, rec.Body)
+		}
+		if rec.Header().Get(echo.HeaderWWWAuthenticate) == "" {
+			t.Errorf("%s key: no %s header", name, echo.HeaderWWWAuthenticate)
+		}
+	}
+}
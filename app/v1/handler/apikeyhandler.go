@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/apikey"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+/**
+ * APIKeyHandler handles HTTP requests for managing the caller's API keys.
+ * It depends on the apikey service for business logic operations.
+ */
+type APIKeyHandler struct {
+	apiKeyService apikey.Service
+}
+
+// APIKeyResponse represents an API key for return in API responses. The key
+// value itself is never included.
+type APIKeyResponse struct {
+	ID         uint     `json:"id" example:"1"`
+	Name       string   `json:"name" example:"nightly-export"`
+	Prefix     string   `json:"prefix" example:"bgk_3f9a1c2b7d4e"`
+	Scopes     []string `json:"scopes" example:"users:read,users:export"`
+	CreatedAt  string   `json:"created_at" example:"2025-06-15T19:22:47.091+07:00"`
+	ExpiresAt  *string  `json:"expires_at,omitempty" example:"2026-12-31T00:00:00.000Z"`
+	LastUsedAt *string  `json:"last_used_at,omitempty" example:"2025-06-16T08:00:00.000Z"`
+	RevokedAt  *string  `json:"revoked_at,omitempty" example:"2025-06-17T08:00:00.000Z"`
+}
+
+// IssuedAPIKeyResponse represents a newly created or rotated API key,
+// including the key value which is shown only this once
+type IssuedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"bgk_3f9a1c2b7d4e_Zm9vYmFyYmF6cXV4cXV1eGNvcmdlZ3JhdWx0Z2FycGx5"`
+}
+
+// NewAPIKeyResponse converts an API key entity into its API representation
+func NewAPIKeyResponse(k apikey.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.DisplayPrefix(),
+		Scopes:     k.ScopeList(),
+		CreatedAt:  k.CreatedAt.Format(timestampLayout),
+		ExpiresAt:  formatOptionalTime(k.ExpiresAt),
+		LastUsedAt: formatOptionalTime(k.LastUsedAt),
+		RevokedAt:  formatOptionalTime(k.RevokedAt),
+	}
+}
+
+// formatOptionalTime formats a nullable timestamp for responses
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(timestampLayout)
+	return &formatted
+}
+
+/**
+ * NewAPIKeyHandler creates a new instance of APIKeyHandler with the provided apikey service.
+ *
+ * @param apiKeyService The service that issues and verifies API keys
+ * @return A pointer to a new APIKeyHandler instance
+ */
+func NewAPIKeyHandler(apiKeyService apikey.Service) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService}
+}
+
+/**
+ * CreateKey handles the HTTP request for issuing an API key to the caller.
+ * The key value is returned once and cannot be retrieved later.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Create an API key
+// @Description Issues an API key acting as the caller, limited to the given scopes. The key is shown only in this response.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body apikey.CreateAPIKeyForm true "API key data"
+// @Security BearerAuth
+// @Success 201 {object} helper.SuccessResponse{data=IssuedAPIKeyResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/api-keys [post]
+func (h *APIKeyHandler) CreateKey(c echo.Context) error {
+	req := new(apikey.CreateAPIKeyForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	issued, err := h.apiKeyService.CreateKey(c.Request().Context(), principal.From(c).UserID, req)
+	if err != nil {
+		return apiKeyErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusCreated
+	res.Message = "API key created, store it now as it will not be shown again"
+	res.Data = IssuedAPIKeyResponse{NewAPIKeyResponse(issued.APIKey), issued.Key}
+	return c.JSON(http.StatusCreated, res)
+}
+
+/**
+ * ListKeys handles the HTTP request for listing the caller's API keys.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List API keys
+// @Description Lists the caller's API keys, including revoked and expired ones. Key values are never returned.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=[]APIKeyResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/api-keys [get]
+func (h *APIKeyHandler) ListKeys(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	keys, err := h.apiKeyService.ListKeys(c.Request().Context(), principal.From(c).UserID)
+	if err != nil {
+		return apiKeyErrorResponse(c, err)
+	}
+
+	data := make([]APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		data = append(data, NewAPIKeyResponse(k))
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "API keys found successfully"
+	res.Data = data
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * RotateKey handles the HTTP request for replacing the value of one of the caller's API keys.
+ * The old value stops working immediately.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Rotate an API key
+// @Description Replaces the key value, keeping name, scopes and expiry. The old value stops working immediately and the new one is shown only in this response.
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=IssuedAPIKeyResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateKey(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	id, err := parseAPIKeyID(c)
+	if err != nil {
+		return invalidAPIKeyIDResponse(c, err)
+	}
+
+	issued, err := h.apiKeyService.RotateKey(c.Request().Context(), principal.From(c).UserID, id)
+	if err != nil {
+		return apiKeyErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "API key rotated, store it now as it will not be shown again"
+	res.Data = IssuedAPIKeyResponse{NewAPIKeyResponse(issued.APIKey), issued.Key}
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * RevokeKey handles the HTTP request for revoking one of the caller's API keys.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Revoke an API key
+// @Description Disables an API key for good
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=APIKeyResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeKey(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	id, err := parseAPIKeyID(c)
+	if err != nil {
+		return invalidAPIKeyIDResponse(c, err)
+	}
+
+	revoked, err := h.apiKeyService.RevokeKey(c.Request().Context(), principal.From(c).UserID, id)
+	if err != nil {
+		return apiKeyErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "API key revoked"
+	res.Data = NewAPIKeyResponse(revoked)
+	return c.JSON(http.StatusOK, res)
+}
+
+// parseAPIKeyID reads the API key ID path parameter
+func parseAPIKeyID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// invalidAPIKeyIDResponse writes the response for a malformed API key ID
+func invalidAPIKeyIDResponse(c echo.Context, err error) error {
+	return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+		Code:    http.StatusBadRequest,
+		Message: "Invalid API key ID",
+		Data:    err.Error(),
+	})
+}
+
+// apiKeyErrorResponse maps errors from the apikey service to HTTP responses
+func apiKeyErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, apikey.ErrKeyNotFound):
+		return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+			Code:    http.StatusNotFound,
+			Message: "API key not found",
+		})
+	case errors.Is(err, apikey.ErrKeyRevoked):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: "API key is revoked",
+		})
+	case errors.Is(err, apikey.ErrScopeNotGranted), errors.Is(err, apikey.ErrExpiryInPast):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid API key data",
+			Data:    err.Error(),
+		})
+	default:
+		c.Logger().Errorf("failed to process API key request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+func TestUserChangesAreAudited(t *testing.T) {
+	s := newServer(t, withAuditRoutes())
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+
+	if rec := serveConditional(s.e, http.MethodPatch, path, "If-Match", `"1"`, `{"name":"renamed"}`); rec.Code != http.StatusOK {
+		t.Fatalf("PATCH: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec := serveConditional(s.e, http.MethodDelete, path, "If-Match", `"2"`, ""); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(s.e, http.MethodGet, "/api/v1/audit?resource=user", "acme", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /audit: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var res struct {
+		Data []handler.AuditEventResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(res.Data) != 2 {
+		t.Fatalf("events = %+v, want the delete and the update", res.Data)
+	}
+
+	deleted, updated := res.Data[0], res.Data[1]
+	if deleted.Action != audit.ActionDelete || updated.Action != audit.ActionUpdate {
+		t.Fatalf("actions = %s, %s; want delete, update newest first", deleted.Action, updated.Action)
+	}
+	if updated.ActorType != audit.ActorAdmin || updated.ActorID != 0 {
+		t.Errorf("update actor = %s %d, want the admin token", updated.ActorType, updated.ActorID)
+	}
+	if got := updated.Changes["name"]; got.Before != "acme" || got.After != "renamed" {
+		t.Errorf("update name change = %+v, want acme -> renamed", got)
+	}
+	if _, ok := updated.Changes["email"]; ok {
+		t.Errorf("update changes = %+v, want only the changed fields", updated.Changes)
+	}
+	if deleted.PrevHash != updated.Hash {
+		t.Errorf("delete prev_hash = %s, want the update's hash %s", deleted.PrevHash, updated.Hash)
+	}
+
+	if rec = serve(s.e, http.MethodGet, "/api/v1/audit", "globex", ""); rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &res) != nil || len(res.Data) != 0 {
+		t.Fatalf("GET /audit as globex: status = %d, body %s; want no events", rec.Code, rec.Body)
+	}
+}
+
+func TestAuditVerifyDetectsTampering(t *testing.T) {
+	s := newServer(t, withAuditRoutes())
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+	for version, name := range []string{"one", "two", "three"} {
+		etag := `"` + strconv.Itoa(version+1) + `"`
+		if rec := serveConditional(s.e, http.MethodPatch, path, "If-Match", etag, `{"name":"`+name+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("PATCH %s: status = %d, want 200: %s", name, rec.Code, rec.Body)
+		}
+	}
+
+	ctx := tenancy.AllTenants(context.Background())
+	auditService := audit.NewService(audit.NewRepository(s.db))
+	report, err := auditService.Verify(ctx)
+	if err != nil || !report.Intact() || report.Events != 3 {
+		t.Fatalf("Verify = %+v, %v; want 3 intact events", report, err)
+	}
+
+	var events []audit.Event
+	if err = s.db.WithContext(ctx).Order("id").Find(&events).Error; err != nil {
+		t.Fatalf("load events: %v", err)
+	}
+	if err = s.db.WithContext(ctx).Delete(&events[0]).Error; !errors.Is(err, audit.ErrAppendOnly) {
+		t.Fatalf("delete through the model: err = %v, want ErrAppendOnly", err)
+	}
+
+	// Rewrite a recorded value behind the application's back
+	if err = s.db.Exec("UPDATE audit_events SET changes = ? WHERE id = ?", `{"name":{"before":"x","after":"y"}}`, events[1].ID).Error; err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	report, err = auditService.Verify(ctx)
+	if err != nil || len(report.Breaks) != 1 || report.Breaks[0].EventID != events[1].ID {
+		t.Fatalf("Verify after edit = %+v, %v; want a break at event %d", report, err, events[1].ID)
+	}
+
+	// Remove the first event
+	if err = s.db.Exec("DELETE FROM audit_events WHERE id = ?", events[0].ID).Error; err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	report, err = auditService.Verify(ctx)
+	if err != nil || len(report.Breaks) != 2 || report.Breaks[0].EventID != events[1].ID {
+		t.Fatalf("Verify after removal = %+v, %v; want breaks from event %d", report, err, events[1].ID)
+	}
+}
+
+func TestUserChangesAreNotKeptWithoutTheirAuditEvent(t *testing.T) {
+	s := newServer(t, withAuditRoutes())
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+	if err := s.db.Migrator().DropTable(&audit.Event{}); err != nil {
+		t.Fatalf("drop audit table: %v", err)
+	}
+
+	if rec := serveConditional(s.e, http.MethodPatch, path, "If-Match", `"1"`, `{"name":"renamed"}`); rec.Code != http.StatusInternalServerError {
+		t.Errorf("PATCH: status = %d, want 500: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(s.e, http.MethodPost, "/api/v1/users", "acme", `{"name":"New","email":"new@acme.example.com","password":"Secr3tPassword"}`); rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "no such table") {
+		t.Errorf("POST: status = %d, body %s; want 500 without the database error", rec.Code, rec.Body)
+	}
+
+	rec := serve(s.e, http.MethodGet, path, "acme", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"acme"`) || rec.Header().Get("ETag") != `"1"` {
+		t.Errorf("user after failed PATCH = %s, ETag %s; want it unchanged", rec.Body, rec.Header().Get("ETag"))
+	}
+	if rec = serve(s.e, http.MethodGet, "/api/v1/users?name_contains=New", "acme", ""); strings.Contains(rec.Body.String(), "new@acme.example.com") {
+		t.Errorf("users after failed POST = %s, want no new user", rec.Body)
+	}
+}
+
+func TestListPagesAreBounded(t *testing.T) {
+	s := newServer(t, withAuditRoutes())
+
+	// A page this far out would overflow the offset into a negative one
+	for _, path := range []string{"/api/v1/users?page=9223372036854775807", "/api/v1/audit?page=9223372036854775807"} {
+		if rec := serve(s.e, http.MethodGet, path, "acme", ""); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("GET %s: status = %d, want 422: %s", path, rec.Code, rec.Body)
+		}
+	}
+}
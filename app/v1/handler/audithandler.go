@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/config"
+	"github.com/ranggaaprilio/boilerGo/helper"
+)
+
+/**
+ * AuditHandler handles HTTP requests for reading the audit log.
+ * It depends on the audit service for the recorded events.
+ */
+type AuditHandler struct {
+	auditService audit.Service
+	pagination   config.PaginationConfigurations
+}
+
+// AuditEventResponse represents a recorded change in API responses
+type AuditEventResponse struct {
+	ID         uint   `json:"id" example:"42"`
+	Resource   string `json:"resource" example:"user"`
+	ResourceID uint   `json:"resource_id" example:"1"`
+	Action     string `json:"action" example:"update"`
+	ActorType  string `json:"actor_type" example:"user"`
+	ActorID    uint   `json:"actor_id" example:"7"`
+	RequestID  string `json:"request_id" example:"4f3c2a9e8b7d6c5f4e3a2b1c0d9e8f7a"`
+	// Changes maps each changed field to its value before and after the
+	// change. It is null once the changes were redacted on erasure.
+	Changes    map[string]audit.FieldChange `json:"changes" swaggertype:"object"`
+	RedactedAt *string                      `json:"redacted_at,omitempty" example:"2025-07-01T08:00:00+07:00"`
+	CreatedAt  string                       `json:"created_at" example:"2025-06-15T19:22:47.091+07:00"`
+	PrevHash   string                       `json:"prev_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Hash       string                       `json:"hash" example:"60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"`
+}
+
+// NewAuditEventResponse converts an audit event into its API representation
+func NewAuditEventResponse(e audit.Event) AuditEventResponse {
+	res := AuditEventResponse{
+		ID:         e.ID,
+		Resource:   e.Resource,
+		ResourceID: e.ResourceID,
+		Action:     e.Action,
+		ActorType:  e.ActorType,
+		ActorID:    e.ActorID,
+		RequestID:  e.RequestID,
+		RedactedAt: formatOptionalTime(e.RedactedAt),
+		CreatedAt:  e.CreatedAt.Format(timestampLayout),
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+	}
+	if e.RedactedAt == nil {
+		_ = json.Unmarshal([]byte(e.Changes), &res.Changes)
+	}
+	return res
+}
+
+/**
+ * NewAuditHandler creates a new instance of AuditHandler with the provided audit service.
+ *
+ * @param auditService The service that keeps the audit log
+ * @param pagination The default and maximum page sizes of listings
+ * @return A pointer to a new AuditHandler instance
+ */
+func NewAuditHandler(auditService audit.Service, pagination config.PaginationConfigurations) *AuditHandler {
+	return &AuditHandler{auditService, pagination}
+}
+
+/**
+ * ListEvents handles the HTTP request for listing the changes recorded in the
+ * current tenant's audit log, newest first, narrowed down by the query filters.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List audit events
+// @Description Lists recorded changes of the current tenant, newest first. Every event carries the hash of the one before it; run `audit verify` to check the chain. Requires the audit:read permission.
+// @Tags audit
+// @Produce json
+// @Param resource query string false "Only events of this resource type" example(user)
+// @Param resource_id query int false "Only events of this resource"
+// @Param action query string false "Only events of this action" Enums(create, update, delete, restore, purge, erase)
+// @Param actor_id query int false "Only changes made by this user"
+// @Param since query string false "Only events recorded at or after this RFC 3339 timestamp"
+// @Param until query string false "Only events recorded before this RFC 3339 timestamp"
+// @Param page query int false "Page number" minimum(1) maximum(100000)
+// @Param per_page query int false "Page size, capped at the configured maximum" minimum(1)
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.PaginatedResponse{data=[]AuditEventResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/audit [get]
+func (h *AuditHandler) ListEvents(c echo.Context) error {
+	req := new(audit.ListEventsQuery)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Failed Form Binding",
+			Data:    err.Error(),
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	filter, err := req.Filter()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid query parameters",
+			Data:    err.Error(),
+		})
+	}
+
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	perPage := req.PerPage
+	if perPage < 1 {
+		perPage = h.pagination.DefaultPageSize
+	}
+	if perPage > h.pagination.MaxPageSize {
+		perPage = h.pagination.MaxPageSize
+	}
+
+	result, err := h.auditService.List(c.Request().Context(), filter, perPage, (page-1)*perPage)
+	if err != nil {
+		c.Logger().Errorf("failed to list audit events: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+
+	meta := helper.PaginationMeta{
+		Total:   result.Total,
+		Page:    page,
+		PerPage: perPage,
+	}
+	if links := listLinks(c, meta); len(links) > 0 {
+		c.Response().Header().Set("Link", helper.LinkHeader(links))
+	}
+
+	events := make([]AuditEventResponse, 0, len(result.Events))
+	for _, e := range result.Events {
+		events = append(events, NewAuditEventResponse(e))
+	}
+
+	return c.JSON(http.StatusOK, helper.PaginatedResponse{
+		Code:    http.StatusOK,
+		Message: "Audit events listed successfully",
+		Data:    events,
+		Meta:    meta,
+	})
+}
@@ -0,0 +1,478 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/auth"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/lockout"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/passwordreset"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/refreshtoken"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/twofactor"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/verification"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+/**
+ * AuthHandler handles HTTP requests related to authentication.
+ * It depends on the auth service for credential checks and token issuance.
+ */
+type AuthHandler struct {
+	authService          auth.Service
+	verificationService  verification.Service
+	passwordResetService passwordreset.Service
+}
+
+/**
+ * NewAuthHandler creates a new instance of AuthHandler with the provided auth service.
+ *
+ * @param authService The service that handles authentication
+ * @param verificationService The service that confirms email addresses
+ * @param passwordResetService The service that resets forgotten passwords
+ * @return A pointer to a new AuthHandler instance
+ */
+func NewAuthHandler(authService auth.Service, verificationService verification.Service, passwordResetService passwordreset.Service) *AuthHandler {
+	return &AuthHandler{authService, verificationService, passwordResetService}
+}
+
+/**
+ * Login handles the HTTP request for logging in with email and password.
+ * On success it returns a short-lived bearer access token and a refresh token,
+ * or a challenge when the user has two-factor authentication enabled.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Log in
+// @Description Exchanges an email and password for a short-lived bearer access token and a refresh token. When the user has two-factor authentication enabled the data is an auth.ChallengeResponse instead, to be completed at /v1/auth/login/2fa. Repeated failures for an email or from a client IP are delayed and then locked out with 429 and a Retry-After header.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body auth.LoginForm true "Login credentials"
+// @Success 200 {object} helper.SuccessResponse{data=auth.TokenResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 429 {object} helper.TooManyRequestsResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/login [post]
+func (h *AuthHandler) Login(c echo.Context) error {
+	req := new(auth.LoginForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	result, err := h.authService.Login(c.Request().Context(), req, c.RealIP())
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	if result.Challenge != nil {
+		res.Message = "Two-factor authentication required"
+		res.Data = result.Challenge
+		return c.JSON(http.StatusOK, res)
+	}
+
+	res.Message = "Login successful"
+	res.Data = result.Tokens
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * LoginSecondFactor handles the HTTP request completing a login that needs a
+ * second factor. It takes the challenge token returned by Login and a code
+ * from the authenticator app or a recovery code.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Complete a two-factor login
+// @Description Exchanges a login challenge and a TOTP or recovery code for an access token and a refresh token. Each code works once. Wrong codes count as failed logins.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.SecondFactorForm true "Challenge token and code"
+// @Success 200 {object} helper.SuccessResponse{data=auth.TokenResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 429 {object} helper.TooManyRequestsResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/login/2fa [post]
+func (h *AuthHandler) LoginSecondFactor(c echo.Context) error {
+	req := new(auth.SecondFactorForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	tokens, err := h.authService.LoginSecondFactor(c.Request().Context(), req, c.RealIP())
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Login successful"
+	res.Data = tokens
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * Refresh handles the HTTP request for exchanging a refresh token.
+ * The refresh token is rotated: the response carries a new one and the old one
+ * stops working. Reusing an old refresh token revokes the whole session.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Refresh tokens
+// @Description Rotates a refresh token and issues a new access token. Reusing a rotated refresh token revokes every token of that login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body auth.RefreshForm true "Refresh token"
+// @Success 200 {object} helper.SuccessResponse{data=auth.TokenResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/refresh [post]
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	req := new(auth.RefreshForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	tokens, err := h.authService.Refresh(c.Request().Context(), req)
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Token refreshed"
+	res.Data = tokens
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * Logout handles the HTTP request for ending a session.
+ * It revokes the given refresh token together with every token rotated from
+ * the same login. Unknown tokens are accepted so logging out is idempotent.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Log out
+// @Description Revokes the session the refresh token belongs to
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body auth.RefreshForm true "Refresh token"
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/logout [post]
+func (h *AuthHandler) Logout(c echo.Context) error {
+	req := new(auth.RefreshForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	if err := h.authService.Logout(c.Request().Context(), req); err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Logged out"
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * LogoutAll handles the HTTP request for ending every session of the caller.
+ * It requires a bearer access token and revokes all refresh tokens of that user.
+ * Access tokens already issued stay valid until they expire.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Log out everywhere
+// @Description Revokes every refresh token of the authenticated user. Issued access tokens stay valid until they expire.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	if err := h.authService.LogoutAll(c.Request().Context(), principal.From(c).UserID); err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Logged out of all sessions"
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * VerifyEmail handles the HTTP request sent by the link in verification emails.
+ * It marks the email in the token as verified. Each token works once.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Verify an email address
+// @Description Confirms the email address a verification link was sent to. Each link works once and expires after auth.verification_token_ttl.
+// @Tags auth
+// @Produce json
+// @Param token query string true "Token from the verification email"
+// @Success 200 {object} helper.SuccessResponse{data=UserResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/verify [get]
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	verifiedUser, err := h.verificationService.Verify(c.Request().Context(), c.QueryParam("token"))
+	if err != nil {
+		return verificationErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Email verified successfully"
+	res.Data = NewUserResponse(verifiedUser)
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * ResendVerification handles the HTTP request for mailing a new verification link
+ * to the authenticated user.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Resend the verification email
+// @Description Mails a new verification link to the authenticated user's email address
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/verify/resend [post]
+func (h *AuthHandler) ResendVerification(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	if err := h.verificationService.Resend(c.Request().Context(), principal.From(c).UserID); err != nil {
+		return verificationErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Verification email sent"
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * ForgotPassword handles the HTTP request for a password reset link.
+ * The response is the same whether or not the email is registered, and
+ * failures are only logged, so it cannot be used to discover accounts.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Request a password reset
+// @Description Mails a single-use password reset link when the email is registered. The response does not reveal whether it is.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body passwordreset.ForgotPasswordForm true "Account email"
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Router /v1/auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	req := new(passwordreset.ForgotPasswordForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	if err := h.passwordResetService.RequestReset(c.Request().Context(), req); err != nil {
+		c.Logger().Errorf("failed to process password reset request: %v", err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "If the email is registered, a password reset link has been sent"
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * ResetPassword handles the HTTP request for choosing a new password with a
+ * reset token. Every session of the user is revoked on success.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Reset a password
+// @Description Sets a new password using the token from a password reset email. The token works once, and every refresh token of the user is revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body passwordreset.ResetPasswordForm true "Reset token and new password"
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	req := new(passwordreset.ResetPasswordForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	if err := h.passwordResetService.ResetPassword(c.Request().Context(), req); err != nil {
+		if errors.Is(err, passwordreset.ErrInvalidToken) {
+			return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid or expired password reset link",
+			})
+		}
+		c.Logger().Errorf("failed to reset password: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Password has been reset"
+	return c.JSON(http.StatusOK, res)
+}
+
+// verificationErrorResponse maps errors from the verification service to HTTP responses
+func verificationErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, verification.ErrInvalidToken):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid or expired verification link",
+		})
+	case errors.Is(err, verification.ErrAlreadyVerified):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: "Email is already verified",
+		})
+	case errors.Is(err, verification.ErrNoEmail):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: "No email address to verify",
+		})
+	case errors.Is(err, user.ErrUserNotFound):
+		return userErrorResponse(c, err)
+	default:
+		c.Logger().Errorf("failed to process verification request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
+
+// authErrorResponse maps auth service errors to HTTP responses
+func authErrorResponse(c echo.Context, err error) error {
+	var blocked *lockout.BlockedError
+	if errors.As(err, &blocked) {
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.FormatInt(blocked.RetryAfter(time.Now()), 10))
+		return c.JSON(http.StatusTooManyRequests, helper.TooManyRequestsResponse{
+			Code:    http.StatusTooManyRequests,
+			Message: "Too many failed login attempts, try again later",
+			Data:    blocked.Error(),
+		})
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials),
+		errors.Is(err, auth.ErrInvalidChallenge),
+		errors.Is(err, twofactor.ErrInvalidCode),
+		errors.Is(err, twofactor.ErrNotEnabled),
+		errors.Is(err, refreshtoken.ErrInvalidToken),
+		errors.Is(err, refreshtoken.ErrTokenReused):
+		return c.JSON(http.StatusUnauthorized, helper.UnauthorizedResponse{
+			Code:    http.StatusUnauthorized,
+			Message: err.Error(),
+		})
+	}
+	c.Logger().Errorf("failed to process auth request: %v", err)
+	return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Message: "Oops sorry, Failed to process data",
+	})
+}
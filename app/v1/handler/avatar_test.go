@@ -0,0 +1,237 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+	routes "github.com/ranggaaprilio/boilerGo/internal/server/routes/v1"
+	"github.com/ranggaaprilio/boilerGo/internal/storage"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// pngImage encodes a width by height PNG
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// uploadAvatar sends data as the avatar field of a multipart form to acme
+func uploadAvatar(e *echo.Echo, path, filename string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("avatar", filename)
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPut, path, &body)
+	req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+	req.Header.Set(middlewares.HeaderAdminToken, adminToken)
+	req.Header.Set("X-Tenant", "acme")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// download fetches a signed URL without any credentials or tenant
+func download(e *echo.Echo, url string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	return rec
+}
+
+func decodeAvatar(t *testing.T, rec *httptest.ResponseRecorder) handler.AvatarResponse {
+	t.Helper()
+	var res struct {
+		Data handler.AvatarResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return res.Data
+}
+
+func TestAvatarUpload(t *testing.T) {
+	s := newServer(t, withAvatars(), withPrivacy(avatarData))
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10) + "/avatar"
+
+	if rec := serve(s.e, http.MethodGet, path, "acme", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET before upload: status = %d, want 404", rec.Code)
+	}
+
+	rec := uploadAvatar(s.e, path, "me.png", pngImage(t, 40, 20))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("ETag = %s, want the user's new version", got)
+	}
+	first := decodeAvatar(t, rec)
+
+	original := download(s.e, first.URL)
+	if original.Code != http.StatusOK || original.Header().Get(echo.HeaderContentType) != "image/png" {
+		t.Fatalf("download original: status = %d, type %q: %s", original.Code, original.Header().Get(echo.HeaderContentType), original.Body)
+	}
+	thumb := download(s.e, first.ThumbnailURL)
+	if thumb.Code != http.StatusOK {
+		t.Fatalf("download thumbnail: status = %d: %s", thumb.Code, thumb.Body)
+	}
+	config, err := png.DecodeConfig(thumb.Body)
+	if err != nil || config.Width != 16 || config.Height != 16 {
+		t.Errorf("thumbnail = %dx%d, %v; want a 16x16 PNG", config.Width, config.Height, err)
+	}
+
+	var event audit.Event
+	if err = s.db.WithContext(tenancy.AllTenants(context.Background())).Where("resource_id = ?", s.acme).Last(&event).Error; err != nil || !strings.Contains(event.Changes, `"avatar":{"before":null`) {
+		t.Errorf("audit event = %+v, %v; want the avatar change recorded", event, err)
+	}
+
+	// Replacing the avatar deletes the previous images
+	if rec = uploadAvatar(s.e, path, "new.png", pngImage(t, 10, 10)); rec.Code != http.StatusOK {
+		t.Fatalf("second upload: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if second := decodeAvatar(t, rec); second.URL == first.URL {
+		t.Errorf("second upload URL = %s, want a new key", second.URL)
+	}
+	if rec = download(s.e, first.URL); rec.Code != http.StatusNotFound {
+		t.Errorf("download of replaced avatar: status = %d, want 404", rec.Code)
+	}
+
+	if rec = serve(s.e, http.MethodDelete, path, "acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec = serve(s.e, http.MethodGet, path, "acme", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET after delete: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestAvatarUploadLimits(t *testing.T) {
+	s := newServer(t, withAvatars(), withPrivacy(avatarData))
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10) + "/avatar"
+
+	oversized := append(pngImage(t, 4, 4), make([]byte, avatarMaxSize)...)
+	cases := []struct {
+		name     string
+		filename string
+		data     []byte
+		want     int
+	}{
+		{"script named like an image", "avatar.png", []byte("<script>alert(1)</script>"), http.StatusUnsupportedMediaType},
+		{"too large", "big.png", oversized, http.StatusRequestEntityTooLarge},
+		{"corrupt image", "broken.png", append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...), http.StatusUnprocessableEntity},
+		{"empty file", "empty.png", nil, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if rec := uploadAvatar(s.e, path, tc.filename, tc.data); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d: %s", tc.name, rec.Code, tc.want, rec.Body)
+		}
+	}
+
+	if rec := serve(s.e, http.MethodPut, path, "acme", `{"avatar":"x"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("JSON body: status = %d, want 400", rec.Code)
+	}
+	globexPath := "/api/v1/users/" + strconv.FormatUint(uint64(s.globex), 10) + "/avatar"
+	if rec := uploadAvatar(s.e, globexPath, "me.png", pngImage(t, 4, 4)); rec.Code != http.StatusNotFound {
+		t.Errorf("upload for another tenant's user: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestSignedDownloadURLs(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	files := storage.NewMemoryStorage()
+	signer, err := storage.NewURLSigner("test-secret", "/api/v1/files", time.Minute, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("URL signer: %v", err)
+	}
+	e := echo.New()
+	routes.SetupFileRoutes(e, handler.NewFileHandler(files, signer))
+
+	if err := files.Put(context.Background(), "docs/a.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	url, expires := signer.URL("docs/a.txt")
+	if !expires.Equal(now.Add(time.Minute)) {
+		t.Errorf("expires = %v, want a minute from now", expires)
+	}
+
+	rec := download(e, url)
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello" {
+		t.Fatalf("download: status = %d, body %q", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get(echo.HeaderXContentTypeOptions); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+
+	for name, tampered := range map[string]string{
+		"other key":         strings.Replace(url, "docs/a.txt", "docs/b.txt", 1),
+		"extended expiry":   strings.Replace(url, "expires=", "expires=9", 1),
+		"missing signature": url[:strings.Index(url, "&signature=")],
+	} {
+		if rec = download(e, tampered); rec.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", name, rec.Code)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if rec = download(e, url); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "expired") {
+		t.Errorf("expired URL: status = %d, want 403 expired: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAvatarPersonalData(t *testing.T) {
+	s := newServer(t, withAvatars(), withPrivacy(avatarData))
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+	image := pngImage(t, 8, 8)
+	rec := uploadAvatar(s.e, path+"/avatar", "me.png", image)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	urls := decodeAvatar(t, rec)
+
+	rec = serve(s.e, http.MethodGet, path+"/personal-data", "acme", "")
+	var export struct {
+		Data struct {
+			Data struct {
+				Avatar struct {
+					ContentType string `json:"content_type"`
+					Image       []byte `json:"image"`
+				} `json:"avatar"`
+			} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &export); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if got := export.Data.Data.Avatar; got.ContentType != "image/png" || !bytes.Equal(got.Image, image) {
+		t.Errorf("exported avatar = %s, %d bytes; want the uploaded PNG", got.ContentType, len(got.Image))
+	}
+
+	if rec = serve(s.e, http.MethodPost, path+"/erasure", "acme", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"avatar":2`) {
+		t.Fatalf("erase: status = %d, want 200 erasing both images: %s", rec.Code, rec.Body)
+	}
+	if rec = download(s.e, urls.URL); rec.Code != http.StatusNotFound {
+		t.Errorf("download after erasure: status = %d, want 404", rec.Code)
+	}
+}
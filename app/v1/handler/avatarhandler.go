@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/avatar"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+	"github.com/ranggaaprilio/boilerGo/internal/storage"
+)
+
+// avatarField is the multipart form field carrying an uploaded avatar
+const avatarField = "avatar"
+
+/**
+ * AvatarHandler handles HTTP requests for uploading, reading and removing
+ * user avatars. It depends on the avatar service, which stores the images
+ * and signs their download URLs.
+ */
+type AvatarHandler struct {
+	avatarService avatar.Service
+	limits        storage.Limits
+}
+
+// AvatarResponse represents the signed download URLs of an avatar in API responses
+type AvatarResponse struct {
+	URL          string `json:"url" example:"/api/v1/files/avatars/1/3f2a9c.png?expires=1750000000&signature=9f86d0"`
+	ThumbnailURL string `json:"thumbnail_url" example:"/api/v1/files/avatars/1/3f2a9c_128.png?expires=1750000000&signature=60303a"`
+	ExpiresAt    string `json:"expires_at" example:"2025-06-15T19:37:47.000+07:00"`
+}
+
+/**
+ * NewAvatarHandler creates a new instance of AvatarHandler with the provided avatar service.
+ *
+ * @param avatarService The service that stores avatars and signs their URLs
+ * @param maxSize The largest accepted upload in bytes
+ * @return A pointer to a new AvatarHandler instance
+ */
+func NewAvatarHandler(avatarService avatar.Service, maxSize int64) *AvatarHandler {
+	return &AvatarHandler{avatarService, storage.Limits{MaxSize: maxSize, Types: avatar.ImageTypes}}
+}
+
+/**
+ * UploadCurrentUserAvatar handles the HTTP request for replacing the
+ * authenticated user's avatar.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Upload my avatar
+// @Description Replaces the current user's avatar with a PNG, JPEG, GIF or WebP image of at most 4096x4096 pixels. The type is detected from the file's content. A square thumbnail is generated and signed download URLs of both are returned.
+// @Tags avatars
+// @Accept multipart/form-data
+// @Produce json
+// @Param avatar formData file true "Avatar image"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} helper.SuccessResponse{data=AvatarResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 413 {object} helper.PayloadTooLargeResponse
+// @Failure 415 {object} helper.UnsupportedMediaTypeResponse
+// @Failure 422 {object} helper.UnprocessableEntityResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/me/avatar [put]
+func (h *AvatarHandler) UploadCurrentUserAvatar(c echo.Context) error {
+	return h.upload(c, principal.From(c).UserID)
+}
+
+/**
+ * UploadUserAvatar handles the HTTP request for replacing a user's avatar.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Upload a user's avatar
+// @Description Replaces a user's avatar as for the current user's own upload. Requires the users:write permission.
+// @Tags avatars
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "User ID"
+// @Param avatar formData file true "Avatar image"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=AvatarResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 413 {object} helper.PayloadTooLargeResponse
+// @Failure 415 {object} helper.UnsupportedMediaTypeResponse
+// @Failure 422 {object} helper.UnprocessableEntityResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/avatar [put]
+func (h *AvatarHandler) UploadUserAvatar(c echo.Context) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+	return h.upload(c, id)
+}
+
+/**
+ * GetCurrentUserAvatar handles the HTTP request for the signed download URLs
+ * of the authenticated user's avatar.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Get my avatar
+// @Description Returns signed download URLs of the current user's avatar and its thumbnail. The URLs need no credentials and stop working at expires_at.
+// @Tags avatars
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} helper.SuccessResponse{data=AvatarResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/me/avatar [get]
+func (h *AvatarHandler) GetCurrentUserAvatar(c echo.Context) error {
+	return h.urls(c, principal.From(c).UserID)
+}
+
+/**
+ * GetUserAvatar handles the HTTP request for the signed download URLs of a
+ * user's avatar.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Get a user's avatar
+// @Description Returns signed download URLs of a user's avatar and its thumbnail. Requires the users:read permission.
+// @Tags avatars
+// @Produce json
+// @Param id path int true "User ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=AvatarResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/avatar [get]
+func (h *AvatarHandler) GetUserAvatar(c echo.Context) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+	return h.urls(c, id)
+}
+
+/**
+ * DeleteCurrentUserAvatar handles the HTTP request for removing the
+ * authenticated user's avatar.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Remove my avatar
+// @Description Removes the current user's avatar and deletes its images
+// @Tags avatars
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/me/avatar [delete]
+func (h *AvatarHandler) DeleteCurrentUserAvatar(c echo.Context) error {
+	return h.remove(c, principal.From(c).UserID)
+}
+
+/**
+ * DeleteUserAvatar handles the HTTP request for removing a user's avatar.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Remove a user's avatar
+// @Description Removes a user's avatar and deletes its images. Requires the users:write permission.
+// @Tags avatars
+// @Produce json
+// @Param id path int true "User ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/avatar [delete]
+func (h *AvatarHandler) DeleteUserAvatar(c echo.Context) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+	return h.remove(c, id)
+}
+
+// upload reads the uploaded image, makes it the user's avatar and writes its URLs
+func (h *AvatarHandler) upload(c echo.Context, userID uint) error {
+	upload, err := storage.ReadUpload(c.Request(), avatarField, h.limits)
+	if err != nil {
+		return avatarErrorResponse(c, err)
+	}
+
+	updated, err := h.avatarService.Upload(c.Request().Context(), userID, upload)
+	if err != nil {
+		return avatarErrorResponse(c, err)
+	}
+	setUserETag(c, updated)
+
+	return h.urls(c, userID)
+}
+
+// urls writes the signed download URLs of the user's avatar
+func (h *AvatarHandler) urls(c echo.Context, userID uint) error {
+	urls, err := h.avatarService.URLs(c.Request().Context(), userID)
+	if err != nil {
+		return avatarErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: "Avatar retrieved successfully",
+		Data: AvatarResponse{
+			URL:          urls.URL,
+			ThumbnailURL: urls.ThumbnailURL,
+			ExpiresAt:    urls.ExpiresAt.Format(timestampLayout),
+		},
+	})
+}
+
+// remove clears the user's avatar
+func (h *AvatarHandler) remove(c echo.Context, userID uint) error {
+	updated, err := h.avatarService.Remove(c.Request().Context(), userID)
+	if err != nil {
+		return avatarErrorResponse(c, err)
+	}
+	setUserETag(c, updated)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: "Avatar removed successfully",
+	})
+}
+
+// avatarErrorResponse maps errors from reading uploads and from the avatar
+// service to HTTP responses, leaving user errors to userErrorResponse
+func avatarErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, avatar.ErrNoAvatar):
+		return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+			Code:    http.StatusNotFound,
+			Message: "User has no avatar",
+		})
+	case errors.Is(err, storage.ErrTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, helper.PayloadTooLargeResponse{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: "Avatar is too large",
+			Data:    err.Error(),
+		})
+	case errors.Is(err, storage.ErrUnsupportedType):
+		return c.JSON(http.StatusUnsupportedMediaType, helper.UnsupportedMediaTypeResponse{
+			Code:    http.StatusUnsupportedMediaType,
+			Message: "Avatar must be a PNG, JPEG, GIF or WebP image",
+			Data:    err.Error(),
+		})
+	case errors.Is(err, avatar.ErrInvalidImage), errors.Is(err, avatar.ErrImageDimensions):
+		return c.JSON(http.StatusUnprocessableEntity, helper.UnprocessableEntityResponse{
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("Avatar must be a valid image of at most %dx%d pixels", avatar.MaxDimension, avatar.MaxDimension),
+			Data:    err.Error(),
+		})
+	case errors.Is(err, storage.ErrNoFile), errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: `Upload the avatar as multipart/form-data in the "` + avatarField + `" field`,
+			Data:    err.Error(),
+		})
+	default:
+		return userErrorResponse(c, err)
+	}
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/storage"
+)
+
+/**
+ * FileHandler serves stored files through signed download URLs. The URL is
+ * the credential, so the handler runs outside tenant resolution and
+ * authentication and can be used directly in <img> tags.
+ */
+type FileHandler struct {
+	store  storage.Storage
+	signer *storage.URLSigner
+}
+
+/**
+ * NewFileHandler creates a new instance of FileHandler with the provided storage and signer.
+ *
+ * @param store The storage the files are read from
+ * @param signer The signer that issued the download URLs
+ * @return A pointer to a new FileHandler instance
+ */
+func NewFileHandler(store storage.Storage, signer *storage.URLSigner) *FileHandler {
+	return &FileHandler{store, signer}
+}
+
+/**
+ * Download handles the HTTP request for a file behind a signed URL.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Download a file
+// @Description Streams a stored file, such as an avatar, to anyone holding a signed download URL that has not expired. Signed URLs are returned by the endpoints that own the files.
+// @Tags files
+// @Produce octet-stream
+// @Param key path string true "Object key"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param signature query string true "Signature of the key and expiry"
+// @Success 200 {file} binary
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/files/{key} [get]
+func (h *FileHandler) Download(c echo.Context) error {
+	key, err := url.PathUnescape(c.Param("*"))
+	if err == nil {
+		err = h.signer.Verify(key, c.QueryParam("expires"), c.QueryParam("signature"))
+	}
+	if err != nil {
+		message := "Download URL is invalid"
+		if errors.Is(err, storage.ErrURLExpired) {
+			message = "Download URL has expired"
+		}
+		return c.JSON(http.StatusForbidden, helper.ForbiddenResponse{
+			Code:    http.StatusForbidden,
+			Message: message,
+		})
+	}
+
+	body, object, err := h.store.Get(c.Request().Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+			Code:    http.StatusNotFound,
+			Message: "File not found",
+		})
+	}
+	if err != nil {
+		c.Logger().Errorf("failed to read file: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to read file",
+		})
+	}
+	defer body.Close()
+
+	// Browsers may cache the file while the URL is valid, but shared caches
+	// may not, and the declared type is never second-guessed
+	expires, _ := strconv.ParseInt(c.QueryParam("expires"), 10, 64)
+	maxAge := time.Until(time.Unix(expires, 0)) / time.Second
+	header := c.Response().Header()
+	header.Set("Cache-Control", "private, max-age="+strconv.FormatInt(int64(maxAge), 10))
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	if object.Size >= 0 {
+		header.Set(echo.HeaderContentLength, strconv.FormatInt(object.Size, 10))
+	}
+	return c.Stream(http.StatusOK, object.ContentType, body)
+}
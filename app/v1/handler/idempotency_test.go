@@ -0,0 +1,156 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/internal/idempotency"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
+)
+
+// idempotencyStores are the key stores every idempotency test runs against
+var idempotencyStores = map[string]func(db *gorm.DB) idempotency.Store{
+	"memory":   func(*gorm.DB) idempotency.Store { return idempotency.NewMemoryStore() },
+	"database": func(db *gorm.DB) idempotency.Store { return idempotency.NewDatabaseStore(db) },
+}
+
+// blockingAuditor holds user changes until release is closed, keeping the
+// request that made them in flight
+type blockingAuditor struct {
+	user.Auditor
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (a *blockingAuditor) Transaction(ctx context.Context, fn func(tx *gorm.DB, recorder audit.Recorder) error) error {
+	a.once.Do(func() { close(a.started) })
+	<-a.release
+	return a.Auditor.Transaction(ctx, fn)
+}
+
+// newIdempotencyServer retries user creations, and the creations of
+// /api/v1/large whose responses are too large to keep, with the keys of the
+// store made by newStore
+func newIdempotencyServer(t *testing.T, newStore func(db *gorm.DB) idempotency.Store, opts ...serverOption) *testServer {
+	t.Helper()
+	opts = append(opts, withIdempotency(newStore), withSetup(func(t *testing.T, s *testServer) {
+		s.v1.POST("/large", func(c echo.Context) error {
+			return c.String(http.StatusCreated, strings.Repeat("x", idempotency.MaxResponseSize+1))
+		}, s.idempotent)
+	}))
+	return newServer(t, opts...)
+}
+
+// serveIdempotent sends a POST to path as acme's admin with an Idempotency-Key
+func serveIdempotent(e *echo.Echo, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(middlewares.HeaderAdminToken, adminToken)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set(middlewares.HeaderIdempotencyKey, key)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+const newUserBody = `{"name":"New","email":"new@acme.example.com","password":"Secr3tPassword"}`
+
+func TestIdempotentRetryIsReplayed(t *testing.T) {
+	for name, newStore := range idempotencyStores {
+		t.Run(name, func(t *testing.T) {
+			s := newIdempotencyServer(t, newStore)
+
+			first := serveIdempotent(s.e, "/api/v1/users", "key-1", newUserBody)
+			if first.Code != http.StatusOK {
+				t.Fatalf("first: status = %d, want 200: %s", first.Code, first.Body)
+			}
+			retry := serveIdempotent(s.e, "/api/v1/users", "key-1", newUserBody)
+			if retry.Code != http.StatusOK || retry.Body.String() != first.Body.String() {
+				t.Fatalf("retry: status = %d, body %s; want the first response %s", retry.Code, retry.Body, first.Body)
+			}
+			if got := retry.Header().Get(middlewares.HeaderIdempotentReplayed); got != "true" {
+				t.Errorf("retry %s = %q, want true", middlewares.HeaderIdempotentReplayed, got)
+			}
+
+			var count int64
+			if err := s.db.WithContext(tenancy.AllTenants(context.Background())).Model(&user.User{}).Where("email = ?", "new@acme.example.com").Count(&count).Error; err != nil || count != 1 {
+				t.Errorf("users created = %d (%v), want 1", count, err)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyReusedForAnotherRequest(t *testing.T) {
+	for name, newStore := range idempotencyStores {
+		t.Run(name, func(t *testing.T) {
+			s := newIdempotencyServer(t, newStore)
+
+			if rec := serveIdempotent(s.e, "/api/v1/users", "key-1", newUserBody); rec.Code != http.StatusOK {
+				t.Fatalf("first: status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			other := `{"name":"Other","email":"other@acme.example.com","password":"Secr3tPassword"}`
+			if rec := serveIdempotent(s.e, "/api/v1/users", "key-1", other); rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("other payload: status = %d, want 422: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestIdempotentRetryWhileInFlight(t *testing.T) {
+	for name, newStore := range idempotencyStores {
+		t.Run(name, func(t *testing.T) {
+			auditor := &blockingAuditor{started: make(chan struct{}), release: make(chan struct{})}
+			s := newIdempotencyServer(t, newStore, withAuditor(func(log user.Auditor) user.Auditor {
+				auditor.Auditor = log
+				return auditor
+			}))
+
+			done := make(chan *httptest.ResponseRecorder)
+			go func() { done <- serveIdempotent(s.e, "/api/v1/users", "key-1", newUserBody) }()
+			<-auditor.started
+
+			rec := serveIdempotent(s.e, "/api/v1/users", "key-1", newUserBody)
+			close(auditor.release)
+			if rec.Code != http.StatusConflict {
+				t.Errorf("retry in flight: status = %d, want 409: %s", rec.Code, rec.Body)
+			}
+			if first := <-done; first.Code != http.StatusOK {
+				t.Fatalf("first: status = %d, want 200: %s", first.Code, first.Body)
+			}
+			if rec = serveIdempotent(s.e, "/api/v1/users", "key-1", newUserBody); rec.Code != http.StatusOK || rec.Header().Get(middlewares.HeaderIdempotentReplayed) != "true" {
+				t.Errorf("retry after it finished: status = %d, body %s; want the replayed response", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestIdempotencyLimitsBodySizes(t *testing.T) {
+	for name, newStore := range idempotencyStores {
+		t.Run(name, func(t *testing.T) {
+			s := newIdempotencyServer(t, newStore)
+
+			body := `{"name":"` + strings.Repeat("x", idempotency.MaxRequestSize) + `"}`
+			if rec := serveIdempotent(s.e, "/api/v1/users", "key-1", body); rec.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("large request: status = %d, want 413", rec.Code)
+			}
+
+			if rec := serveIdempotent(s.e, "/api/v1/large", "key-2", ""); rec.Code != http.StatusCreated || rec.Body.Len() != idempotency.MaxResponseSize+1 {
+				t.Fatalf("large response: status = %d, %d bytes; want 201 with the whole body", rec.Code, rec.Body.Len())
+			}
+			rec := serveIdempotent(s.e, "/api/v1/large", "key-2", "")
+			if rec.Code != http.StatusCreated || rec.Body.Len() != 0 || rec.Header().Get(middlewares.HeaderIdempotentReplayed) != "true" {
+				t.Errorf("large response replay: status = %d, %d bytes; want 201 without the body", rec.Code, rec.Body.Len())
+			}
+		})
+	}
+}
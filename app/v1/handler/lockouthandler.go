@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/lockout"
+	"github.com/ranggaaprilio/boilerGo/helper"
+)
+
+/**
+ * LockoutHandler handles HTTP requests for reviewing and clearing failed login
+ * counters. It depends on the lockout service for the counter state.
+ */
+type LockoutHandler struct {
+	lockoutService lockout.Service
+}
+
+// LockoutResponse represents a failed login counter for an account or a
+// client IP in API responses
+type LockoutResponse struct {
+	Kind          string  `json:"kind" example:"account"`
+	Value         string  `json:"value" example:"john.doe@example.com"`
+	Failures      int     `json:"failures" example:"4"`
+	LastFailureAt string  `json:"last_failure_at" example:"2025-06-15T19:22:47.091+07:00"`
+	Blocked       bool    `json:"blocked" example:"true"`
+	Locked        bool    `json:"locked" example:"false"`
+	BlockedUntil  *string `json:"blocked_until,omitempty" example:"2025-06-15T19:22:49.091+07:00"`
+}
+
+// NewLockoutResponse converts a login counter into its API representation.
+// Blocked, Locked and BlockedUntil describe the state at now.
+func NewLockoutResponse(a lockout.LoginAttempt, now time.Time) LockoutResponse {
+	res := LockoutResponse{
+		Kind:          a.Kind,
+		Value:         a.Value,
+		Failures:      a.Failures,
+		LastFailureAt: a.LastFailureAt.Format(timestampLayout),
+		Blocked:       a.Blocked(now),
+	}
+	if res.Blocked {
+		res.Locked = a.Locked
+		res.BlockedUntil = formatOptionalTime(a.BlockedUntil)
+	}
+	return res
+}
+
+/**
+ * NewLockoutHandler creates a new instance of LockoutHandler with the provided lockout service.
+ *
+ * @param lockoutService The service that tracks failed logins
+ * @return A pointer to a new LockoutHandler instance
+ */
+func NewLockoutHandler(lockoutService lockout.Service) *LockoutHandler {
+	return &LockoutHandler{lockoutService}
+}
+
+/**
+ * ListLockouts handles the HTTP request for listing recent failed login
+ * counters together with their delay or lock state.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List failed login counters
+// @Description Lists accounts and client IPs with recent failed logins, most recent first. Blocked counters are refused logins until blocked_until; locked tells a lockout apart from a back-off delay.
+// @Tags security
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=[]LockoutResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/security/lockouts [get]
+func (h *LockoutHandler) ListLockouts(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	attempts, err := h.lockoutService.List(c.Request().Context())
+	if err != nil {
+		return lockoutErrorResponse(c, err)
+	}
+
+	now := time.Now()
+	data := make([]LockoutResponse, 0, len(attempts))
+	for _, a := range attempts {
+		data = append(data, NewLockoutResponse(a, now))
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Login counters found successfully"
+	res.Data = data
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * Unlock handles the HTTP request for clearing the failed login counter of an
+ * account or a client IP, lifting any delay or lock on it.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Clear a failed login counter
+// @Description Forgets the failures of an account (normalized email) or a client IP, lifting any delay or lock. Clearing an unknown counter succeeds.
+// @Tags security
+// @Produce json
+// @Param kind path string true "Counter kind" Enums(account, ip)
+// @Param value path string true "Email or IP address, URL encoded"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/security/lockouts/{kind}/{value} [delete]
+func (h *LockoutHandler) Unlock(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	value, err := url.PathUnescape(c.Param("value"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid counter value",
+			Data:    err.Error(),
+		})
+	}
+
+	if err = h.lockoutService.Unlock(c.Request().Context(), c.Param("kind"), value); err != nil {
+		return lockoutErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Login counter cleared"
+	return c.JSON(http.StatusOK, res)
+}
+
+// lockoutErrorResponse maps errors from the lockout service to HTTP responses
+func lockoutErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, lockout.ErrUnknownKind):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Counter kind must be account or ip",
+		})
+	default:
+		c.Logger().Errorf("failed to process lockout request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
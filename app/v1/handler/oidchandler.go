@@ -0,0 +1,390 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/identity"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/config"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/oidc"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// oidcBasePath is where the provider endpoints are mounted; the flow cookie
+// is scoped below it
+const oidcBasePath = "/api/v1/auth/oidc/"
+
+/**
+ * OIDCHandler handles HTTP requests for logging in with external OpenID
+ * Connect providers and for managing linked provider accounts.
+ * It depends on the OIDC client for the provider flow, the identity service
+ * for resolving provider accounts to users, and the session handler for
+ * starting the cookie session a provider login ends in.
+ */
+type OIDCHandler struct {
+	client          *oidc.Client
+	identityService identity.Service
+	sessions        *SessionHandler
+	conf            config.OIDCConfigurations
+}
+
+// OIDCProviderResponse represents a provider users can log in with
+type OIDCProviderResponse struct {
+	Name        string `json:"name" example:"google"`
+	DisplayName string `json:"display_name" example:"Google"`
+	// LoginURL is where to send the browser to log in with the provider
+	LoginURL string `json:"login_url" example:"/api/v1/auth/oidc/google/login"`
+}
+
+// IdentityResponse represents a provider account linked to a user
+type IdentityResponse struct {
+	ID        uint   `json:"id" example:"3"`
+	Provider  string `json:"provider" example:"google"`
+	Email     string `json:"email" example:"john.doe@example.com"`
+	CreatedAt string `json:"created_at" example:"2025-06-15T19:22:47.091+07:00"`
+}
+
+// NewIdentityResponse converts a linked identity into its API representation.
+// The provider subject is an internal identifier and is not exposed.
+func NewIdentityResponse(i identity.Identity) IdentityResponse {
+	return IdentityResponse{
+		ID:        i.ID,
+		Provider:  i.Provider,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt.Format(timestampLayout),
+	}
+}
+
+/**
+ * NewOIDCHandler creates a new instance of OIDCHandler.
+ *
+ * @param client The client running login flows against the providers
+ * @param identityService The service that links provider accounts to users
+ * @param sessions The handler that starts cookie sessions
+ * @param conf The OpenID Connect settings
+ * @return A pointer to a new OIDCHandler instance
+ */
+func NewOIDCHandler(client *oidc.Client, identityService identity.Service, sessions *SessionHandler, conf config.OIDCConfigurations) *OIDCHandler {
+	return &OIDCHandler{client, identityService, sessions, conf}
+}
+
+/**
+ * ListProviders handles the HTTP request listing the configured providers.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List identity providers
+// @Description Lists the OpenID Connect providers users can log in with, in configuration order.
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} helper.SuccessResponse{data=[]OIDCProviderResponse}
+// @Router /v1/auth/oidc/providers [get]
+func (h *OIDCHandler) ListProviders(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	providers := h.client.Providers()
+	data := make([]OIDCProviderResponse, 0, len(providers))
+	for _, p := range providers {
+		data = append(data, OIDCProviderResponse{
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+			LoginURL:    oidcBasePath + p.Name + "/login",
+		})
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Success"
+	res.Data = data
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * Login handles the HTTP request starting a login with a provider. The
+ * browser is redirected to the provider while the flow state is kept in a
+ * short-lived cookie.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Log in with an identity provider
+// @Description Redirects the browser to the provider to log in, using the authorization code flow with PKCE. The provider sends the browser back to the callback, which starts a cookie session.
+// @Tags oidc
+// @Param provider path string true "Provider name"
+// @Success 302
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 502 {object} helper.BadGatewayResponse
+// @Router /v1/auth/oidc/{provider}/login [get]
+func (h *OIDCHandler) Login(c echo.Context) error {
+	return h.begin(c, 0)
+}
+
+/**
+ * Link handles the HTTP request starting a provider login that links the
+ * provider account to the logged in user.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Link an identity provider
+// @Description Redirects the browser to the provider to link that account to the caller, so the caller can log in with it afterwards. Meant for browsers logged in with a session cookie.
+// @Tags oidc
+// @Param provider path string true "Provider name"
+// @Security BearerAuth
+// @Success 302
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 502 {object} helper.BadGatewayResponse
+// @Router /v1/auth/oidc/{provider}/link [get]
+func (h *OIDCHandler) Link(c echo.Context) error {
+	return h.begin(c, principal.From(c).UserID)
+}
+
+/**
+ * Callback handles the provider redirecting back after a login. The code is
+ * exchanged and the ID token validated; a login then starts a cookie session
+ * and a link attaches the provider account to the user who started it.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Identity provider callback
+// @Description Completes a provider login or link started from this browser and redirects to the configured post-login URL. A login with an unknown provider account creates a user from the provider's verified email; an email that already belongs to a user is refused until that user links the provider. Provider logins skip local two-factor authentication, which is left to the provider.
+// @Tags oidc
+// @Param provider path string true "Provider name"
+// @Param code query string false "Authorization code"
+// @Param state query string true "Login state"
+// @Param error query string false "Error reported by the provider"
+// @Success 302
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Failure 502 {object} helper.BadGatewayResponse
+// @Router /v1/auth/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c echo.Context) error {
+	provider := c.Param("provider")
+
+	flowToken := ""
+	if cookie, err := c.Cookie(h.flowCookieName()); err == nil {
+		flowToken = cookie.Value
+	}
+	// The flow is single use whatever the outcome
+	c.SetCookie(h.flowCookie(provider, "", time.Unix(0, 0)))
+
+	result, err := h.client.Complete(c.Request().Context(), provider, flowToken, oidc.Callback{
+		Code:  c.QueryParam("code"),
+		State: c.QueryParam("state"),
+		Error: c.QueryParam("error"),
+	})
+	if err != nil {
+		return oidcErrorResponse(c, err)
+	}
+
+	// The provider calls back the same URL for every tenant, so act for the
+	// tenant the flow was started for
+	c.SetRequest(c.Request().WithContext(tenancy.WithTenant(c.Request().Context(), result.Tenant)))
+
+	if result.LinkUserID != 0 {
+		if _, err = h.identityService.Link(c.Request().Context(), result.LinkUserID, result.Provider, result.Claims); err != nil {
+			return oidcErrorResponse(c, err)
+		}
+		return c.Redirect(http.StatusFound, h.conf.PostLoginURL)
+	}
+
+	userID, err := h.identityService.Login(c.Request().Context(), result.Provider, result.Claims)
+	if err != nil {
+		return oidcErrorResponse(c, err)
+	}
+	if _, err = h.sessions.issueSession(c, userID); err != nil {
+		return oidcErrorResponse(c, err)
+	}
+	return c.Redirect(http.StatusFound, h.conf.PostLoginURL)
+}
+
+/**
+ * ListIdentities handles the HTTP request listing the provider accounts
+ * linked to the caller.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List my linked identities
+// @Description Lists the provider accounts linked to the caller, oldest first.
+// @Tags oidc
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=[]IdentityResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/identities [get]
+func (h *OIDCHandler) ListIdentities(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	identities, err := h.identityService.List(c.Request().Context(), principal.From(c).UserID)
+	if err != nil {
+		return oidcErrorResponse(c, err)
+	}
+
+	data := make([]IdentityResponse, 0, len(identities))
+	for _, i := range identities {
+		data = append(data, NewIdentityResponse(i))
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Success"
+	res.Data = data
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * UnlinkIdentity handles the HTTP request removing one of the caller's
+ * linked provider accounts.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Unlink an identity
+// @Description Removes a provider account from the caller. The last linked account of a user without a password cannot be removed.
+// @Tags oidc
+// @Produce json
+// @Param id path string true "Identity ID"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/identities/{id} [delete]
+func (h *OIDCHandler) UnlinkIdentity(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid identity ID",
+			Data:    err.Error(),
+		})
+	}
+
+	if err = h.identityService.Unlink(c.Request().Context(), principal.From(c).UserID, uint(id)); err != nil {
+		return oidcErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Identity unlinked"
+	return c.JSON(http.StatusOK, res)
+}
+
+// begin starts a provider flow, remembering it in the flow cookie, and
+// redirects to the provider
+func (h *OIDCHandler) begin(c echo.Context, linkUserID uint) error {
+	provider := c.Param("provider")
+
+	flow, err := h.client.Begin(c.Request().Context(), provider, linkUserID)
+	if err != nil {
+		return oidcErrorResponse(c, err)
+	}
+
+	c.SetCookie(h.flowCookie(provider, flow.Token, flow.ExpiresAt))
+	return c.Redirect(http.StatusFound, flow.AuthURL)
+}
+
+// flowCookieName derives the flow cookie name from the session cookie
+func (h *OIDCHandler) flowCookieName() string {
+	return h.sessions.cookie.CookieName + "_oidc"
+}
+
+// flowCookie builds the cookie carrying a flow to the provider's callback.
+// SameSite must be lax for the cookie to come back on the provider's
+// cross-site redirect.
+func (h *OIDCHandler) flowCookie(provider, value string, expires time.Time) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     h.flowCookieName(),
+		Value:    value,
+		Path:     oidcBasePath + provider,
+		Domain:   h.sessions.cookie.CookieDomain,
+		Expires:  expires,
+		Secure:   h.sessions.cookie.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
+
+// oidcErrorResponse maps errors from provider flows and linked identities to
+// HTTP responses
+func oidcErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, oidc.ErrUnknownProvider):
+		return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+			Code:    http.StatusNotFound,
+			Message: "Identity provider not found",
+		})
+	case errors.Is(err, identity.ErrIdentityNotFound):
+		return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+			Code:    http.StatusNotFound,
+			Message: "Linked identity not found",
+		})
+	case errors.Is(err, oidc.ErrInvalidState):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid or expired login, please start again",
+		})
+	case errors.Is(err, oidc.ErrLoginDenied), errors.Is(err, oidc.ErrInvalidIDToken):
+		return c.JSON(http.StatusUnauthorized, helper.UnauthorizedResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "Login with the identity provider failed",
+			Data:    err.Error(),
+		})
+	case errors.Is(err, user.ErrUserNotFound):
+		return c.JSON(http.StatusUnauthorized, helper.UnauthorizedResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "The linked account no longer exists",
+		})
+	case errors.Is(err, identity.ErrEmailUnverified):
+		return c.JSON(http.StatusForbidden, helper.ForbiddenResponse{
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		})
+	case errors.Is(err, identity.ErrAccountExists), errors.Is(err, identity.ErrIdentityTaken), errors.Is(err, identity.ErrLastLoginMethod):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		})
+	case errors.Is(err, oidc.ErrProviderFailed):
+		return c.JSON(http.StatusBadGateway, helper.BadGatewayResponse{
+			Code:    http.StatusBadGateway,
+			Message: "Identity provider is unavailable",
+			Data:    err.Error(),
+		})
+	default:
+		c.Logger().Errorf("failed to process OIDC request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// countingLoader counts the permission lookups of the loader it wraps
+type countingLoader struct {
+	principal.PermissionLoader
+	loads int
+}
+
+func (l *countingLoader) UserPermissions(ctx context.Context, userID uint) ([]string, error) {
+	l.loads++
+	return l.PermissionLoader.UserPermissions(ctx, userID)
+}
+
+func TestRequirePermissionChecksTheCallersRoles(t *testing.T) {
+	s := newServer(t, withRoles())
+	bearer := "Bearer " + accessToken(1, s.acme)
+	user := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+
+	if rec := serveAs(s.e, http.MethodGet, "/api/v1/users", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status = %d, want 401: %s", rec.Code, rec.Body)
+	}
+	rec := serveAs(s.e, http.MethodGet, "/api/v1/users", bearer, "")
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "Missing permission "+rbac.PermUsersRead) {
+		t.Errorf("user without roles: status = %d, want 403 for %s: %s", rec.Code, rbac.PermUsersRead, rec.Body)
+	}
+
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+	if _, err := s.roles.AssignRole(ctx, s.acme, rbac.RoleViewer); err != nil {
+		t.Fatalf("assign viewer role: %v", err)
+	}
+	if rec = serveAs(s.e, http.MethodGet, "/api/v1/users", bearer, ""); rec.Code != http.StatusOK {
+		t.Errorf("viewer listing users: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	rec = serveAs(s.e, http.MethodDelete, user, bearer, "")
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "Missing permission "+rbac.PermUsersDelete) {
+		t.Errorf("viewer deleting a user: status = %d, want 403 for %s: %s", rec.Code, rbac.PermUsersDelete, rec.Body)
+	}
+	if rec = serve(s.e, http.MethodGet, "/api/v1/users", "acme", ""); rec.Code != http.StatusOK {
+		t.Errorf("admin token: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPermissionsAreLoadedOncePerRequest(t *testing.T) {
+	loader := &countingLoader{}
+	s := newServer(t, withRoles(), withPermissionLoader(func(roles principal.PermissionLoader) principal.PermissionLoader {
+		loader.PermissionLoader = roles
+		return loader
+	}), withSetup(func(t *testing.T, s *testServer) {
+		s.v1.GET("/checked", func(c echo.Context) error {
+			return c.NoContent(http.StatusNoContent)
+		}, middlewares.RequirePermission(rbac.PermUsersRead), middlewares.RequirePermission(rbac.PermUsersExport))
+	}))
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+	if _, err := s.roles.AssignRole(ctx, s.acme, rbac.RoleViewer); err != nil {
+		t.Fatalf("assign viewer role: %v", err)
+	}
+	bearer := "Bearer " + accessToken(1, s.acme)
+
+	if rec := serveAs(s.e, http.MethodGet, "/api/v1/checked", bearer, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("two checks: status = %d, want 204: %s", rec.Code, rec.Body)
+	}
+	if loader.loads != 1 {
+		t.Errorf("permissions loaded %d times for one request, want once", loader.loads)
+	}
+
+	// The cache lives for one request, so role changes apply to the next one
+	if _, err := s.roles.UnassignRole(ctx, s.acme, rbac.RoleViewer); err != nil {
+		t.Fatalf("remove viewer role: %v", err)
+	}
+	if rec := serveAs(s.e, http.MethodGet, "/api/v1/checked", bearer, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("after the role was removed: status = %d, want 403: %s", rec.Code, rec.Body)
+	}
+	if loader.loads != 2 {
+		t.Errorf("permissions loaded %d times for two requests, want twice", loader.loads)
+	}
+
+	if rec := serve(s.e, http.MethodGet, "/api/v1/checked", "acme", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("admin token: status = %d, want 204: %s", rec.Code, rec.Body)
+	}
+	if loader.loads != 2 {
+		t.Errorf("permissions loaded %d times after an admin token request, want admins to skip the lookup", loader.loads)
+	}
+}
@@ -0,0 +1,121 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/apikey"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+func TestPersonalDataExport(t *testing.T) {
+	s := newServer(t, withModels(&apikey.APIKey{}), withAuditRoutes(), withPrivacy(apiKeyAndAuditData))
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+	if rec := serveConditional(s.e, http.MethodPatch, path, "If-Match", `"1"`, `{"name":"renamed"}`); rec.Code != http.StatusOK {
+		t.Fatalf("PATCH: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	key := apikey.APIKey{TenantID: 1, UserID: s.acme, Name: "ci", Prefix: "abcd1234", KeyHash: strings.Repeat("f", 64), Scopes: "users:read"}
+	if err := s.db.WithContext(tenancy.AllTenants(context.Background())).Create(&key).Error; err != nil {
+		t.Fatalf("create API key: %v", err)
+	}
+
+	rec := serve(s.e, http.MethodGet, path+"/personal-data", "acme", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, "personal-data-") {
+		t.Errorf("Content-Disposition = %q, want an attachment", got)
+	}
+
+	var res struct {
+		Data struct {
+			Data struct {
+				User    map[string]interface{}   `json:"user"`
+				APIKeys []map[string]interface{} `json:"api_keys"`
+				Audit   []audit.ExportedEvent    `json:"audit"`
+			} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	data := res.Data.Data
+	if data.User["email"] != "owner@acme.example.com" || data.User["name"] != "renamed" {
+		t.Errorf("user = %v, want acme's owner", data.User)
+	}
+	if _, ok := data.User["password_hash"]; ok {
+		t.Errorf("user = %v, want no password hash", data.User)
+	}
+	if len(data.APIKeys) != 1 || data.APIKeys[0]["name"] != "ci" {
+		t.Fatalf("api_keys = %v, want the ci key", data.APIKeys)
+	}
+	if _, ok := data.APIKeys[0]["key_hash"]; ok {
+		t.Errorf("api key = %v, want no key hash", data.APIKeys[0])
+	}
+	if len(data.Audit) != 1 || data.Audit[0].Action != audit.ActionUpdate {
+		t.Errorf("audit = %+v, want the update", data.Audit)
+	}
+
+	if rec = serve(s.e, http.MethodGet, "/api/v1/users/999/personal-data", "acme", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("export of unknown user: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestPersonalDataErasure(t *testing.T) {
+	s := newServer(t, withModels(&apikey.APIKey{}), withAuditRoutes(), withPrivacy(apiKeyAndAuditData))
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+	if rec := serveConditional(s.e, http.MethodPatch, path, "If-Match", `"1"`, `{"name":"renamed"}`); rec.Code != http.StatusOK {
+		t.Fatalf("PATCH: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	ctx := tenancy.AllTenants(context.Background())
+	key := apikey.APIKey{TenantID: 1, UserID: s.acme, Name: "ci", Prefix: "abcd1234", KeyHash: strings.Repeat("f", 64), Scopes: "users:read"}
+	if err := s.db.WithContext(ctx).Create(&key).Error; err != nil {
+		t.Fatalf("create API key: %v", err)
+	}
+
+	rec := serve(s.e, http.MethodPost, path+"/erasure", "acme", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("erase: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var res struct {
+		Data struct {
+			Rows map[string]int64 `json:"rows"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rows := res.Data.Rows; rows["user"] != 1 || rows["api_keys"] != 1 || rows["audit"] != 1 {
+		t.Errorf("rows = %v, want the user, its key and its update event", rows)
+	}
+
+	var erased user.User
+	if err := s.db.WithContext(ctx).Unscoped().First(&erased, s.acme).Error; err != nil {
+		t.Fatalf("user row should remain: %v", err)
+	}
+	if erased.Name != "" || erased.Email != nil || erased.PasswordHash != "" || !erased.DeletedAt.Valid {
+		t.Errorf("erased user = %+v, want an anonymized, soft deleted row", erased)
+	}
+	var keys int64
+	if s.db.WithContext(ctx).Model(&apikey.APIKey{}).Where("user_id = ?", s.acme).Count(&keys); keys != 0 {
+		t.Errorf("API keys left = %d, want 0", keys)
+	}
+
+	report, err := audit.NewService(audit.NewRepository(s.db)).Verify(ctx)
+	if err != nil || !report.Intact() {
+		t.Fatalf("Verify after erasure = %+v, %v; want intact chains", report, err)
+	}
+	if rec = serve(s.e, http.MethodGet, "/api/v1/audit?resource_id="+strconv.FormatUint(uint64(s.acme), 10), "acme", ""); strings.Contains(rec.Body.String(), "renamed") || !strings.Contains(rec.Body.String(), `"action":"erase"`) {
+		t.Errorf("audit after erasure = %s, want the update redacted and the erasure recorded", rec.Body)
+	}
+
+	if rec = serve(s.e, http.MethodPost, path+"/erasure", "acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("second erasure: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/privacy"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+/**
+ * PrivacyHandler handles HTTP requests for data subject requests: exporting
+ * and erasing everything stored about a user. It depends on the privacy
+ * service, which collects the data of every registered module.
+ */
+type PrivacyHandler struct {
+	privacyService privacy.Service
+}
+
+/**
+ * NewPrivacyHandler creates a new instance of PrivacyHandler with the provided privacy service.
+ *
+ * @param privacyService The service that exports and erases personal data
+ * @return A pointer to a new PrivacyHandler instance
+ */
+func NewPrivacyHandler(privacyService privacy.Service) *PrivacyHandler {
+	return &PrivacyHandler{privacyService}
+}
+
+/**
+ * ExportCurrentUser handles the HTTP request for exporting everything stored
+ * about the authenticated user.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Export my personal data
+// @Description Returns everything stored about the current user, keyed by module, as a JSON download. Secrets such as password and token hashes are left out.
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=privacy.Export}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/me/personal-data [get]
+func (h *PrivacyHandler) ExportCurrentUser(c echo.Context) error {
+	return h.export(c, principal.From(c).UserID)
+}
+
+/**
+ * ExportUser handles the HTTP request for exporting everything stored about
+ * a user on their behalf.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Export a user's personal data
+// @Description Returns everything stored about a user, soft deleted or not, keyed by module, as a JSON download. Requires the privacy:manage permission.
+// @Tags privacy
+// @Produce json
+// @Param id path int true "User ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=privacy.Export}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/personal-data [get]
+func (h *PrivacyHandler) ExportUser(c echo.Context) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+	return h.export(c, id)
+}
+
+/**
+ * EraseCurrentUser handles the HTTP request for erasing everything stored
+ * about the authenticated user. The account cannot be used afterwards.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Erase my personal data
+// @Description Erases everything stored about the current user and closes the account. Rows only meaningful with the user are deleted; the user row is anonymized and soft deleted so references stay valid, and audit events about the user are redacted without breaking the hash chain. Not available to API keys.
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=privacy.ErasureReport}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/me/erasure [post]
+func (h *PrivacyHandler) EraseCurrentUser(c echo.Context) error {
+	return h.erase(c, principal.From(c).UserID)
+}
+
+/**
+ * EraseUser handles the HTTP request for erasing everything stored about a
+ * user on their behalf.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Erase a user's personal data
+// @Description Erases everything stored about a user, soft deleted or not, as for the user's own erasure. Requires the privacy:manage permission.
+// @Tags privacy
+// @Produce json
+// @Param id path int true "User ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=privacy.ErasureReport}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/erasure [post]
+func (h *PrivacyHandler) EraseUser(c echo.Context) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+	return h.erase(c, id)
+}
+
+// export writes the export of a user as a JSON attachment
+func (h *PrivacyHandler) export(c echo.Context, userID uint) error {
+	export, err := h.privacyService.Export(c.Request().Context(), userID)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="personal-data-%d.json"`, userID))
+	return c.JSON(http.StatusOK, helper.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: "Personal data exported successfully",
+		Data:    export,
+	})
+}
+
+// erase erases a user and writes the report
+func (h *PrivacyHandler) erase(c echo.Context, userID uint) error {
+	report, err := h.privacyService.Erase(c.Request().Context(), userID)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: "Personal data erased successfully",
+		Data:    report,
+	})
+}
@@ -0,0 +1,29 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+func TestOnlyTheAdminTokenCreatesRoles(t *testing.T) {
+	s := newServer(t, withRoles())
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+	if _, err := s.roles.AssignRole(ctx, s.acme, "admin"); err != nil {
+		t.Fatalf("assign admin role: %v", err)
+	}
+	bearer := "Bearer " + accessToken(1, s.acme)
+	body := `{"name":"support","description":"Support staff","permissions":["users:read"]}`
+
+	if rec := serveAs(s.e, http.MethodGet, "/api/v1/roles", bearer, ""); rec.Code != http.StatusOK {
+		t.Fatalf("list as roles:manage holder: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec := serveAs(s.e, http.MethodPost, "/api/v1/roles", bearer, body); rec.Code != http.StatusForbidden {
+		t.Fatalf("create as roles:manage holder: status = %d, want 403: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(s.e, http.MethodPost, "/api/v1/roles", "acme", body); rec.Code != http.StatusCreated {
+		t.Fatalf("create with the admin token: status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+}
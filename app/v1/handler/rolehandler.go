@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/helper"
+)
+
+/**
+ * RoleHandler handles HTTP requests for managing roles and their assignment to users.
+ * It depends on the rbac service for business logic operations.
+ */
+type RoleHandler struct {
+	rbacService rbac.Service
+}
+
+// RoleResponse represents a role for return in API responses
+type RoleResponse struct {
+	Name        string   `json:"name" example:"viewer"`
+	Description string   `json:"description" example:"Read-only access to users"`
+	Permissions []string `json:"permissions" example:"users:read,users:export"`
+}
+
+// NewRoleResponse converts a role entity into its API representation
+func NewRoleResponse(r rbac.Role) RoleResponse {
+	return RoleResponse{
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.PermissionNames(),
+	}
+}
+
+// newRoleResponses converts a list of roles into their API representation
+func newRoleResponses(roles []rbac.Role) []RoleResponse {
+	res := make([]RoleResponse, 0, len(roles))
+	for _, r := range roles {
+		res = append(res, NewRoleResponse(r))
+	}
+	return res
+}
+
+/**
+ * NewRoleHandler creates a new instance of RoleHandler with the provided rbac service.
+ *
+ * @param rbacService The service that manages roles and permissions
+ * @return A pointer to a new RoleHandler instance
+ */
+func NewRoleHandler(rbacService rbac.Service) *RoleHandler {
+	return &RoleHandler{rbacService}
+}
+
+/**
+ * ListRoles handles the HTTP request for listing every role and its permissions.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List roles
+// @Description Lists every role with the permissions it grants. Requires the roles:manage permission.
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=[]RoleResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/roles [get]
+func (h *RoleHandler) ListRoles(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	roles, err := h.rbacService.ListRoles(c.Request().Context())
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Roles found successfully"
+	res.Data = newRoleResponses(roles)
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * CreateRole handles the HTTP request for creating a role from existing permissions.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Create a role
+// @Description Creates a role granting existing permissions. Roles are shared by every tenant, so creating one requires the admin token.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param role body rbac.CreateRoleForm true "Role data"
+// @Param Idempotency-Key header string false "Retries with the same key get the first response replayed"
+// @Security AdminToken
+// @Success 201 {object} helper.SuccessResponse{data=RoleResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 413 {object} helper.PayloadTooLargeResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/roles [post]
+func (h *RoleHandler) CreateRole(c echo.Context) error {
+	req := new(rbac.CreateRoleForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	role, err := h.rbacService.CreateRole(c.Request().Context(), req)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusCreated
+	res.Message = "Role created successfully"
+	res.Data = NewRoleResponse(role)
+	return c.JSON(http.StatusCreated, res)
+}
+
+/**
+ * GetUserRoles handles the HTTP request for listing the roles assigned to a user.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List a user's roles
+// @Description Lists the roles assigned to a user. Requires the roles:manage permission.
+// @Tags roles
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=[]RoleResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/roles [get]
+func (h *RoleHandler) GetUserRoles(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	roles, err := h.rbacService.UserRoles(c.Request().Context(), uid)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Roles found successfully"
+	res.Data = newRoleResponses(roles)
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * AssignRole handles the HTTP request for granting a role to a user.
+ * Assigning a role the user already has succeeds without changes.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Assign a role to a user
+// @Description Grants a role to a user and returns the user's roles. Requires the roles:manage permission.
+// @Tags roles
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=[]RoleResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/roles/{role} [put]
+func (h *RoleHandler) AssignRole(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	roles, err := h.rbacService.AssignRole(c.Request().Context(), uid, c.Param("role"))
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Role assigned successfully"
+	res.Data = newRoleResponses(roles)
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * UnassignRole handles the HTTP request for taking a role away from a user.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Remove a role from a user
+// @Description Takes a role away from a user and returns the user's remaining roles. Requires the roles:manage permission.
+// @Tags roles
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=[]RoleResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/roles/{role} [delete]
+func (h *RoleHandler) UnassignRole(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	roles, err := h.rbacService.UnassignRole(c.Request().Context(), uid, c.Param("role"))
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Role removed successfully"
+	res.Data = newRoleResponses(roles)
+	return c.JSON(http.StatusOK, res)
+}
+
+// roleErrorResponse maps errors from the rbac service to HTTP responses
+func roleErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		return userErrorResponse(c, err)
+	case errors.Is(err, rbac.ErrRoleNotFound):
+		return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+			Code:    http.StatusNotFound,
+			Message: "Role not found",
+		})
+	case errors.Is(err, rbac.ErrRoleExists):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: "Role already exists",
+		})
+	case errors.Is(err, rbac.ErrUnknownPermission):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Unknown permission",
+			Data:    err.Error(),
+		})
+	default:
+		c.Logger().Errorf("failed to process role request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package handler_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/apikey"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/avatar"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/identity"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/privacy"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/refreshtoken"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/session"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/tenant"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/twofactor"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/verification"
+	"github.com/ranggaaprilio/boilerGo/config"
+	"github.com/ranggaaprilio/boilerGo/internal/idempotency"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+	routes "github.com/ranggaaprilio/boilerGo/internal/server/routes/v1"
+	"github.com/ranggaaprilio/boilerGo/internal/storage"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"github.com/ranggaaprilio/boilerGo/internal/validation"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const adminToken = "test-admin-token"
+
+// testPagination is the page size limits of the test servers
+var testPagination = config.PaginationConfigurations{DefaultPageSize: 20, MaxPageSize: 100}
+
+// avatarMaxSize is the upload limit of the test server
+const avatarMaxSize = 64 << 10
+
+// testSessionCookie is the session cookie settings of the test servers
+var testSessionCookie = config.SessionConfigurations{
+	CookieName:  "test_session",
+	CookiePath:  "/",
+	SameSite:    "lax",
+	IdleTimeout: 30 * time.Minute,
+	MaxLifetime: 24 * time.Hour,
+}
+
+// noTokens rejects every bearer token, so tenants come from the header
+type noTokens struct{}
+
+func (noTokens) VerifyAccessToken(string) (uint, uint, error) {
+	return 0, 0, echo.ErrUnauthorized
+}
+
+// testTokens accepts the access tokens made by accessToken
+type testTokens struct{}
+
+func (testTokens) VerifyAccessToken(token string) (uint, uint, error) {
+	tenantID, userID, _ := strings.Cut(token, ".")
+	tenant, err := strconv.ParseUint(tenantID, 10, 0)
+	if err != nil {
+		return 0, 0, echo.ErrUnauthorized
+	}
+	id, err := strconv.ParseUint(userID, 10, 0)
+	if err != nil {
+		return 0, 0, echo.ErrUnauthorized
+	}
+	return uint(id), uint(tenant), nil
+}
+
+// accessToken returns a bearer token of testTokens for a user of the tenant
+func accessToken(tenantID, userID uint) string {
+	return strconv.FormatUint(uint64(tenantID), 10) + "." + strconv.FormatUint(uint64(userID), 10)
+}
+
+// noKeys rejects every API key
+type noKeys struct{}
+
+func (noKeys) VerifyAPIKey(context.Context, string) (uint, []string, error) {
+	return 0, nil, apikey.ErrInvalidKey
+}
+
+// noVerification sends no verification emails. Only registration uses it.
+type noVerification struct {
+	verification.Service
+}
+
+func (noVerification) SendVerification(context.Context, user.User) error {
+	return nil
+}
+
+// passThrough stands in for a route middleware a test does not exercise
+func passThrough(next echo.HandlerFunc) echo.HandlerFunc {
+	return next
+}
+
+// testServer serves the user routes, and the routes its options add, for
+// two tenants, acme and globex, each holding one user
+type testServer struct {
+	e          *echo.Echo
+	v1         *echo.Group
+	db         *gorm.DB
+	audit      audit.Service
+	users      user.Service
+	idempotent echo.MiddlewareFunc
+	files      storage.Storage
+	roles      rbac.Service
+	sessions   session.Service
+	keys       apikey.Service
+	acme       uint // the ID of acme's user
+	globex     uint // the ID of globex's user
+}
+
+// serverOptions is what the options of newServer add to a test server
+type serverOptions struct {
+	models     []interface{}
+	auditor    func(user.Auditor) user.Auditor
+	dependents func(db *gorm.DB) []user.Dependent
+	newStore   func(db *gorm.DB) idempotency.Store
+	roles      bool
+	loader     func(principal.PermissionLoader) principal.PermissionLoader
+	sessions   bool
+	apiKeys    bool
+	setups     []func(t *testing.T, s *testServer)
+}
+
+type serverOption func(*serverOptions)
+
+// withModels migrates models next to tenants, users and the audit log
+func withModels(models ...interface{}) serverOption {
+	return func(o *serverOptions) { o.models = append(o.models, models...) }
+}
+
+// withAuditor records user changes through the auditor wrap returns for the
+// audit log
+func withAuditor(wrap func(user.Auditor) user.Auditor) serverOption {
+	return func(o *serverOptions) { o.auditor = wrap }
+}
+
+// withIdempotency retries user creations with the keys of the store made by
+// newStore
+func withIdempotency(newStore func(db *gorm.DB) idempotency.Store) serverOption {
+	return func(o *serverOptions) {
+		o.models = append(o.models, &idempotency.Record{})
+		o.newStore = newStore
+	}
+}
+
+// withSetup runs setup once the user routes are served, to add other routes
+func withSetup(setup func(t *testing.T, s *testServer)) serverOption {
+	return func(o *serverOptions) { o.setups = append(o.setups, setup) }
+}
+
+// withRoles checks permissions against the built-in roles and serves the
+// role routes
+func withRoles() serverOption {
+	return func(o *serverOptions) {
+		o.models = append(o.models, &rbac.Permission{}, &rbac.Role{}, &rbac.UserRole{})
+		o.roles = true
+		o.setups = append(o.setups, func(t *testing.T, s *testServer) {
+			routes.SetupRoleRoutes(s.v1, handler.NewRoleHandler(s.roles), s.idempotent)
+		})
+	}
+}
+
+// withPermissionLoader looks the permissions of withRoles up through the
+// loader wrap returns for the role service
+func withPermissionLoader(wrap func(principal.PermissionLoader) principal.PermissionLoader) serverOption {
+	return func(o *serverOptions) { o.loader = wrap }
+}
+
+// withAPIKeys authenticates API keys, scoped to the built-in roles, and
+// serves the API key routes
+func withAPIKeys() serverOption {
+	return func(o *serverOptions) {
+		withRoles()(o)
+		o.models = append(o.models, &apikey.APIKey{})
+		o.apiKeys = true
+		o.setups = append(o.setups, func(t *testing.T, s *testServer) {
+			routes.SetupAPIKeyRoutes(s.v1, handler.NewAPIKeyHandler(s.keys), middlewares.RequireAuth(), passThrough)
+		})
+	}
+}
+
+// withSessions identifies callers from session cookies and serves the session
+// routes. Tests create sessions through s.sessions since logins are not
+// served.
+func withSessions() serverOption {
+	return func(o *serverOptions) {
+		o.models = append(o.models, &session.Session{})
+		o.sessions = true
+		o.setups = append(o.setups, func(t *testing.T, s *testServer) {
+			routes.SetupSessionRoutes(s.v1, handler.NewSessionHandler(nil, s.sessions, testSessionCookie), middlewares.RequireAuth())
+		})
+	}
+}
+
+// withAuditRoutes serves the audit log
+func withAuditRoutes() serverOption {
+	return withSetup(func(t *testing.T, s *testServer) {
+		routes.SetupAuditRoutes(s.v1, handler.NewAuditHandler(s.audit, testPagination))
+	})
+}
+
+// withAvatars serves the avatar and file routes, keeping files in memory
+func withAvatars() serverOption {
+	return withSetup(func(t *testing.T, s *testServer) {
+		s.files = storage.NewMemoryStorage()
+		signer, err := storage.NewURLSigner("test-secret", "/api/v1/files", time.Minute, time.Now)
+		if err != nil {
+			t.Fatalf("URL signer: %v", err)
+		}
+		avatarService := avatar.NewService(s.users, s.files, signer, avatar.Options{ThumbnailSize: 16})
+		routes.SetupAvatarRoutes(s.v1, handler.NewAvatarHandler(avatarService, avatarMaxSize), middlewares.RequireAuth())
+		routes.SetupFileRoutes(s.e, handler.NewFileHandler(s.files, signer))
+	})
+}
+
+// withPrivacy serves the personal data routes for the modules modules returns
+func withPrivacy(modules func(s *testServer) map[string]privacy.Module) serverOption {
+	return withSetup(func(t *testing.T, s *testServer) {
+		privacyService := privacy.NewService(user.NewRepository(s.db), s.audit)
+		for name, module := range modules(s) {
+			privacyService.Register(name, module)
+		}
+		routes.SetupPrivacyRoutes(s.v1, handler.NewPrivacyHandler(privacyService), middlewares.RequireAuth())
+	})
+}
+
+// dependentModels are the models of the modules withAllDependents registers
+var dependentModels = []interface{}{
+	&refreshtoken.RefreshToken{},
+	&session.Session{},
+	&apikey.APIKey{},
+	&twofactor.TwoFactor{},
+	&twofactor.RecoveryCode{},
+	&rbac.Role{},
+	&rbac.UserRole{},
+	&identity.Identity{},
+}
+
+// withAllDependents registers every module keeping rows about users as a
+// dependent of the user service
+func withAllDependents() serverOption {
+	return func(o *serverOptions) {
+		o.models = append(o.models, dependentModels...)
+		o.dependents = func(db *gorm.DB) []user.Dependent {
+			return []user.Dependent{
+				refreshtoken.UserDependent{},
+				session.NewUserDependent(session.NewDatabaseStore(db)),
+				apikey.UserDependent{},
+				twofactor.UserDependent{},
+				user.NewDependentTable(&rbac.UserRole{}),
+				user.NewDependentTable(&identity.Identity{}),
+			}
+		}
+	}
+}
+
+// avatarData makes avatars the only module holding personal data
+func avatarData(s *testServer) map[string]privacy.Module {
+	return map[string]privacy.Module{"avatar": avatar.NewPersonalData(user.NewRepository(s.db), s.files)}
+}
+
+// apiKeyAndAuditData makes API keys and the audit log the modules holding
+// personal data
+func apiKeyAndAuditData(s *testServer) map[string]privacy.Module {
+	return map[string]privacy.Module{
+		"api_keys": privacy.NewTable(s.db, &apikey.APIKey{}, "key_hash"),
+		"audit":    audit.NewPersonalData(audit.NewRepository(s.db), user.AuditResource),
+	}
+}
+
+// newServer returns a test server on an in-memory database of the test's own.
+// User changes are recorded in the audit log and creations are not retried
+// unless opts say otherwise.
+func newServer(t *testing.T, opts ...serverOption) *testServer {
+	t.Helper()
+	var o serverOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s := &testServer{db: newTestDB(t, o.models...), idempotent: passThrough}
+	tenants := seedTenants(t, s)
+	group := []echo.MiddlewareFunc{
+		middlewares.Tenant(tenants, noTokens{}, middlewares.TenantOptions{Header: "X-Tenant"}),
+		middlewares.AdminToken(adminToken),
+	}
+	var keys middlewares.APIKeyVerifier = noKeys{}
+	if o.roles {
+		s.roles = rbac.NewService(rbac.NewRepository(s.db), user.NewRepository(s.db))
+		if err := s.roles.EnsureDefaults(context.Background()); err != nil {
+			t.Fatalf("create built-in roles: %v", err)
+		}
+	}
+	if o.apiKeys {
+		s.keys = apikey.NewService(apikey.NewRepository(s.db), s.roles)
+		keys = s.keys
+	}
+	group = append(group, middlewares.Authenticate(testTokens{}, keys))
+	if o.sessions {
+		s.sessions = session.NewService(session.NewDatabaseStore(s.db), session.Options{
+			IdleTimeout: testSessionCookie.IdleTimeout,
+			MaxLifetime: testSessionCookie.MaxLifetime,
+		})
+		group = append(group, middlewares.SessionCookie(s.sessions, testSessionCookie.CookieName))
+	}
+	if o.roles {
+		var loader principal.PermissionLoader = s.roles
+		if o.loader != nil {
+			loader = o.loader(loader)
+		}
+		group = append(group, middlewares.Permissions(loader))
+	}
+	group = append(group, middlewares.AuditActor())
+	s.e = echo.New()
+	s.e.Validator = validation.NewValidator()
+	s.v1 = s.e.Group("/api/v1", group...)
+
+	s.audit = audit.NewService(audit.NewRepository(s.db))
+	var auditor user.Auditor = s.audit
+	if o.auditor != nil {
+		auditor = o.auditor(auditor)
+	}
+	userService := user.NewService(user.NewRepository(s.db), user.NewBcryptHasher(4), auditor)
+	if o.dependents != nil {
+		for _, dependent := range o.dependents(s.db) {
+			userService.AddDependent(dependent)
+		}
+	}
+	s.users = userService
+	if o.newStore != nil {
+		keys := idempotency.NewService(o.newStore(s.db), idempotency.Options{TTL: time.Hour, LockTimeout: time.Minute})
+		s.idempotent = middlewares.Idempotency(keys)
+	}
+	routes.SetupUserRoutes(s.v1, handler.NewUserHandler(s.users, noVerification{}, testPagination), middlewares.RequireAuth(), s.idempotent)
+
+	for _, setup := range o.setups {
+		setup(t, s)
+	}
+	return s
+}
+
+// newTestDB opens an in-memory database of the test's own with the tenancy
+// plugin, and migrates tenants, users, the audit log and the given models.
+// The database is shared by every pooled connection and dropped with the
+// last of them when the test ends.
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	dsn := "file:" + url.PathEscape(t.Name()) + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err = db.Use(tenancy.Plugin{}); err != nil {
+		t.Fatalf("register tenancy plugin: %v", err)
+	}
+	models = append([]interface{}{&tenant.Tenant{}, &user.User{}, &audit.Event{}}, models...)
+	if err = db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// seedTenants creates the tenants acme and globex, each holding one user
+// named after it, and returns the tenant service
+func seedTenants(t *testing.T, s *testServer) tenant.Service {
+	t.Helper()
+	tenants := tenant.NewService(tenant.NewRepository(s.db))
+	users := user.NewRepository(s.db)
+	for _, slug := range []string{"acme", "globex"} {
+		created, err := tenants.Create(context.Background(), &tenant.CreateTenantForm{Slug: slug, Name: slug})
+		if err != nil {
+			t.Fatalf("create tenant %s: %v", slug, err)
+		}
+		email := "owner@" + slug + ".example.com"
+		saved, err := users.Save(tenancy.WithTenant(context.Background(), created.Ref()), user.User{Name: slug, Email: &email})
+		if err != nil {
+			t.Fatalf("create user of %s: %v", slug, err)
+		}
+		if slug == "acme" {
+			s.acme = saved.ID
+		} else {
+			s.globex = saved.ID
+		}
+	}
+	return tenants
+}
+
+// serve sends a request as the admin of tenantSlug, or of no tenant when it
+// is empty
+func serve(e *echo.Echo, method, path, tenantSlug, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(middlewares.HeaderAdminToken, adminToken)
+	if tenantSlug != "" {
+		req.Header.Set("X-Tenant", tenantSlug)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// serveAs sends a request to acme with the Authorization header and without
+// the admin token
+func serveAs(e *echo.Echo, method, path, authorization, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Tenant", "acme")
+	if authorization != "" {
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
@@ -0,0 +1,102 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/session"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// startSession creates a session for acme's user
+func startSession(t *testing.T, s *testServer) session.Issued {
+	t.Helper()
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+	issued, err := s.sessions.Create(ctx, s.acme, "203.0.113.7", "test")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return issued
+}
+
+// serveWithSession sends a request to acme with the session cookie and, when
+// it is not empty, the CSRF token
+func serveWithSession(e *echo.Echo, method, path, cookie, csrfToken, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: testSessionCookie.CookieName, Value: cookie})
+	if csrfToken != "" {
+		req.Header.Set(middlewares.HeaderCSRFToken, csrfToken)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSessionCookieRequiresTheCSRFToken(t *testing.T) {
+	s := newServer(t, withSessions())
+	current := startSession(t, s)
+	other := startSession(t, s)
+
+	cases := []struct {
+		name      string
+		csrfToken string
+		status    int
+	}{
+		{"without a token", "", http.StatusForbidden},
+		{"with another session's token", other.CSRFToken, http.StatusForbidden},
+		{"with the session's token", current.CSRFToken, http.StatusOK},
+	}
+	for i, tc := range cases {
+		body := `{"name":"New","email":"new` + strconv.Itoa(i) + `@acme.example.com","password":"Secr3tPassword"}`
+		if rec := serveWithSession(s.e, http.MethodPost, "/api/v1/users", current.Token, tc.csrfToken, body); rec.Code != tc.status {
+			t.Errorf("POST %s: status = %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+		}
+	}
+
+	if rec := serveWithSession(s.e, http.MethodDelete, "/api/v1/auth/sessions", current.Token, "", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("revoke all without a token: status = %d, want 403: %s", rec.Code, rec.Body)
+	}
+	if rec := serveWithSession(s.e, http.MethodGet, "/api/v1/auth/sessions", current.Token, "", ""); rec.Code != http.StatusOK {
+		t.Errorf("list sessions after a refused revoke: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	// Unknown cookies are ignored rather than refused, so they never block a login
+	if rec := serveWithSession(s.e, http.MethodPost, "/api/v1/users", "unknown", "", `{"name":"Stale","email":"stale@acme.example.com","password":"Secr3tPassword"}`); rec.Code != http.StatusOK {
+		t.Errorf("POST with an unknown cookie: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestRevokeAllSessionsEndsThem(t *testing.T) {
+	s := newServer(t, withSessions())
+	current := startSession(t, s)
+	other := startSession(t, s)
+
+	rec := serveWithSession(s.e, http.MethodDelete, "/api/v1/auth/sessions", current.Token, current.CSRFToken, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revoke all: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if cookie := rec.Result().Cookies(); len(cookie) != 1 || cookie[0].Name != testSessionCookie.CookieName || cookie[0].MaxAge >= 0 {
+		t.Errorf("revoke all set cookies %v, want the session cookie cleared", cookie)
+	}
+	for _, issued := range []session.Issued{current, other} {
+		if rec = serveWithSession(s.e, http.MethodGet, "/api/v1/auth/sessions", issued.Token, "", ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("list sessions after revoke all: status = %d, want 401: %s", rec.Code, rec.Body)
+		}
+	}
+
+	// Administrators end the sessions of any user
+	third := startSession(t, s)
+	if rec = serve(s.e, http.MethodDelete, "/api/v1/users/"+strconv.FormatUint(uint64(s.acme), 10)+"/sessions", "acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("revoke user's sessions: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec = serveWithSession(s.e, http.MethodGet, "/api/v1/auth/sessions", third.Token, "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("list sessions after the administrator revoked them: status = %d, want 401: %s", rec.Code, rec.Body)
+	}
+}
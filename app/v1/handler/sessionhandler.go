@@ -0,0 +1,515 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/auth"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/session"
+	"github.com/ranggaaprilio/boilerGo/config"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+/**
+ * SessionHandler handles HTTP requests for cookie-based sessions, used by
+ * server-rendered pages instead of bearer tokens.
+ * It depends on the auth service for credential checks and the session
+ * service for storing sessions.
+ */
+type SessionHandler struct {
+	authService    auth.Service
+	sessionService session.Service
+	cookie         config.SessionConfigurations
+}
+
+// SessionResponse represents a login session in API responses. The session
+// ID cookie value is never included.
+type SessionResponse struct {
+	ID         uint   `json:"id" example:"12"`
+	CreatedAt  string `json:"created_at" example:"2025-06-15T19:22:47.091+07:00"`
+	LastSeenAt string `json:"last_seen_at" example:"2025-06-15T19:40:02.511+07:00"`
+	ExpiresAt  string `json:"expires_at" example:"2025-06-15T20:10:02.511+07:00"`
+	IPAddress  string `json:"ip_address" example:"203.0.113.7"`
+	UserAgent  string `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	// Current marks the session the request was made with
+	Current bool `json:"current" example:"true"`
+}
+
+// CurrentSessionResponse represents the caller's own session, including the
+// CSRF token to send in the X-CSRF-Token header of state-changing requests
+type CurrentSessionResponse struct {
+	SessionResponse
+	CSRFToken string `json:"csrf_token" example:"Qm9vdHN0cmFwIENTUkYgdG9rZW4gZXhhbXBsZSB2YWx1ZQ"`
+}
+
+// NewSessionResponse converts a session into its API representation
+func NewSessionResponse(s session.Session, currentID uint) SessionResponse {
+	return SessionResponse{
+		ID:         s.ID,
+		CreatedAt:  s.CreatedAt.Format(timestampLayout),
+		LastSeenAt: s.LastSeenAt.Format(timestampLayout),
+		ExpiresAt:  s.ExpiresAt.Format(timestampLayout),
+		IPAddress:  s.IPAddress,
+		UserAgent:  s.UserAgent,
+		Current:    s.ID == currentID,
+	}
+}
+
+/**
+ * NewSessionHandler creates a new instance of SessionHandler.
+ *
+ * @param authService The service that checks login credentials
+ * @param sessionService The service that stores sessions
+ * @param cookie The session cookie settings
+ * @return A pointer to a new SessionHandler instance
+ */
+func NewSessionHandler(authService auth.Service, sessionService session.Service, cookie config.SessionConfigurations) *SessionHandler {
+	return &SessionHandler{authService, sessionService, cookie}
+}
+
+/**
+ * Login handles the HTTP request for logging in with email and password into
+ * a cookie session. On success the session ID is set as an HttpOnly cookie;
+ * when the user has two-factor authentication enabled a challenge is returned
+ * instead.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Log in with a session cookie
+// @Description Checks an email and password and starts a server-side session, set as an HttpOnly cookie. The response carries the CSRF token that state-changing requests made with the cookie must send in the X-CSRF-Token header. When the user has two-factor authentication enabled the data is an auth.ChallengeResponse instead, to be completed at /v1/auth/session/2fa.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param credentials body auth.LoginForm true "Login credentials"
+// @Success 200 {object} helper.SuccessResponse{data=CurrentSessionResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 429 {object} helper.TooManyRequestsResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/session [post]
+func (h *SessionHandler) Login(c echo.Context) error {
+	req := new(auth.LoginForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	check, err := h.authService.CheckCredentials(c.Request().Context(), req, c.RealIP())
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	if check.Challenge != nil {
+		res.Code = http.StatusOK
+		res.Message = "Two-factor authentication required"
+		res.Data = check.Challenge
+		return c.JSON(http.StatusOK, res)
+	}
+
+	return h.startSession(c, check.UserID)
+}
+
+/**
+ * LoginSecondFactor handles the HTTP request completing a session login that
+ * needs a second factor.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Complete a two-factor session login
+// @Description Exchanges a login challenge and a TOTP or recovery code for a session cookie. Wrong codes count as failed logins.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param request body auth.SecondFactorForm true "Challenge token and code"
+// @Success 200 {object} helper.SuccessResponse{data=CurrentSessionResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 429 {object} helper.TooManyRequestsResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/session/2fa [post]
+func (h *SessionHandler) LoginSecondFactor(c echo.Context) error {
+	req := new(auth.SecondFactorForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	userID, err := h.authService.CheckSecondFactor(c.Request().Context(), req, c.RealIP())
+	if err != nil {
+		return authErrorResponse(c, err)
+	}
+
+	return h.startSession(c, userID)
+}
+
+/**
+ * CurrentSession handles the HTTP request returning the session of the
+ * request's cookie, including its CSRF token.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Get the current session
+// @Description Returns the session the cookie belongs to and its CSRF token. Reading it keeps the session alive like any other request.
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} helper.SuccessResponse{data=CurrentSessionResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/session [get]
+func (h *SessionHandler) CurrentSession(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	current, err := h.sessionService.Authenticate(c.Request().Context(), h.cookieValue(c))
+	if err != nil {
+		return sessionErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Session found successfully"
+	res.Data = CurrentSessionResponse{NewSessionResponse(current, current.ID), current.CSRFToken}
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * Logout handles the HTTP request ending the session of the request's cookie.
+ * The cookie is cleared. Requests without a valid session only clear the
+ * cookie so logging out is idempotent.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Log out of the session
+// @Description Ends the session the cookie belongs to and clears the cookie. The X-CSRF-Token header must match the session; without a valid session only the cookie is cleared.
+// @Tags sessions
+// @Produce json
+// @Param X-CSRF-Token header string false "CSRF token of the session"
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/session [delete]
+func (h *SessionHandler) Logout(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	p := principal.From(c)
+	if p.SessionID != 0 {
+		err := h.sessionService.RevokeByID(c.Request().Context(), p.UserID, p.SessionID)
+		if err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+			return sessionErrorResponse(c, err)
+		}
+	}
+	c.SetCookie(h.clearedCookie())
+
+	res.Code = http.StatusOK
+	res.Message = "Logged out"
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * ListSessions handles the HTTP request listing the caller's active sessions.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List my sessions
+// @Description Lists the caller's active cookie sessions, most recently used first. The session of the request is marked current.
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=[]SessionResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/sessions [get]
+func (h *SessionHandler) ListSessions(c echo.Context) error {
+	p := principal.From(c)
+	return h.listSessions(c, p.UserID, p.SessionID)
+}
+
+/**
+ * RevokeSession handles the HTTP request ending one of the caller's sessions.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Revoke one of my sessions
+// @Description Ends one of the caller's sessions, signing that browser out. Revoking the current session also clears its cookie.
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid session ID",
+			Data:    err.Error(),
+		})
+	}
+
+	p := principal.From(c)
+	if err = h.sessionService.RevokeByID(c.Request().Context(), p.UserID, uint(id)); err != nil {
+		return sessionErrorResponse(c, err)
+	}
+	if uint(id) == p.SessionID {
+		c.SetCookie(h.clearedCookie())
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Session revoked"
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * RevokeAllSessions handles the HTTP request ending every session of the caller.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Revoke all my sessions
+// @Description Ends every cookie session of the caller, including the current one. Bearer tokens are not affected; use /v1/auth/logout-all for those.
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/sessions [delete]
+func (h *SessionHandler) RevokeAllSessions(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	p := principal.From(c)
+	if err := h.sessionService.RevokeAll(c.Request().Context(), p.UserID); err != nil {
+		return sessionErrorResponse(c, err)
+	}
+	if p.SessionID != 0 {
+		c.SetCookie(h.clearedCookie())
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "All sessions revoked"
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * ListUserSessions handles the HTTP request listing the active sessions of any
+ * user, for administrators.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List a user's sessions
+// @Description Lists the active cookie sessions of a user, most recently used first
+// @Tags sessions
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=[]SessionResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/sessions [get]
+func (h *SessionHandler) ListUserSessions(c echo.Context) error {
+	userID, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	return h.listSessions(c, userID, principal.From(c).SessionID)
+}
+
+/**
+ * RevokeUserSessions handles the HTTP request ending every session of a user,
+ * for administrators.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Revoke a user's sessions
+// @Description Ends every cookie session of a user
+// @Tags sessions
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/sessions [delete]
+func (h *SessionHandler) RevokeUserSessions(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	userID, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	if err = h.sessionService.RevokeAll(c.Request().Context(), userID); err != nil {
+		return sessionErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "All sessions revoked"
+	return c.JSON(http.StatusOK, res)
+}
+
+// listSessions writes the active sessions of a user, marking currentID
+func (h *SessionHandler) listSessions(c echo.Context, userID, currentID uint) error {
+	var res helper.SuccessResponse
+
+	sessions, err := h.sessionService.List(c.Request().Context(), userID)
+	if err != nil {
+		return sessionErrorResponse(c, err)
+	}
+
+	data := make([]SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		data = append(data, NewSessionResponse(s, currentID))
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Sessions found successfully"
+	res.Data = data
+	return c.JSON(http.StatusOK, res)
+}
+
+// startSession creates a session for the authenticated user and responds with
+// it
+func (h *SessionHandler) startSession(c echo.Context, userID uint) error {
+	var res helper.SuccessResponse
+
+	issued, err := h.issueSession(c, userID)
+	if err != nil {
+		return sessionErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Login successful"
+	res.Data = CurrentSessionResponse{NewSessionResponse(issued.Session, issued.ID), issued.CSRFToken}
+	return c.JSON(http.StatusOK, res)
+}
+
+// issueSession creates a session for the user and sets its cookie. A session
+// cookie the browser already holds is ended first so a planted session ID
+// cannot outlive the login.
+func (h *SessionHandler) issueSession(c echo.Context, userID uint) (session.Issued, error) {
+	if err := h.sessionService.Revoke(c.Request().Context(), h.cookieValue(c)); err != nil {
+		return session.Issued{}, err
+	}
+
+	issued, err := h.sessionService.Create(c.Request().Context(), userID, c.RealIP(), c.Request().UserAgent())
+	if err != nil {
+		return session.Issued{}, err
+	}
+	c.SetCookie(h.newCookie(issued.Token, issued.CreatedAt.Add(h.cookie.MaxLifetime)))
+	return issued, nil
+}
+
+// cookieValue returns the session ID cookie of the request, or an empty string
+func (h *SessionHandler) cookieValue(c echo.Context) string {
+	cookie, err := c.Cookie(h.cookie.CookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// newCookie builds the session cookie. It lasts as long as the session can,
+// the server ending it earlier when it goes idle.
+func (h *SessionHandler) newCookie(value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     h.cookie.CookieName,
+		Value:    value,
+		Path:     h.cookie.CookiePath,
+		Domain:   h.cookie.CookieDomain,
+		Expires:  expires,
+		Secure:   h.cookie.Secure,
+		HttpOnly: true,
+		SameSite: sameSiteMode(h.cookie.SameSite),
+	}
+}
+
+// clearedCookie builds a cookie telling the browser to drop the session cookie
+func (h *SessionHandler) clearedCookie() *http.Cookie {
+	cookie := h.newCookie("", time.Unix(0, 0))
+	cookie.MaxAge = -1
+	return cookie
+}
+
+// sameSiteMode converts the configured SameSite setting
+func sameSiteMode(mode string) http.SameSite {
+	switch mode {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// sessionErrorResponse maps errors from the session service to HTTP responses
+func sessionErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, session.ErrInvalidSession):
+		return c.JSON(http.StatusUnauthorized, helper.UnauthorizedResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "No valid session",
+		})
+	case errors.Is(err, session.ErrSessionNotFound):
+		return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+			Code:    http.StatusNotFound,
+			Message: "Session not found",
+		})
+	default:
+		c.Logger().Errorf("failed to process session request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handler_test
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUsersOfAnotherTenantAreNotFound(t *testing.T) {
+	s := newServer(t)
+	globexPath := "/api/v1/users/" + strconv.FormatUint(uint64(s.globex), 10)
+
+	if rec := serve(s.e, http.MethodGet, "/api/v1/users/"+strconv.FormatUint(uint64(s.acme), 10), "acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("own user: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, globexPath, ""},
+		{http.MethodPut, globexPath, `{"name":"hijacked","email":"hijacked@example.com"}`},
+		{http.MethodPatch, globexPath, `{"name":"hijacked"}`},
+		{http.MethodDelete, globexPath, ""},
+	}
+	for _, tc := range cases {
+		if rec := serve(s.e, tc.method, tc.path, "acme", tc.body); rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s as acme: status = %d, want 404: %s", tc.method, tc.path, rec.Code, rec.Body)
+		}
+	}
+
+	rec := serve(s.e, http.MethodGet, globexPath, "globex", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"globex"`) {
+		t.Fatalf("globex user as globex: status = %d, body %s; want it unchanged", rec.Code, rec.Body)
+	}
+}
+
+func TestUserListOnlyShowsOwnTenant(t *testing.T) {
+	s := newServer(t)
+
+	rec := serve(s.e, http.MethodGet, "/api/v1/users", "acme", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "owner@acme.example.com") || strings.Contains(body, "globex") {
+		t.Fatalf("list as acme = %s, want only acme's user", body)
+	}
+}
+
+func TestUnknownTenantIsNotFound(t *testing.T) {
+	s := newServer(t)
+
+	rec := serve(s.e, http.MethodGet, "/api/v1/users/"+strconv.FormatUint(uint64(s.acme), 10), "initech", "")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "Tenant not found") {
+		t.Fatalf("status = %d, body %s; want 404 Tenant not found", rec.Code, rec.Body)
+	}
+
+	if rec = serve(s.e, http.MethodGet, "/api/v1/users", "", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("no tenant: status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/tenant"
+	"github.com/ranggaaprilio/boilerGo/helper"
+)
+
+/**
+ * TenantHandler handles HTTP requests for managing the customers that share
+ * the deployment. It depends on the tenant service for business logic
+ * operations.
+ */
+type TenantHandler struct {
+	tenantService tenant.Service
+}
+
+// TenantResponse represents a tenant for return in API responses
+type TenantResponse struct {
+	ID        uint      `json:"id" example:"2"`
+	Slug      string    `json:"slug" example:"acme"`
+	Name      string    `json:"name" example:"Acme Corporation"`
+	CreatedAt time.Time `json:"created_at" example:"2025-06-15T19:22:47.091+07:00"`
+}
+
+// NewTenantResponse converts a tenant entity into its API representation
+func NewTenantResponse(t tenant.Tenant) TenantResponse {
+	return TenantResponse{
+		ID:        t.ID,
+		Slug:      t.Slug,
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+/**
+ * NewTenantHandler creates a new instance of TenantHandler with the provided tenant service.
+ *
+ * @param tenantService The service that manages tenants
+ * @return A pointer to a new TenantHandler instance
+ */
+func NewTenantHandler(tenantService tenant.Service) *TenantHandler {
+	return &TenantHandler{tenantService}
+}
+
+/**
+ * ListTenants handles the HTTP request for listing every tenant.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List tenants
+// @Description Lists every tenant of the deployment, oldest first. Requires the admin token.
+// @Tags tenants
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=[]TenantResponse}
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/tenants [get]
+func (h *TenantHandler) ListTenants(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	tenants, err := h.tenantService.List(c.Request().Context())
+	if err != nil {
+		return tenantErrorResponse(c, err)
+	}
+
+	data := make([]TenantResponse, 0, len(tenants))
+	for _, t := range tenants {
+		data = append(data, NewTenantResponse(t))
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Tenants found successfully"
+	res.Data = data
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * CreateTenant handles the HTTP request for adding a tenant.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Create a tenant
+// @Description Adds a tenant. The slug names the tenant in the tenant header and subdomains and cannot be changed later. Requires the admin token.
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Param tenant body tenant.CreateTenantForm true "Tenant data"
+// @Param Idempotency-Key header string false "Retries with the same key get the first response replayed"
+// @Security AdminToken
+// @Success 201 {object} helper.SuccessResponse{data=TenantResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 413 {object} helper.PayloadTooLargeResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/tenants [post]
+func (h *TenantHandler) CreateTenant(c echo.Context) error {
+	req := new(tenant.CreateTenantForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	created, err := h.tenantService.Create(c.Request().Context(), req)
+	if err != nil {
+		return tenantErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusCreated
+	res.Message = "Tenant created successfully"
+	res.Data = NewTenantResponse(created)
+	return c.JSON(http.StatusCreated, res)
+}
+
+// tenantErrorResponse maps tenant service errors to HTTP responses
+func tenantErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, tenant.ErrInvalidSlug):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid tenant data",
+			Data:    err.Error(),
+		})
+	case errors.Is(err, tenant.ErrTenantExists):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: "Tenant already exists",
+		})
+	default:
+		c.Logger().Errorf("failed to process tenant request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/twofactor"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+/**
+ * TwoFactorHandler handles HTTP requests for managing the caller's two-factor
+ * authentication. It depends on the twofactor service for business logic.
+ */
+type TwoFactorHandler struct {
+	twoFactorService twofactor.Service
+}
+
+/**
+ * NewTwoFactorHandler creates a new instance of TwoFactorHandler with the provided twofactor service.
+ *
+ * @param twoFactorService The service that enrols and checks TOTP authenticators
+ * @return A pointer to a new TwoFactorHandler instance
+ */
+func NewTwoFactorHandler(twoFactorService twofactor.Service) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService}
+}
+
+/**
+ * Status handles the HTTP request for the caller's two-factor status.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Two-factor status
+// @Description Tells whether two-factor authentication is enabled and how many recovery codes are left
+// @Tags two-factor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=twofactor.StatusResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/2fa [get]
+func (h *TwoFactorHandler) Status(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	status, err := h.twoFactorService.Status(c.Request().Context(), principal.From(c).UserID)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Two-factor status found successfully"
+	res.Data = status
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * Enroll handles the HTTP request for starting two-factor enrolment.
+ * It returns a new secret and otpauth URI; logins are not affected until the
+ * enrolment is confirmed with a code.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Start two-factor enrolment
+// @Description Generates a TOTP secret and otpauth URI for the caller's authenticator app. Enrolling again before confirming replaces the secret.
+// @Tags two-factor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=twofactor.EnrollmentResponse}
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	enrolment, err := h.twoFactorService.Enroll(c.Request().Context(), principal.From(c).UserID)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Scan the secret with your authenticator app, then confirm with a code"
+	res.Data = enrolment
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * Confirm handles the HTTP request for finishing two-factor enrolment.
+ * A valid code from the authenticator app turns two-factor authentication on
+ * and the response carries the recovery codes.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Confirm two-factor enrolment
+// @Description Enables two-factor authentication with a first code from the authenticator app and returns one-time recovery codes. The recovery codes are shown only in this response.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param code body twofactor.CodeForm true "Code"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=twofactor.RecoveryCodesResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm(c echo.Context) error {
+	req := new(twofactor.CodeForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	codes, err := h.twoFactorService.Confirm(c.Request().Context(), principal.From(c).UserID, req)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Two-factor authentication enabled, store the recovery codes now as they will not be shown again"
+	res.Data = codes
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * Disable handles the HTTP request for turning two-factor authentication off.
+ * It needs a current TOTP or recovery code.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Disable two-factor authentication
+// @Description Turns two-factor authentication off after checking a current TOTP or recovery code
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param code body twofactor.CodeForm true "Code"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c echo.Context) error {
+	req := new(twofactor.CodeForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	if err := h.twoFactorService.Disable(c.Request().Context(), principal.From(c).UserID, req); err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Two-factor authentication disabled"
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * RegenerateRecoveryCodes handles the HTTP request for a new set of recovery
+ * codes. Codes issued earlier stop working.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Regenerate recovery codes
+// @Description Replaces every recovery code after checking a current TOTP or recovery code. The new codes are shown only in this response.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param code body twofactor.CodeForm true "Code"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=twofactor.RecoveryCodesResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/auth/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	req := new(twofactor.CodeForm)
+	var res helper.SuccessResponse
+	if err := c.Bind(req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = "Failed Form Binding"
+		res.Data = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request().Context(), principal.From(c).UserID, req)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Recovery codes regenerated, store them now as they will not be shown again"
+	res.Data = codes
+	return c.JSON(http.StatusOK, res)
+}
+
+// twoFactorErrorResponse maps errors from the twofactor service to HTTP responses
+func twoFactorErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid two-factor code",
+		})
+	case errors.Is(err, twofactor.ErrNotEnrolled),
+		errors.Is(err, twofactor.ErrNotEnabled),
+		errors.Is(err, twofactor.ErrAlreadyEnabled):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		})
+	case errors.Is(err, user.ErrUserNotFound):
+		return userErrorResponse(c, err)
+	default:
+		c.Logger().Errorf("failed to process two-factor request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/apikey"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/identity"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/refreshtoken"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/session"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/twofactor"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
+)
+
+// seedDependents gives acme's user one row in each dependent table
+func seedDependents(t *testing.T, s *testServer) {
+	t.Helper()
+	ctx := tenancy.AllTenants(context.Background())
+	expires := time.Now().Add(time.Hour)
+	role := rbac.Role{Name: "support"}
+	rows := []interface{}{
+		&refreshtoken.RefreshToken{TenantID: 1, UserID: s.acme, FamilyID: "family", TokenHash: strings.Repeat("a", 64), ExpiresAt: expires},
+		&session.Session{TenantID: 1, UserID: s.acme, TokenHash: strings.Repeat("b", 64), CSRFToken: "csrf", LastSeenAt: time.Now(), ExpiresAt: expires},
+		&apikey.APIKey{TenantID: 1, UserID: s.acme, Name: "ci", Prefix: "abcd1234", KeyHash: strings.Repeat("c", 64), Scopes: "users:read"},
+		&twofactor.TwoFactor{TenantID: 1, UserID: s.acme, Secret: "secret"},
+		&twofactor.RecoveryCode{TenantID: 1, UserID: s.acme, CodeHash: strings.Repeat("d", 64)},
+		&role,
+		&identity.Identity{TenantID: 1, UserID: s.acme, Provider: "google", Subject: "1234"},
+	}
+	for _, row := range rows {
+		if err := s.db.WithContext(ctx).Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+	if err := s.db.WithContext(ctx).Create(&rbac.UserRole{UserID: s.acme, RoleID: role.ID, TenantID: 1}).Error; err != nil {
+		t.Fatalf("create role assignment: %v", err)
+	}
+}
+
+// countRows returns how many rows of model belong to userID
+func countRows(t *testing.T, db *gorm.DB, model interface{}, userID uint) int64 {
+	t.Helper()
+	var count int64
+	if err := db.WithContext(tenancy.AllTenants(context.Background())).Model(model).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		t.Fatalf("count %T: %v", model, err)
+	}
+	return count
+}
+
+func TestDeleteUserRevokesItsAccess(t *testing.T) {
+	s := newServer(t, withAllDependents())
+	seedDependents(t, s)
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+
+	if rec := serveConditional(s.e, http.MethodDelete, path, "If-Match", `"1"`, ""); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	ctx := tenancy.AllTenants(context.Background())
+	var token refreshtoken.RefreshToken
+	if err := s.db.WithContext(ctx).Where("user_id = ?", s.acme).First(&token).Error; err != nil {
+		t.Fatalf("find refresh token: %v", err)
+	}
+	if token.RevokedAt == nil || token.RevokedReason != refreshtoken.RevokedUserDeleted {
+		t.Errorf("refresh token revoked at %v for %q, want revoked for %q", token.RevokedAt, token.RevokedReason, refreshtoken.RevokedUserDeleted)
+	}
+	var key apikey.APIKey
+	if err := s.db.WithContext(ctx).Where("user_id = ?", s.acme).First(&key).Error; err != nil {
+		t.Fatalf("find API key: %v", err)
+	}
+	if key.RevokedAt == nil {
+		t.Error("API key is not revoked")
+	}
+	if n := countRows(t, s.db, &session.Session{}, s.acme); n != 0 {
+		t.Errorf("sessions = %d, want 0", n)
+	}
+
+	// Rows granting nothing on their own are kept for a restore
+	for _, model := range []interface{}{&twofactor.TwoFactor{}, &rbac.UserRole{}, &identity.Identity{}} {
+		if n := countRows(t, s.db, model, s.acme); n != 1 {
+			t.Errorf("%T rows = %d, want 1", model, n)
+		}
+	}
+}
+
+func TestPurgeUserDeletesItsRows(t *testing.T) {
+	s := newServer(t, withAllDependents())
+	seedDependents(t, s)
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10) + "/purge"
+
+	if rec := serve(s.e, http.MethodDelete, path, "acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("purge: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	for _, model := range dependentModels {
+		if _, ok := model.(*rbac.Role); ok {
+			continue
+		}
+		if n := countRows(t, s.db, model, s.acme); n != 0 {
+			t.Errorf("%T rows = %d, want 0", model, n)
+		}
+	}
+}
+
+func TestPurgeUserKeepsItsRowsWhenItFails(t *testing.T) {
+	s := newServer(t, withAllDependents())
+	seedDependents(t, s)
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10) + "/purge"
+
+	// Without the audit log the purge cannot be recorded and rolls back
+	if err := s.db.Migrator().DropTable(&audit.Event{}); err != nil {
+		t.Fatalf("drop audit table: %v", err)
+	}
+	if rec := serve(s.e, http.MethodDelete, path, "acme", ""); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("purge: status = %d, want 500: %s", rec.Code, rec.Body)
+	}
+
+	for _, model := range dependentModels {
+		if _, ok := model.(*rbac.Role); ok {
+			continue
+		}
+		if n := countRows(t, s.db, model, s.acme); n != 1 {
+			t.Errorf("%T rows = %d, want 1", model, n)
+		}
+	}
+}
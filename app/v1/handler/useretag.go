@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+)
+
+// Conditional request headers
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// errIfMatchRequired is returned when a request changing a user has no If-Match header
+var errIfMatchRequired = errors.New("If-Match header is required")
+
+// userETag returns the entity tag of a user's current version
+func userETag(u user.User) string {
+	return `"` + strconv.FormatUint(uint64(u.Version), 10) + `"`
+}
+
+// setUserETag sends the entity tag of u with the response
+func setUserETag(c echo.Context, u user.User) {
+	c.Response().Header().Set(headerETag, userETag(u))
+}
+
+// userNotModified writes a 304 response and returns true when the request's
+// If-None-Match header already names the current version of u
+func userNotModified(c echo.Context, u user.User) (bool, error) {
+	if !etagListMatches(c.Request().Header.Get(headerIfNoneMatch), userETag(u), true) {
+		return false, nil
+	}
+	return true, c.NoContent(http.StatusNotModified)
+}
+
+// matchedUser returns the current user if the request's If-Match header
+// allows it to be changed. The header is required; a stale tag is reported
+// as user.ErrVersionMismatch and a missing user as user.ErrUserNotFound.
+func (h *UserHandler) matchedUser(c echo.Context, id uint) (user.User, error) {
+	current, err := h.userService.GetUserByID(c.Request().Context(), id)
+	if err != nil {
+		return current, err
+	}
+
+	header := c.Request().Header.Get(headerIfMatch)
+	if header == "" {
+		return current, errIfMatchRequired
+	}
+	if !etagListMatches(header, userETag(current), false) {
+		return current, user.ErrVersionMismatch
+	}
+
+	return current, nil
+}
+
+// etagListMatches reports whether a comma separated list of entity tags, or
+// "*", contains etag. Weak tags only match when weak comparison is allowed,
+// as it is for If-None-Match.
+func etagListMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// serveConditional serves a request as tenant acme with one extra header
+func serveConditional(e *echo.Echo, method, path, header, value, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(middlewares.HeaderAdminToken, adminToken)
+	req.Header.Set("X-Tenant", "acme")
+	if value != "" {
+		req.Header.Set(header, value)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUserReadsAreConditional(t *testing.T) {
+	s := newServer(t)
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+
+	rec := serve(s.e, http.MethodGet, path, "acme", "")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag != `"1"` {
+		t.Fatalf("GET: status = %d, ETag = %q; want 200 with \"1\"", rec.Code, etag)
+	}
+
+	if rec = serveConditional(s.e, http.MethodGet, path, "If-None-Match", etag, ""); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("GET with current ETag: status = %d, body %q; want empty 304", rec.Code, rec.Body)
+	}
+	if rec = serveConditional(s.e, http.MethodGet, path, "If-None-Match", `W/"1"`, ""); rec.Code != http.StatusNotModified {
+		t.Fatalf("GET with weak ETag: status = %d, want 304", rec.Code)
+	}
+	if rec = serveConditional(s.e, http.MethodGet, path, "If-None-Match", `"0"`, ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET with stale ETag: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestUserChangesRequireCurrentVersion(t *testing.T) {
+	s := newServer(t)
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+	body := `{"name":"renamed"}`
+
+	if rec := serveConditional(s.e, http.MethodPatch, path, "If-Match", "", body); rec.Code != http.StatusPreconditionRequired {
+		t.Fatalf("PATCH without If-Match: status = %d, want 428: %s", rec.Code, rec.Body)
+	}
+
+	rec := serveConditional(s.e, http.MethodPatch, path, "If-Match", `"1"`, body)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("PATCH with current ETag: status = %d, ETag = %q; want 200 with \"2\": %s", rec.Code, rec.Header().Get("ETag"), rec.Body)
+	}
+
+	for _, method := range []string{http.MethodPatch, http.MethodDelete} {
+		if rec = serveConditional(s.e, method, path, "If-Match", `"1"`, body); rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("%s with stale ETag: status = %d, want 412: %s", method, rec.Code, rec.Body)
+		}
+	}
+
+	if rec = serveConditional(s.e, http.MethodDelete, path, "If-Match", `"2"`, ""); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE with current ETag: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/helper"
+)
+
+// exportFlushEvery is the number of rows written between flushes to the client
+const exportFlushEvery = 100
+
+// exportCSVHeader is the header row of CSV exports
+var exportCSVHeader = []string{"id", "name", "email", "created_at", "updated_at", "deleted_at"}
+
+// csvFormulaPrefixes are the leading characters that make spreadsheet
+// applications evaluate a cell as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+/**
+ * ExportUsers handles the HTTP request for exporting users.
+ * Rows are read from the database through a cursor and written to the client
+ * as they arrive, flushing every few rows, so exports of any size run in
+ * constant memory. It accepts the same filters as ListUsers.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Export users
+// @Description Streams all users matching the filters as CSV or NDJSON. Requires the users:export permission.
+// @Tags users
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "Export format" Enums(csv, ndjson) default(csv)
+// @Param sort query string false "Sort field, prefix with - for descending" Enums(name, -name, created_at, -created_at)
+// @Param name_contains query string false "Only users whose name contains this text"
+// @Param created_after query string false "Only users created after this RFC 3339 timestamp"
+// @Param include_deleted query bool false "Include soft deleted users, requires the users:restore permission"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {file} file
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Router /v1/users/export [get]
+func (h *UserHandler) ExportUsers(c echo.Context) error {
+	req := new(user.ExportUsersQuery)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Failed Form Binding",
+			Data:    err.Error(),
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	filter, err := req.Filter()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid query parameters",
+			Data:    err.Error(),
+		})
+	}
+
+	if denied, err := forbidIncludeDeleted(c, filter); denied {
+		return err
+	}
+
+	if req.Format == user.ExportFormatNDJSON {
+		return h.exportNDJSON(c, filter)
+	}
+	return h.exportCSV(c, filter)
+}
+
+// exportCSV streams the filtered users as CSV with a header row
+func (h *UserHandler) exportCSV(c echo.Context, filter user.ListFilter) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="users.csv"`)
+	res.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(res)
+	if err := writer.Write(exportCSVHeader); err != nil {
+		return err
+	}
+
+	rows := 0
+	err := h.userService.ExportUsers(c.Request().Context(), filter, func(u user.User) error {
+		item := NewUserResponse(u)
+		deletedAt := ""
+		if item.DeletedAt != nil {
+			deletedAt = *item.DeletedAt
+		}
+
+		record := []string{strconv.FormatUint(uint64(item.ID), 10), csvCell(item.Name), csvCell(item.Email), item.CreatedAt, item.UpdatedAt, deletedAt}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			res.Flush()
+		}
+		return nil
+	})
+
+	writer.Flush()
+	res.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// csvCell escapes a user supplied value for a CSV export. Values that a
+// spreadsheet would run as a formula are prefixed with a quote so they are
+// shown as text.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// exportNDJSON streams the filtered users as newline delimited JSON
+func (h *UserHandler) exportNDJSON(c echo.Context, filter user.ListFilter) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="users.ndjson"`)
+	res.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(res)
+
+	rows := 0
+	err := h.userService.ExportUsers(c.Request().Context(), filter, func(u user.User) error {
+		if err := encoder.Encode(NewUserResponse(u)); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			res.Flush()
+		}
+		return nil
+	})
+
+	res.Flush()
+	return err
+}
@@ -0,0 +1,56 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+func TestCSVExportEscapesFormulas(t *testing.T) {
+	s := newServer(t)
+
+	names := map[string]string{
+		"formula@example.com": `=HYPERLINK("http://evil.example.com","click")`,
+		"plus@example.com":    "+1 555 0100",
+		"minus@example.com":   "-2+3",
+		"at@example.com":      "@SUM(A1:A2)",
+		"tab@example.com":     "\t=1+1",
+		"cr@example.com":      "\r=1+1",
+		"plain@example.com":   "Jane - Doe",
+	}
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+	for email, name := range names {
+		email := email
+		if _, err := user.NewRepository(s.db).Save(ctx, user.User{Name: name, Email: &email}); err != nil {
+			t.Fatalf("create %s: %v", email, err)
+		}
+	}
+
+	rec := serve(s.e, http.MethodGet, "/api/v1/users/export", "acme", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, record := range records[1:] {
+		got[record[2]] = record[1]
+	}
+	for email, name := range names {
+		want := "'" + name
+		if email == "plain@example.com" {
+			want = name
+		}
+		if got[email] != want {
+			t.Errorf("name of %s = %q, want %q", email, got[email], want)
+		}
+	}
+}
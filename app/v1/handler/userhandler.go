@@ -4,13 +4,18 @@
 package handler
 
 import (
-	// "log"
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
 	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/verification"
+	"github.com/ranggaaprilio/boilerGo/config"
 	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
 )
 
 /**
@@ -18,26 +23,55 @@ import (
  * It depends on the user service for business logic operations.
  */
 type UserHandler struct {
-	userService user.Service
+	userService         user.Service
+	verificationService verification.Service
+	pagination          config.PaginationConfigurations
 }
 
 // UserResponse represents a user for return in API responses
 type UserResponse struct {
-	ID        int     `json:"ID" example:"1"`
-	Name      string  `json:"name" example:"John Doe"`
-	CreatedAt string  `json:"CreatedAt" example:"2025-06-15T19:22:47.091+07:00"`
-	UpdatedAt string  `json:"UpdatedAt" example:"2025-06-15T19:22:47.091+07:00"`
-	DeletedAt *string `json:"DeletedAt,omitempty" example:"2025-06-15T19:22:47.091+07:00"`
+	ID              uint    `json:"ID" example:"1"`
+	Name            string  `json:"name" example:"John Doe"`
+	Email           string  `json:"email,omitempty" example:"john.doe@example.com"`
+	EmailVerifiedAt *string `json:"email_verified_at,omitempty" example:"2025-06-15T19:30:00.000+07:00"`
+	CreatedAt       string  `json:"CreatedAt" example:"2025-06-15T19:22:47.091+07:00"`
+	UpdatedAt       string  `json:"UpdatedAt" example:"2025-06-15T19:22:47.091+07:00"`
+	DeletedAt       *string `json:"DeletedAt,omitempty" example:"2025-06-15T19:22:47.091+07:00"`
+}
+
+// timestampLayout is the format used for timestamps in user responses
+const timestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// NewUserResponse converts a user entity into its API representation
+func NewUserResponse(u user.User) UserResponse {
+	res := UserResponse{
+		ID:        u.ID,
+		Name:      u.Name,
+		Email:     u.EmailAddress(),
+		CreatedAt: u.CreatedAt.Format(timestampLayout),
+		UpdatedAt: u.UpdatedAt.Format(timestampLayout),
+	}
+	if u.EmailVerifiedAt != nil {
+		verifiedAt := u.EmailVerifiedAt.Format(timestampLayout)
+		res.EmailVerifiedAt = &verifiedAt
+	}
+	if u.DeletedAt.Valid {
+		deletedAt := u.DeletedAt.Time.Format(timestampLayout)
+		res.DeletedAt = &deletedAt
+	}
+	return res
 }
 
 /**
  * NewUserHandler creates a new instance of UserHandler with the provided user service.
  *
  * @param userService The service that handles user-related business logic
+ * @param verificationService The service that mails email verification links
+ * @param pagination The page size limits applied to user listings
  * @return A pointer to a new UserHandler instance
  */
-func NewUserHandler(userService user.Service) *UserHandler {
-	return &UserHandler{userService}
+func NewUserHandler(userService user.Service, verificationService verification.Service, pagination config.PaginationConfigurations) *UserHandler {
+	return &UserHandler{userService, verificationService, pagination}
 }
 
 /**
@@ -48,7 +82,8 @@ func NewUserHandler(userService user.Service) *UserHandler {
  * 1. Binds the request body to an AddUserForm struct
  * 2. Validates the form data
  * 3. Calls the user service to register the user
- * 4. Returns an appropriate response
+ * 4. Mails a verification link to the new user's email
+ * 5. Returns an appropriate response
  *
  * @param c Echo context containing the HTTP request and response
  * @return An error if one occurs during processing
@@ -60,8 +95,12 @@ func NewUserHandler(userService user.Service) *UserHandler {
 // @Accept json
 // @Produce json
 // @Param user body user.AddUserForm true "User Data"
+// @Param Idempotency-Key header string false "Retries with the same key get the first response replayed"
 // @Success 200 {object} helper.SuccessResponse{data=UserResponse}
 // @Failure 400 {object} helper.BadRequestResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 413 {object} helper.PayloadTooLargeResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
 // @Failure 500 {object} helper.InternalServerErrorResponse
 // @Router /v1/users [post]
 func (h *UserHandler) RegisterUser(c echo.Context) error {
@@ -76,25 +115,23 @@ func (h *UserHandler) RegisterUser(c echo.Context) error {
 	}
 
 	if err = c.Validate(req); err != nil {
-		res.Code = http.StatusBadRequest
-		res.Message = "Name is Required"
-		res.Data = err.Error()
-		return c.JSON(http.StatusBadRequest, res)
+		return validationErrorResponse(c, err)
 	}
 
-	// log.Fatal(err)
-
-	newUser, err := h.userService.RegisterUser(req)
+	newUser, err := h.userService.RegisterUser(c.Request().Context(), req)
 	if err != nil {
-		res.Code = http.StatusInternalServerError
-		res.Message = "Oops sorry ,Failed Save data"
-		res.Data = err.Error()
-		return c.JSON(http.StatusBadRequest, res)
+		return userErrorResponse(c, err)
+	}
+
+	// The account exists either way; a failed email can be retried through
+	// the resend endpoint
+	if err = h.verificationService.SendVerification(c.Request().Context(), newUser); err != nil {
+		c.Logger().Errorf("failed to send verification email to user %d: %v", newUser.ID, err)
 	}
 
 	res.Code = http.StatusOK
 	res.Message = "Success save data"
-	res.Data = newUser
+	res.Data = NewUserResponse(newUser)
 	return c.JSON(http.StatusOK, res)
 
 }
@@ -113,41 +150,629 @@ func (h *UserHandler) RegisterUser(c echo.Context) error {
  */
 
 // @Summary Get a user by ID
-// @Description Retrieves user information by user ID
+// @Description Retrieves user information by user ID. Requires the users:read permission.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path string true "User ID"
+// @Param If-None-Match header string false "ETag from an earlier read; answered with 304 while it is current"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
 // @Success 200 {object} helper.SuccessResponse{data=UserResponse}
+// @Header 200 {string} ETag "Version of the user, send it as If-Match to change the user"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
 // @Failure 404 {object} helper.NotFoundResponse
 // @Failure 500 {object} helper.InternalServerErrorResponse
 // @Router /v1/users/{id} [get]
 func (h *UserHandler) GetUser(c echo.Context) error {
-	id := c.Param("id")
 	var res helper.SuccessResponse
 
-	// Example implementation - in a real app, this would call the user service
-	// user, err := h.userService.GetUserByID(id)
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
 
-	// Mock response for demonstration
-	uid, err := strconv.Atoi(id)
+	foundUser, err := h.userService.GetUserByID(c.Request().Context(), uid)
 	if err != nil {
-		res.Code = http.StatusBadRequest
-		res.Message = "Invalid user ID"
-		res.Data = err.Error()
-		return c.JSON(http.StatusBadRequest, res)
+		return userErrorResponse(c, err)
+	}
+
+	setUserETag(c, foundUser)
+	if notModified, err := userNotModified(c, foundUser); notModified {
+		return err
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "User found successfully"
+	res.Data = NewUserResponse(foundUser)
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * GetCurrentUser handles the HTTP request for retrieving the authenticated user.
+ * It requires a bearer access token and reads the user ID from the request principal.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Get the current user
+// @Description Retrieves the user the bearer access token was issued to
+// @Tags users
+// @Produce json
+// @Param If-None-Match header string false "ETag from an earlier read; answered with 304 while it is current"
+// @Security BearerAuth
+// @Success 200 {object} helper.SuccessResponse{data=UserResponse}
+// @Header 200 {string} ETag "Version of the user, send it as If-Match to change the user"
+// @Success 304 "Not Modified"
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/me [get]
+func (h *UserHandler) GetCurrentUser(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	foundUser, err := h.userService.GetUserByID(c.Request().Context(), principal.From(c).UserID)
+	if err != nil {
+		return userErrorResponse(c, err)
 	}
-	userData := UserResponse{
-		ID:        uid,
-		Name:      "Example User",
-		CreatedAt: "2025-06-15T19:22:47.091+07:00",
-		UpdatedAt: "2025-06-15T19:22:47.091+07:00",
-		DeletedAt: nil,
+
+	setUserETag(c, foundUser)
+	if notModified, err := userNotModified(c, foundUser); notModified {
+		return err
 	}
 
 	res.Code = http.StatusOK
 	res.Message = "User found successfully"
-	res.Data = userData
+	res.Data = NewUserResponse(foundUser)
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * ListUsers handles the HTTP request for listing users.
+ * It supports offset pagination (page, per_page) and cursor pagination (cursor),
+ * sorting by whitelisted fields and filtering by name and creation time.
+ * Soft deleted users are only included for callers with the users:restore permission.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary List users
+// @Description Lists users with offset or cursor pagination. Paging links are also sent in the Link header. Requires the users:read permission.
+// @Tags users
+// @Produce json
+// @Param page query int false "Page number, ignored when cursor is set" minimum(1) maximum(100000)
+// @Param per_page query int false "Page size, capped at the configured maximum" minimum(1)
+// @Param cursor query string false "Opaque cursor from a previous next_cursor"
+// @Param sort query string false "Sort field, prefix with - for descending" Enums(name, -name, created_at, -created_at)
+// @Param name_contains query string false "Only users whose name contains this text"
+// @Param created_after query string false "Only users created after this RFC 3339 timestamp"
+// @Param include_deleted query bool false "Include soft deleted users, requires the users:restore permission"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.PaginatedResponse{data=[]UserResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users [get]
+func (h *UserHandler) ListUsers(c echo.Context) error {
+	req := new(user.ListUsersQuery)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Failed Form Binding",
+			Data:    err.Error(),
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	filter, err := req.Filter()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid query parameters",
+			Data:    err.Error(),
+		})
+	}
+
+	if denied, err := forbidIncludeDeleted(c, filter); denied {
+		return err
+	}
+
+	page, perPage := h.pageParams(req)
+	result, err := h.userService.ListUsers(c.Request().Context(), filter, user.PageRequest{
+		Limit:  perPage,
+		Offset: (page - 1) * perPage,
+		Cursor: req.Cursor,
+	})
+	if errors.Is(err, user.ErrInvalidCursor) {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid pagination cursor",
+		})
+	}
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	meta := helper.PaginationMeta{
+		Total:      result.Total,
+		PerPage:    perPage,
+		NextCursor: result.NextCursor,
+	}
+	if req.Cursor == "" {
+		meta.Page = page
+	}
+
+	if links := listLinks(c, meta); len(links) > 0 {
+		c.Response().Header().Set("Link", helper.LinkHeader(links))
+	}
+
+	users := make([]UserResponse, 0, len(result.Users))
+	for _, u := range result.Users {
+		users = append(users, NewUserResponse(u))
+	}
+
+	return c.JSON(http.StatusOK, helper.PaginatedResponse{
+		Code:    http.StatusOK,
+		Message: "Users listed successfully",
+		Data:    users,
+		Meta:    meta,
+	})
+}
+
+/**
+ * UpdateUser handles the HTTP request for replacing a user's editable fields.
+ * It processes PUT requests and expects every editable field in the body.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Update a user
+// @Description Replaces all editable fields of an active user. Requires the users:write permission.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body user.UpdateUserForm true "User Data"
+// @Param If-Match header string true "ETag of the user version being changed"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=UserResponse}
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 412 {object} helper.PreconditionFailedResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 428 {object} helper.PreconditionRequiredResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id} [put]
+func (h *UserHandler) UpdateUser(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	req := new(user.UpdateUserForm)
+	if err = c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Failed Form Binding",
+			Data:    err.Error(),
+		})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	current, err := h.matchedUser(c, uid)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	updatedUser, err := h.userService.UpdateUser(c.Request().Context(), uid, current.Version, req)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	setUserETag(c, updatedUser)
+
+	res.Code = http.StatusOK
+	res.Message = "User updated successfully"
+	res.Data = NewUserResponse(updatedUser)
 	return c.JSON(http.StatusOK, res)
 }
+
+/**
+ * PatchUser handles the HTTP request for partially updating a user.
+ * It processes PATCH requests; fields missing from the body are left unchanged.
+ * Besides a plain JSON body it accepts JSON Merge Patch (RFC 7396) and
+ * JSON Patch (RFC 6902) documents, chosen by the Content-Type header.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Partially update a user
+// @Description Changes only the fields present in the request body. The body may also be a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json) applied to the user's name and email. Requires the users:write permission.
+// @Tags users
+// @Accept json,application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body user.PatchUserForm true "Fields to change"
+// @Param If-Match header string true "ETag of the user version being changed"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=UserResponse}
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 412 {object} helper.PreconditionFailedResponse
+// @Failure 422 {object} helper.ValidationErrorResponse
+// @Failure 428 {object} helper.PreconditionRequiredResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id} [patch]
+func (h *UserHandler) PatchUser(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case user.MIMEMergePatch:
+		return h.patchUserDocument(c, uid, user.ApplyMergePatch)
+	case user.MIMEJSONPatch:
+		return h.patchUserDocument(c, uid, user.ApplyJSONPatch)
+	}
+
+	req := new(user.PatchUserForm)
+	if err = c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Failed Form Binding",
+			Data:    err.Error(),
+		})
+	}
+
+	if err = c.Validate(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	current, err := h.matchedUser(c, uid)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	patchedUser, err := h.userService.PatchUser(c.Request().Context(), uid, current.Version, req)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	setUserETag(c, patchedUser)
+
+	res.Code = http.StatusOK
+	res.Message = "User updated successfully"
+	res.Data = NewUserResponse(patchedUser)
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * DeleteUser handles the HTTP request for soft deleting a user.
+ * The row is kept with DeletedAt set and can be brought back with RestoreUser.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Delete a user
+// @Description Soft deletes an active user. Requires the users:delete permission.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param If-Match header string true "ETag of the user version being changed"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 412 {object} helper.PreconditionFailedResponse
+// @Failure 428 {object} helper.PreconditionRequiredResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id} [delete]
+func (h *UserHandler) DeleteUser(c echo.Context) error {
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	current, err := h.matchedUser(c, uid)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	if err = h.userService.DeleteUser(c.Request().Context(), uid, current.Version); err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: "User deleted successfully",
+	})
+}
+
+/**
+ * RestoreUser handles the HTTP request for restoring a soft deleted user.
+ * Restoring a user that is still active is reported as a conflict.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Restore a deleted user
+// @Description Clears the soft delete marker of a user. Requires the users:restore permission.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=UserResponse}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/restore [post]
+func (h *UserHandler) RestoreUser(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	restoredUser, err := h.userService.RestoreUser(c.Request().Context(), uid)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "User restored successfully"
+	res.Data = NewUserResponse(restoredUser)
+	return c.JSON(http.StatusOK, res)
+}
+
+/**
+ * PurgeUser handles the HTTP request for permanently removing a user.
+ * This endpoint requires the users:purge permission and works on active and soft deleted users.
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Permanently delete a user
+// @Description Removes a user row for good. Requires the users:purge permission.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 404 {object} helper.NotFoundResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/{id}/purge [delete]
+func (h *UserHandler) PurgeUser(c echo.Context) error {
+	uid, err := parseUserID(c)
+	if err != nil {
+		return invalidUserIDResponse(c, err)
+	}
+
+	if err = h.userService.PurgeUser(c.Request().Context(), uid); err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: "User purged successfully",
+	})
+}
+
+// pageParams returns the requested page number and page size, applying the
+// configured default and maximum page size
+func (h *UserHandler) pageParams(req *user.ListUsersQuery) (page int, perPage int) {
+	page = req.Page
+	if page < 1 {
+		page = 1
+	}
+
+	perPage = req.PerPage
+	if perPage < 1 {
+		perPage = h.pagination.DefaultPageSize
+	}
+	if perPage > h.pagination.MaxPageSize {
+		perPage = h.pagination.MaxPageSize
+	}
+
+	return page, perPage
+}
+
+// listLinks builds the RFC 8288 paging links for a listing response. Offset
+// pages get first, prev, next and last links; cursor pages only get next.
+func listLinks(c echo.Context, meta helper.PaginationMeta) []helper.Link {
+	pageURL := func(set map[string]string) string {
+		u := *c.Request().URL
+		u.Scheme = c.Scheme()
+		u.Host = c.Request().Host
+		q := u.Query()
+		q.Del("page")
+		q.Del("cursor")
+		q.Set("per_page", strconv.Itoa(meta.PerPage))
+		for key, value := range set {
+			q.Set(key, value)
+		}
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	// Cursor pages have no page number, so they can only link forward
+	if meta.Page == 0 {
+		if meta.NextCursor == "" {
+			return nil
+		}
+		return []helper.Link{{
+			URL: pageURL(map[string]string{"cursor": meta.NextCursor}),
+			Rel: "next",
+		}}
+	}
+
+	lastPage := int((meta.Total + int64(meta.PerPage) - 1) / int64(meta.PerPage))
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := []helper.Link{
+		{URL: pageURL(map[string]string{"page": "1"}), Rel: "first"},
+	}
+	if meta.Page > 1 {
+		links = append(links, helper.Link{
+			URL: pageURL(map[string]string{"page": strconv.Itoa(meta.Page - 1)}),
+			Rel: "prev",
+		})
+	}
+	if meta.Page < lastPage {
+		links = append(links, helper.Link{
+			URL: pageURL(map[string]string{"page": strconv.Itoa(meta.Page + 1)}),
+			Rel: "next",
+		})
+	}
+	links = append(links, helper.Link{
+		URL: pageURL(map[string]string{"page": strconv.Itoa(lastPage)}),
+		Rel: "last",
+	})
+
+	return links
+}
+
+// parseUserID reads the numeric user ID from the :id path parameter
+func parseUserID(c echo.Context) (uint, error) {
+	uid, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(uid), nil
+}
+
+// invalidUserIDResponse writes the 400 response for a malformed :id path parameter
+func invalidUserIDResponse(c echo.Context, err error) error {
+	return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+		Code:    http.StatusBadRequest,
+		Message: "Invalid user ID",
+		Data:    err.Error(),
+	})
+}
+
+// forbidIncludeDeleted writes a 403 response and returns true when the filter
+// asks for soft deleted users the caller may not see
+func forbidIncludeDeleted(c echo.Context, filter user.ListFilter) (bool, error) {
+	if !filter.IncludeDeleted {
+		return false, nil
+	}
+
+	allowed, err := principal.HasPermission(c, rbac.PermUsersRestore)
+	if err != nil {
+		return true, userErrorResponse(c, err)
+	}
+	if !allowed {
+		return true, c.JSON(http.StatusForbidden, helper.ForbiddenResponse{
+			Code:    http.StatusForbidden,
+			Message: "Permission " + rbac.PermUsersRestore + " required to include deleted users",
+		})
+	}
+	return false, nil
+}
+
+// userErrorResponse maps errors from the user service to HTTP responses
+func userErrorResponse(c echo.Context, err error) error {
+	var readOnly *user.ReadOnlyFieldError
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+			Code:    http.StatusNotFound,
+			Message: "User not found",
+		})
+	case errors.Is(err, user.ErrEmailTaken):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: "Email is already registered",
+		})
+	case errors.Is(err, user.ErrVersionMismatch):
+		return c.JSON(http.StatusPreconditionFailed, helper.PreconditionFailedResponse{
+			Code:    http.StatusPreconditionFailed,
+			Message: "User was changed by another request, fetch it again and retry",
+		})
+	case errors.Is(err, errIfMatchRequired):
+		return c.JSON(http.StatusPreconditionRequired, helper.PreconditionRequiredResponse{
+			Code:    http.StatusPreconditionRequired,
+			Message: "If-Match header with the user's ETag is required",
+		})
+	case errors.As(err, &readOnly):
+		return readOnlyFieldResponse(c, readOnly)
+	case errors.Is(err, user.ErrInvalidPatch):
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid patch document",
+			Data:    err.Error(),
+		})
+	case errors.Is(err, user.ErrPatchConflict):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: "Patch cannot be applied to the user",
+			Data:    err.Error(),
+		})
+	case errors.Is(err, user.ErrUserNotDeleted):
+		return c.JSON(http.StatusConflict, helper.ConflictResponse{
+			Code:    http.StatusConflict,
+			Message: "User is not deleted",
+		})
+	default:
+		// Database and other internal errors are logged rather than shown
+		// to the client
+		c.Logger().Errorf("failed to process user request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to process data",
+		})
+	}
+}
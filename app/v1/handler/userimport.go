@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/helper"
+)
+
+// Import file formats accepted by ImportUsers
+const (
+	importFormatCSV    = "csv"
+	importFormatNDJSON = "ndjson"
+)
+
+// importFileField is the multipart form field carrying the import file
+const importFileField = "file"
+
+// errUnsupportedImportFormat is returned when the upload format cannot be determined
+var errUnsupportedImportFormat = errors.New("unsupported import format, expected csv or ndjson")
+
+/**
+ * ImportUsers handles the HTTP request for bulk importing users.
+ * The upload is streamed row by row from a CSV or NDJSON body, or from the
+ * "file" field of a multipart form, so large files are never held in memory.
+ *
+ * This method:
+ * 1. Works out the upload format from the format query parameter, the
+ *    Content-Type or the uploaded file name
+ * 2. Validates every row with the same rules as user registration
+ * 3. Saves valid rows in batched transactions unless dry_run is set
+ * 4. Returns a report of accepted and rejected rows
+ *
+ * @param c Echo context containing the HTTP request and response
+ * @return An error if one occurs during processing
+ */
+
+// @Summary Import users
+// @Description Bulk imports users from CSV (with a header row) or NDJSON. Requires the users:import permission.
+// @Tags users
+// @Accept text/csv,application/x-ndjson,multipart/form-data
+// @Produce json
+// @Param format query string false "Upload format, detected from the request when omitted" Enums(csv, ndjson)
+// @Param dry_run query bool false "Validate rows without saving them"
+// @Param file formData file false "Import file when uploading as multipart/form-data"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Security AdminToken
+// @Success 200 {object} helper.SuccessResponse{data=user.ImportReport}
+// @Failure 400 {object} helper.BadRequestResponse
+// @Failure 401 {object} helper.UnauthorizedResponse
+// @Failure 403 {object} helper.ForbiddenResponse
+// @Failure 409 {object} helper.ConflictResponse
+// @Failure 415 {object} helper.UnsupportedMediaTypeResponse
+// @Failure 422 {object} helper.UnprocessableEntityResponse
+// @Failure 500 {object} helper.InternalServerErrorResponse
+// @Router /v1/users/import [post]
+func (h *UserHandler) ImportUsers(c echo.Context) error {
+	var res helper.SuccessResponse
+
+	dryRun := false
+	if value := c.QueryParam("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid dry_run value",
+				Data:    err.Error(),
+			})
+		}
+		dryRun = parsed
+	}
+
+	body, format, err := importSource(c)
+	if errors.Is(err, errUnsupportedImportFormat) {
+		return c.JSON(http.StatusUnsupportedMediaType, helper.UnsupportedMediaTypeResponse{
+			Code:    http.StatusUnsupportedMediaType,
+			Message: "Unsupported import format",
+			Data:    err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Failed to read import file",
+			Data:    err.Error(),
+		})
+	}
+
+	var decoder user.RowDecoder
+	if format == importFormatCSV {
+		decoder, err = user.NewCSVDecoder(body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Failed to read import file",
+				Data:    err.Error(),
+			})
+		}
+	} else {
+		decoder = user.NewNDJSONDecoder(body)
+	}
+
+	report, err := h.userService.ImportUsers(c.Request().Context(), decoder, c.Validate, dryRun)
+	if err != nil {
+		c.Logger().Errorf("failed to import users: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to import data",
+		})
+	}
+
+	res.Code = http.StatusOK
+	res.Message = "Import processed"
+	if dryRun {
+		res.Message = "Import validated, nothing was saved"
+	}
+	res.Data = report
+	return c.JSON(http.StatusOK, res)
+}
+
+// importSource returns a reader over the uploaded rows and their format. For
+// multipart requests it streams the "file" part instead of buffering the form.
+func importSource(c echo.Context) (io.Reader, string, error) {
+	format := strings.ToLower(c.QueryParam("format"))
+	if format != "" && format != importFormatCSV && format != importFormatNDJSON {
+		return nil, "", errUnsupportedImportFormat
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != echo.MIMEMultipartForm {
+		if format == "" {
+			format = importFormatFromMediaType(mediaType)
+		}
+		if format == "" {
+			return nil, "", errUnsupportedImportFormat
+		}
+		return c.Request().Body, format, nil
+	}
+
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", errors.New("multipart form has no \"" + importFileField + "\" field")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() != importFileField {
+			continue
+		}
+
+		if format == "" {
+			partType, _, _ := mime.ParseMediaType(part.Header.Get(echo.HeaderContentType))
+			format = importFormatFromMediaType(partType)
+		}
+		if format == "" {
+			format = importFormatFromExtension(part.FileName())
+		}
+		if format == "" {
+			return nil, "", errUnsupportedImportFormat
+		}
+		return part, format, nil
+	}
+}
+
+// importFormatFromMediaType maps a media type to an import format, or "" if unknown
+func importFormatFromMediaType(mediaType string) string {
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return importFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return importFormatNDJSON
+	}
+	return ""
+}
+
+// importFormatFromExtension maps a file name extension to an import format, or "" if unknown
+func importFormatFromExtension(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return importFormatCSV
+	case ".ndjson", ".jsonl":
+		return importFormatNDJSON
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/validation"
+)
+
+// patchUserDocument handles a PATCH whose body is a patch document. The patch
+// is applied to the current user, the result is validated like a full update
+// and then saved under the version named by If-Match.
+func (h *UserHandler) patchUserDocument(c echo.Context, uid uint, apply func(user.User, []byte) (user.UpdateUserForm, error)) error {
+	var res helper.SuccessResponse
+
+	patch, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Failed to read patch document",
+			Data:    err.Error(),
+		})
+	}
+
+	current, err := h.matchedUser(c, uid)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	req, err := apply(current, patch)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	if err = c.Validate(&req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	patchedUser, err := h.userService.UpdateUser(c.Request().Context(), uid, current.Version, &req)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	setUserETag(c, patchedUser)
+
+	res.Code = http.StatusOK
+	res.Message = "User updated successfully"
+	res.Data = NewUserResponse(patchedUser)
+	return c.JSON(http.StatusOK, res)
+}
+
+// readOnlyFieldResponse writes the 422 response for a patch that changes a
+// field clients may not write, in the same shape as validation errors
+func readOnlyFieldResponse(c echo.Context, err *user.ReadOnlyFieldError) error {
+	return c.JSON(http.StatusUnprocessableEntity, helper.ValidationErrorResponse{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Validation failed",
+		Errors: []validation.FieldError{{
+			Field:   err.Field,
+			Rule:    "readonly",
+			Message: err.Error(),
+		}},
+	})
+}
@@ -0,0 +1,57 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// servePatch sends a patch document of the given media type as tenant acme,
+// against the user's first version
+func servePatch(e *echo.Echo, path, mediaType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, mediaType)
+	req.Header.Set(middlewares.HeaderAdminToken, adminToken)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPatchDocuments(t *testing.T) {
+	cases := []struct {
+		name      string
+		mediaType string
+		body      string
+		status    int
+		contains  string
+	}{
+		{"merge patch", "application/merge-patch+json", `{"name":"Wile E."}`, http.StatusOK, `"name":"Wile E."`},
+		{"merge patch removing a required field", "application/merge-patch+json", `{"email":null}`, http.StatusUnprocessableEntity, `"field":"email","rule":"required"`},
+		{"merge patch of a read-only field", "application/merge-patch+json", `{"ID":7}`, http.StatusUnprocessableEntity, `"field":"ID","rule":"readonly"`},
+		{"merge patch that is not an object", "application/merge-patch+json", `["name"]`, http.StatusBadRequest, "Invalid patch document"},
+		{"json patch", "application/json-patch+json", `[{"op":"test","path":"/name","value":"acme"},{"op":"replace","path":"/email","value":"road@acme.example.com"}]`, http.StatusOK, `"email":"road@acme.example.com"`},
+		{"json patch with a failing test", "application/json-patch+json", `[{"op":"test","path":"/name","value":"globex"},{"op":"replace","path":"/name","value":"x"}]`, http.StatusConflict, "Patch cannot be applied"},
+		{"json patch of a read-only field", "application/json-patch+json", `[{"op":"replace","path":"/CreatedAt","value":"2000-01-01T00:00:00Z"}]`, http.StatusUnprocessableEntity, `"field":"CreatedAt","rule":"readonly"`},
+		{"json patch moving a read-only field", "application/json-patch+json", `[{"op":"move","from":"/email_verified_at","path":"/name"}]`, http.StatusUnprocessableEntity, `"field":"email_verified_at"`},
+		{"malformed json patch", "application/json-patch+json", `{"op":"remove"}`, http.StatusBadRequest, "Invalid patch document"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newServer(t)
+			path := "/api/v1/users/" + strconv.FormatUint(uint64(s.acme), 10)
+
+			rec := servePatch(s.e, path, tc.mediaType, tc.body)
+			if rec.Code != tc.status || !strings.Contains(rec.Body.String(), tc.contains) {
+				t.Fatalf("status = %d, body %s; want %d containing %s", rec.Code, rec.Body, tc.status, tc.contains)
+			}
+		})
+	}
+}
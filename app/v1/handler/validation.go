@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/validation"
+)
+
+// validationErrorResponse writes the 422 response for request data that
+// failed validation, with one translated entry per failed rule. Other errors
+// mean the request could not be validated at all.
+func validationErrorResponse(c echo.Context, err error) error {
+	var verr *validation.Error
+	if !errors.As(err, &verr) {
+		c.Logger().Errorf("failed to validate request: %v", err)
+		return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Oops sorry, Failed to validate data",
+		})
+	}
+
+	return c.JSON(http.StatusUnprocessableEntity, helper.ValidationErrorResponse{
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Validation failed",
+		Errors:  verr.Fields(c.Request().Header.Get("Accept-Language")),
+	})
+}
@@ -0,0 +1,20 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserDependent keeps a user's API keys in step with the user: they are
+// revoked when the user is soft deleted and deleted when it is purged
+type UserDependent struct{}
+
+func (UserDependent) RevokeUser(ctx context.Context, tx *gorm.DB, userID uint, at time.Time) error {
+	return NewRepository(tx).RevokeUser(ctx, userID, at)
+}
+
+func (UserDependent) DeleteUser(ctx context.Context, tx *gorm.DB, userID uint) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&APIKey{}).Error
+}
@@ -0,0 +1,17 @@
+package apikey
+
+import "errors"
+
+var (
+	// ErrInvalidKey is returned for malformed, unknown, expired or revoked keys
+	ErrInvalidKey = errors.New("invalid, expired or revoked API key")
+	// ErrKeyNotFound is returned when the caller has no key with the given ID
+	ErrKeyNotFound = errors.New("API key not found")
+	// ErrKeyRevoked is returned when rotating a revoked key
+	ErrKeyRevoked = errors.New("API key is revoked")
+	// ErrScopeNotGranted is returned when a requested scope is not a
+	// permission the owner holds
+	ErrScopeNotGranted = errors.New("scope is not granted to the key owner")
+	// ErrExpiryInPast is returned when a key would be created already expired
+	ErrExpiryInPast = errors.New("expires_at must be in the future")
+)
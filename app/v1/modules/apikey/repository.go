@@ -0,0 +1,91 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Save(ctx context.Context, key APIKey) (APIKey, error)
+	Update(ctx context.Context, key APIKey) (APIKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (APIKey, error)
+	FindByUser(ctx context.Context, userID uint, id uint) (APIKey, error)
+	ListByUser(ctx context.Context, userID uint) ([]APIKey, error)
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+	RevokeUser(ctx context.Context, userID uint, at time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Save(ctx context.Context, key APIKey) (APIKey, error) {
+	err := r.db.WithContext(ctx).Create(&key).Error
+	if err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+func (r *repository) Update(ctx context.Context, key APIKey) (APIKey, error) {
+	err := r.db.WithContext(ctx).Save(&key).Error
+	if err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+func (r *repository) FindByPrefix(ctx context.Context, prefix string) (APIKey, error) {
+	var key APIKey
+	err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, ErrKeyNotFound
+	}
+	if err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+// FindByUser returns a key only if it belongs to the given user
+func (r *repository) FindByUser(ctx context.Context, userID uint, id uint) (APIKey, error) {
+	var key APIKey
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, ErrKeyNotFound
+	}
+	if err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+// ListByUser returns every key of a user, newest first
+func (r *repository) ListByUser(ctx context.Context, userID uint) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// TouchLastUsed records when a key was last used without touching updated_at
+func (r *repository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+// RevokeUser revokes every active key of a user
+func (r *repository) RevokeUser(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
@@ -0,0 +1,11 @@
+package apikey
+
+import "time"
+
+// CreateAPIKeyForm represents the request body for issuing an API key
+// @Description Create API key request form
+type CreateAPIKeyForm struct {
+	Name      string     `form:"name" json:"name" validate:"required,max=100" example:"nightly-export"`
+	Scopes    []string   `form:"scopes" json:"scopes" validate:"required,min=1,dive,required" example:"users:read,users:export"`
+	ExpiresAt *time.Time `form:"expires_at" json:"expires_at" example:"2026-12-31T00:00:00Z"`
+}
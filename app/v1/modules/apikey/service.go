@@ -0,0 +1,228 @@
+// Package apikey issues scoped API keys that let machine clients authenticate
+// as their owner without an interactive login
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+)
+
+// Key layout: keyPrefix, then prefixLength hex characters used for lookup, an
+// underscore, and the secret
+const (
+	keyPrefix    = "bgk_"
+	prefixLength = 12
+	secretBytes  = 32
+)
+
+// lastUsedResolution limits how often last_used_at is written for a busy key
+const lastUsedResolution = time.Minute
+
+// PermissionSource returns the permissions a user holds
+type PermissionSource interface {
+	UserPermissions(ctx context.Context, userID uint) ([]string, error)
+}
+
+// Issued is an API key together with its plaintext value, which is only
+// available right after it is created or rotated
+type Issued struct {
+	APIKey
+	Key string
+}
+
+type Service interface {
+	CreateKey(ctx context.Context, userID uint, input *CreateAPIKeyForm) (Issued, error)
+	ListKeys(ctx context.Context, userID uint) ([]APIKey, error)
+	RotateKey(ctx context.Context, userID uint, id uint) (Issued, error)
+	RevokeKey(ctx context.Context, userID uint, id uint) (APIKey, error)
+	VerifyAPIKey(ctx context.Context, key string) (uint, []string, error)
+}
+
+type service struct {
+	repository  Repository
+	permissions PermissionSource
+	now         func() time.Time
+	logger      *appLogger.LogrusLogger
+}
+
+func NewService(repository Repository, permissions PermissionSource) *service {
+	return &service{
+		repository:  repository,
+		permissions: permissions,
+		now:         time.Now,
+		logger:      appLogger.SimpleLogger("apikey"),
+	}
+}
+
+// CreateKey issues a key for the user. Scopes must be permissions the user
+// currently holds.
+func (s *service) CreateKey(ctx context.Context, userID uint, input *CreateAPIKeyForm) (Issued, error) {
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(s.now()) {
+		return Issued{}, ErrExpiryInPast
+	}
+
+	scopes, err := s.checkScopes(ctx, userID, input.Scopes)
+	if err != nil {
+		return Issued{}, err
+	}
+
+	prefix, key, err := newKey()
+	if err != nil {
+		return Issued{}, err
+	}
+
+	saved, err := s.repository.Save(ctx, APIKey{
+		UserID:    userID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   hashKey(key),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: input.ExpiresAt,
+	})
+	if err != nil {
+		return Issued{}, err
+	}
+
+	return Issued{APIKey: saved, Key: key}, nil
+}
+
+func (s *service) ListKeys(ctx context.Context, userID uint) ([]APIKey, error) {
+	return s.repository.ListByUser(ctx, userID)
+}
+
+// RotateKey replaces the secret of a key, keeping its name, scopes and
+// expiry. The old value stops working immediately.
+func (s *service) RotateKey(ctx context.Context, userID uint, id uint) (Issued, error) {
+	existing, err := s.repository.FindByUser(ctx, userID, id)
+	if err != nil {
+		return Issued{}, err
+	}
+	if existing.RevokedAt != nil {
+		return Issued{}, ErrKeyRevoked
+	}
+
+	prefix, key, err := newKey()
+	if err != nil {
+		return Issued{}, err
+	}
+	existing.Prefix = prefix
+	existing.KeyHash = hashKey(key)
+
+	saved, err := s.repository.Update(ctx, existing)
+	if err != nil {
+		return Issued{}, err
+	}
+
+	return Issued{APIKey: saved, Key: key}, nil
+}
+
+// RevokeKey disables a key for good. Revoking twice is not an error.
+func (s *service) RevokeKey(ctx context.Context, userID uint, id uint) (APIKey, error) {
+	existing, err := s.repository.FindByUser(ctx, userID, id)
+	if err != nil {
+		return existing, err
+	}
+	if existing.RevokedAt != nil {
+		return existing, nil
+	}
+
+	now := s.now()
+	existing.RevokedAt = &now
+	return s.repository.Update(ctx, existing)
+}
+
+// VerifyAPIKey checks a presented key and returns its owner and scopes
+func (s *service) VerifyAPIKey(ctx context.Context, key string) (uint, []string, error) {
+	prefix, ok := parseKey(key)
+	if !ok {
+		return 0, nil, ErrInvalidKey
+	}
+
+	stored, err := s.repository.FindByPrefix(ctx, prefix)
+	if errors.Is(err, ErrKeyNotFound) {
+		return 0, nil, ErrInvalidKey
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored.KeyHash), []byte(hashKey(key))) != 1 {
+		return 0, nil, ErrInvalidKey
+	}
+
+	now := s.now()
+	if !stored.Usable(now) {
+		return 0, nil, ErrInvalidKey
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedResolution {
+		if err = s.repository.TouchLastUsed(ctx, stored.ID, now); err != nil {
+			s.logger.Warn("Failed to record API key use", "key_id", stored.ID, "error", err)
+		}
+	}
+
+	return stored.UserID, stored.ScopeList(), nil
+}
+
+// checkScopes makes sure every scope is a permission the user holds and
+// returns them without duplicates
+func (s *service) checkScopes(ctx context.Context, userID uint, scopes []string) ([]string, error) {
+	held, err := s.permissions.UserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	granted := make(map[string]bool, len(held))
+	for _, p := range held {
+		granted[p] = true
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !granted[scope] {
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotGranted, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique, nil
+}
+
+// newKey generates a key and returns its lookup prefix and full value
+func newKey() (string, string, error) {
+	raw := make([]byte, prefixLength/2+secretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	prefix := hex.EncodeToString(raw[:prefixLength/2])
+	secret := base64.RawURLEncoding.EncodeToString(raw[prefixLength/2:])
+	return prefix, keyPrefix + prefix + "_" + secret, nil
+}
+
+// parseKey extracts the lookup prefix from a presented key
+func parseKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, keyPrefix)
+	if !ok || len(rest) <= prefixLength+1 || rest[prefixLength] != '_' {
+		return "", false
+	}
+	return rest[:prefixLength], true
+}
+
+// hashKey returns the hex SHA-256 of a key
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,139 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// acme is the tenant the keys of the tests belong to
+var acme = tenancy.Tenant{ID: 1, Slug: "acme"}
+
+// heldPermissions grants every user the same permissions
+type heldPermissions []string
+
+func (p heldPermissions) UserPermissions(context.Context, uint) ([]string, error) {
+	return p, nil
+}
+
+// newTestService returns a service whose key owners hold users:read and
+// users:write, keeping keys in an in-memory database, on a clock the test
+// moves with the returned pointer
+func newTestService(t *testing.T) (*service, *time.Time) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err = db.Use(tenancy.Plugin{}); err != nil {
+		t.Fatalf("register tenancy plugin: %v", err)
+	}
+	if err = db.AutoMigrate(&APIKey{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewService(NewRepository(db), heldPermissions{"users:read", "users:write"})
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestCreateKeyScopesArePermissionsTheOwnerHolds(t *testing.T) {
+	s, now := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	issued, err := s.CreateKey(ctx, 7, &CreateAPIKeyForm{Name: "export", Scopes: []string{"users:read", "users:read"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !slices.Equal(issued.ScopeList(), []string{"users:read"}) {
+		t.Errorf("scopes = %v, want users:read once", issued.ScopeList())
+	}
+
+	if _, err = s.CreateKey(ctx, 7, &CreateAPIKeyForm{Name: "purge", Scopes: []string{"users:read", "users:purge"}}); !errors.Is(err, ErrScopeNotGranted) {
+		t.Errorf("create with a permission the owner lacks: err = %v, want ErrScopeNotGranted", err)
+	}
+	past := now.Add(-time.Minute)
+	if _, err = s.CreateKey(ctx, 7, &CreateAPIKeyForm{Name: "expired", Scopes: []string{"users:read"}, ExpiresAt: &past}); !errors.Is(err, ErrExpiryInPast) {
+		t.Errorf("create already expired: err = %v, want ErrExpiryInPast", err)
+	}
+}
+
+func TestVerifyAPIKey(t *testing.T) {
+	s, now := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	expiry := now.Add(time.Hour)
+	issued, err := s.CreateKey(ctx, 7, &CreateAPIKeyForm{Name: "export", Scopes: []string{"users:read"}, ExpiresAt: &expiry})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	userID, scopes, err := s.VerifyAPIKey(ctx, issued.Key)
+	if err != nil || userID != 7 || !slices.Equal(scopes, []string{"users:read"}) {
+		t.Fatalf("verify: user %d with scopes %v (%v), want user 7 with users:read", userID, scopes, err)
+	}
+	cases := []struct {
+		name string
+		ctx  context.Context
+		key  string
+	}{
+		{"malformed", ctx, "not-a-key"},
+		{"tampered", ctx, issued.Key + "x"},
+		{"of another tenant", tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 2, Slug: "globex"}), issued.Key},
+	}
+	for _, tc := range cases {
+		if _, _, err = s.VerifyAPIKey(tc.ctx, tc.key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("verify %s key: err = %v, want ErrInvalidKey", tc.name, err)
+		}
+	}
+
+	*now = expiry
+	if _, _, err = s.VerifyAPIKey(ctx, issued.Key); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("verify expired key: err = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestRevokedAndRotatedKeysStopWorking(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	issued, err := s.CreateKey(ctx, 7, &CreateAPIKeyForm{Name: "export", Scopes: []string{"users:read"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	rotated, err := s.RotateKey(ctx, 7, issued.ID)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if _, _, err = s.VerifyAPIKey(ctx, issued.Key); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("verify the value before rotation: err = %v, want ErrInvalidKey", err)
+	}
+	if _, _, err = s.VerifyAPIKey(ctx, rotated.Key); err != nil {
+		t.Errorf("verify the rotated value: %v", err)
+	}
+
+	if _, err = s.RevokeKey(ctx, 8, issued.ID); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("revoke another user's key: err = %v, want ErrKeyNotFound", err)
+	}
+	if _, err = s.RevokeKey(ctx, 7, issued.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, _, err = s.VerifyAPIKey(ctx, rotated.Key); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("verify revoked key: err = %v, want ErrInvalidKey", err)
+	}
+	if _, err = s.RotateKey(ctx, 7, issued.ID); !errors.Is(err, ErrKeyRevoked) {
+		t.Errorf("rotate revoked key: err = %v, want ErrKeyRevoked", err)
+	}
+}
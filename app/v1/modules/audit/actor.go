@@ -0,0 +1,27 @@
+package audit
+
+import "context"
+
+// Actor is who makes the changes of a request
+type Actor struct {
+	Type string
+	// ID is the user making the change, or 0 when there is none
+	ID        uint
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are recorded as made by the actor
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFrom returns the actor of the context, or an anonymous one
+func ActorFrom(ctx context.Context) Actor {
+	a, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok || a.Type == "" {
+		a.Type = ActorAnonymous
+	}
+	return a
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// hashPrecision is what every supported database keeps of CreatedAt, so a
+// stored event hashes the same as when it was written
+const hashPrecision = time.Millisecond
+
+// computeHash returns the chain hash of an event: the SHA-256 of its content
+// and PrevHash. The ID is left out because it is assigned on insert. Changes
+// enter through their own digest so they can be redacted later without
+// breaking the chain; a redacted event uses the digest kept in its place.
+func computeHash(e Event) string {
+	if e.RedactedAt != nil {
+		return hashOf(e, e.ChangesDigest)
+	}
+	return hashOf(e, digest(e.Changes))
+}
+
+// digest returns the SHA-256 of the recorded changes
+func digest(changes string) string {
+	sum := sha256.Sum256([]byte(changes))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashOf(e Event, changesDigest string) string {
+	content, _ := json.Marshal([]interface{}{
+		e.PrevHash,
+		e.TenantID,
+		e.Resource,
+		e.ResourceID,
+		e.Action,
+		e.ActorType,
+		e.ActorID,
+		e.RequestID,
+		changesDigest,
+		e.CreatedAt.UnixMilli(),
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// diff returns the fields whose JSON values differ between before and after.
+// A nil side counts as a resource without fields.
+func diff(before, after interface{}) (map[string]FieldChange, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]FieldChange)
+	for name, value := range beforeFields {
+		if other, ok := afterFields[name]; !ok || !bytes.Equal(value, other) {
+			changes[name] = FieldChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = FieldChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields returns the top level members of v's JSON encoding
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if v == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
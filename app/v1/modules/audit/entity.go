@@ -0,0 +1,75 @@
+// Package audit keeps an append-only, tamper-evident log of changes to data
+package audit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionErase   = "erase"
+)
+
+// Kinds of actors that make changes
+const (
+	ActorUser      = "user"
+	ActorAPIKey    = "api_key"
+	ActorAdmin     = "admin"
+	ActorAnonymous = "anonymous"
+)
+
+// Event is one change to a resource. The events of a tenant form a hash
+// chain: Hash covers the event's content and PrevHash, the Hash of the event
+// before it, so editing or removing an event breaks the chain after it.
+type Event struct {
+	ID         uint   `gorm:"primarykey"`
+	TenantID   uint   `gorm:"not null;uniqueIndex:idx_audit_chain,priority:1;index:idx_audit_resource,priority:1"`
+	Resource   string `gorm:"type:varchar(50);not null;index:idx_audit_resource,priority:2"`
+	ResourceID uint   `gorm:"not null;index:idx_audit_resource,priority:3"`
+	Action     string `gorm:"type:varchar(20);not null"`
+	ActorType  string `gorm:"type:varchar(20);not null"`
+	// ActorID is the user who made the change, or 0 for anonymous callers and
+	// the admin token
+	ActorID   uint   `gorm:"not null;index"`
+	RequestID string `gorm:"type:varchar(64)"`
+	// Changes is a JSON object mapping each changed field to its value before
+	// and after the change
+	Changes string `gorm:"type:text"`
+	// ChangesDigest keeps the digest of Changes once they are redacted, so
+	// the event still verifies. RedactedAt is set at the same time.
+	ChangesDigest string `gorm:"type:char(64)"`
+	RedactedAt    *time.Time
+	CreatedAt     time.Time `gorm:"not null;index"`
+	// PrevHash is unique per tenant so concurrent writers cannot fork the chain
+	PrevHash string `gorm:"type:char(64);not null;uniqueIndex:idx_audit_chain,priority:2"`
+	Hash     string `gorm:"type:char(64);not null"`
+}
+
+func (Event) TableName() string {
+	return "audit_events"
+}
+
+// BeforeUpdate refuses every update, the log is append-only. Redaction of
+// personal data is the only change made, and it bypasses hooks.
+func (Event) BeforeUpdate(*gorm.DB) error {
+	return ErrAppendOnly
+}
+
+// BeforeDelete refuses every delete, the log is append-only
+func (Event) BeforeDelete(*gorm.DB) error {
+	return ErrAppendOnly
+}
+
+// FieldChange is the value of a field before and after a change. Before is
+// null for created resources and After for removed ones.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
@@ -0,0 +1,11 @@
+package audit
+
+import "errors"
+
+var (
+	// ErrAppendOnly is returned when something tries to change or remove a recorded event
+	ErrAppendOnly = errors.New("audit events cannot be changed or removed")
+
+	// ErrChainConflict is returned when another event was appended to the chain at the same time
+	ErrChainConflict = errors.New("audit chain was extended concurrently")
+)
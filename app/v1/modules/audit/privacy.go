@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// redactedChanges replaces the changes of a redacted event
+const redactedChanges = ""
+
+// PersonalData exports and erases what the audit log holds about a user for
+// data subject requests. Events stay in the chain: erasure redacts the
+// changes made to the user and keeps their digest, so every chain still
+// verifies. Events the user made to other resources are kept as they are;
+// they only refer to the user by ID.
+type PersonalData struct {
+	repository Repository
+	// resource is the name users are recorded under
+	resource string
+	now      func() time.Time
+}
+
+func NewPersonalData(repository Repository, resource string) *PersonalData {
+	return &PersonalData{repository: repository, resource: resource, now: time.Now}
+}
+
+// ExportedEvent is an audit event in a data subject export
+type ExportedEvent struct {
+	ID         uint            `json:"id"`
+	Resource   string          `json:"resource"`
+	ResourceID uint            `json:"resource_id"`
+	Action     string          `json:"action"`
+	ActorType  string          `json:"actor_type"`
+	ActorID    uint            `json:"actor_id"`
+	RequestID  string          `json:"request_id"`
+	Changes    json.RawMessage `json:"changes"`
+	RedactedAt *time.Time      `json:"redacted_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ExportUser returns the events about the user and the events they made
+func (p *PersonalData) ExportUser(ctx context.Context, userID uint) (interface{}, error) {
+	events, err := p.repository.Subject(ctx, p.resource, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]ExportedEvent, 0, len(events))
+	for _, e := range events {
+		var changes json.RawMessage
+		if e.RedactedAt == nil {
+			changes = json.RawMessage(e.Changes)
+		}
+		exported = append(exported, ExportedEvent{
+			ID:         e.ID,
+			Resource:   e.Resource,
+			ResourceID: e.ResourceID,
+			Action:     e.Action,
+			ActorType:  e.ActorType,
+			ActorID:    e.ActorID,
+			RequestID:  e.RequestID,
+			Changes:    changes,
+			RedactedAt: e.RedactedAt,
+			CreatedAt:  e.CreatedAt,
+		})
+	}
+	return exported, nil
+}
+
+// EraseUser redacts the changes recorded about the user and returns the
+// number of events redacted
+func (p *PersonalData) EraseUser(ctx context.Context, userID uint) (int64, error) {
+	events, err := p.repository.Subject(ctx, p.resource, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var redacted int64
+	now := p.now().UTC()
+	for _, e := range events {
+		if e.Resource != p.resource || e.ResourceID != userID || e.RedactedAt != nil {
+			continue
+		}
+
+		e.ChangesDigest = digest(e.Changes)
+		e.Changes = redactedChanges
+		e.RedactedAt = &now
+		if err = p.repository.Redact(ctx, e); err != nil {
+			return redacted, err
+		}
+		redacted++
+	}
+	return redacted, nil
+}
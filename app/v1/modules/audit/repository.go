@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Last(ctx context.Context) (Event, error)
+	Append(ctx context.Context, event Event) (Event, error)
+	List(ctx context.Context, filter ListFilter, limit, offset int) ([]Event, int64, error)
+	Each(ctx context.Context, fn func(event Event) error) error
+	Subject(ctx context.Context, resource string, id uint) ([]Event, error)
+	Redact(ctx context.Context, event Event) error
+	Transaction(ctx context.Context, fn func(repository Repository, tx *gorm.DB) error) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+// Last returns the newest event of the context's tenant, or the zero Event
+// when the tenant has none yet
+func (r *repository) Last(ctx context.Context) (Event, error) {
+	var event Event
+	err := r.db.WithContext(ctx).Order("id DESC").Limit(1).Find(&event).Error
+	return event, err
+}
+
+// Append inserts an event. It returns ErrChainConflict when another event
+// already follows the same previous hash.
+func (r *repository) Append(ctx context.Context, event Event) (Event, error) {
+	err := r.db.WithContext(ctx).Create(&event).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return event, ErrChainConflict
+	}
+	if err != nil {
+		return event, err
+	}
+
+	return event, nil
+}
+
+// List returns one page of events matching the filter, newest first,
+// together with the total number of matches
+func (r *repository) List(ctx context.Context, filter ListFilter, limit, offset int) ([]Event, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Event{})
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.ResourceID != 0 {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []Event
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&events).Error
+	return events, total, err
+}
+
+// Each streams every event visible to the context to fn, chain by chain in
+// the order they were appended
+func (r *repository) Each(ctx context.Context, fn func(event Event) error) error {
+	rows, err := r.db.WithContext(ctx).Model(&Event{}).Order("tenant_id, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event Event
+		if err = r.db.ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err = fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Subject returns the events about a resource together with the events
+// whose actor is the user with the same ID, oldest first
+func (r *repository) Subject(ctx context.Context, resource string, id uint) ([]Event, error) {
+	var events []Event
+	err := r.db.WithContext(ctx).
+		Where("(resource = ? AND resource_id = ?) OR actor_id = ?", resource, id, id).
+		Order("id").Find(&events).Error
+	return events, err
+}
+
+// Redact stores the redacted Changes, ChangesDigest and RedactedAt of an
+// event. Columns are written directly because the model refuses updates.
+func (r *repository) Redact(ctx context.Context, event Event) error {
+	return r.db.WithContext(ctx).Model(&event).UpdateColumns(map[string]interface{}{
+		"changes":        event.Changes,
+		"changes_digest": event.ChangesDigest,
+		"redacted_at":    event.RedactedAt,
+	}).Error
+}
+
+// Transaction runs fn in a database transaction, handing it a repository
+// bound to the transaction and the transaction itself for other writes. The
+// transaction commits when fn returns nil.
+func (r *repository) Transaction(ctx context.Context, fn func(repository Repository, tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{tx}, tx)
+	})
+}
@@ -0,0 +1,52 @@
+package audit
+
+import "time"
+
+// ListEventsQuery represents the query parameters accepted when listing audit events
+// @Description Audit event listing query parameters
+type ListEventsQuery struct {
+	Resource   string `query:"resource" validate:"omitempty,max=50" example:"user"`
+	ResourceID uint   `query:"resource_id" example:"1"`
+	Action     string `query:"action" validate:"omitempty,oneof=create update delete restore purge erase" example:"update"`
+	ActorID    uint   `query:"actor_id" example:"1"`
+	Since      string `query:"since" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" example:"2025-06-01T00:00:00Z"`
+	Until      string `query:"until" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" example:"2025-07-01T00:00:00Z"`
+	Page       int    `query:"page" validate:"omitempty,min=1,max=100000" example:"1"`
+	PerPage    int    `query:"per_page" validate:"omitempty,min=1" example:"20"`
+}
+
+// ListFilter narrows down the events returned by a listing
+type ListFilter struct {
+	Resource   string
+	ResourceID uint
+	Action     string
+	ActorID    uint
+	Since      *time.Time
+	Until      *time.Time
+}
+
+// Filter converts the query parameters into a ListFilter
+func (q ListEventsQuery) Filter() (ListFilter, error) {
+	filter := ListFilter{
+		Resource:   q.Resource,
+		ResourceID: q.ResourceID,
+		Action:     q.Action,
+		ActorID:    q.ActorID,
+	}
+
+	for _, bound := range []struct {
+		value string
+		into  **time.Time
+	}{{q.Since, &filter.Since}, {q.Until, &filter.Until}} {
+		if bound.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return filter, err
+		}
+		*bound.into = &parsed
+	}
+
+	return filter, nil
+}
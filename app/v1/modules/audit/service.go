@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
+)
+
+// appendAttempts bounds the retries of a transaction whose append lost a
+// race with another writer of the same chain
+const appendAttempts = 3
+
+// Change describes a change to record. Before is nil for created resources
+// and After for removed ones.
+type Change struct {
+	Resource   string
+	ResourceID uint
+	Action     string
+	Before     interface{}
+	After      interface{}
+}
+
+// ListResult is one page of events together with the total number of matches
+type ListResult struct {
+	Events []Event
+	Total  int64
+}
+
+// Break is an event where a chain no longer verifies
+type Break struct {
+	EventID  uint
+	TenantID uint
+	Reason   string
+}
+
+// VerifyReport is the outcome of checking every chain of the log
+type VerifyReport struct {
+	Events int
+	// Heads maps each tenant to the hash of its newest event. Recording heads
+	// elsewhere also reveals events cut from the end of a chain.
+	Heads  map[uint]string
+	Breaks []Break
+}
+
+// Intact reports whether every chain verified
+func (r VerifyReport) Intact() bool {
+	return len(r.Breaks) == 0
+}
+
+// Recorder appends changes to the audit log
+type Recorder interface {
+	Record(ctx context.Context, change Change) error
+}
+
+type Service interface {
+	Recorder
+	Transaction(ctx context.Context, fn func(tx *gorm.DB, recorder Recorder) error) error
+	List(ctx context.Context, filter ListFilter, limit, offset int) (ListResult, error)
+	Verify(ctx context.Context) (VerifyReport, error)
+}
+
+type service struct {
+	repository Repository
+	now        func() time.Time
+	// mu serializes the transactions appending from this process, so each
+	// reads the chain head the one before it committed; the unique previous
+	// hash catches writers in other processes
+	mu sync.Mutex
+}
+
+func NewService(repository Repository) *service {
+	return &service{repository: repository, now: time.Now}
+}
+
+// Record appends a change made by the context's actor to the chain of the
+// context's tenant, in a transaction of its own
+func (s *service) Record(ctx context.Context, change Change) error {
+	return s.Transaction(ctx, func(_ *gorm.DB, recorder Recorder) error {
+		return recorder.Record(ctx, change)
+	})
+}
+
+// Transaction runs fn in a database transaction, handing it the transaction
+// for the caller's writes and a recorder appending to the log through it, so
+// a change and the events describing it are committed together or not at
+// all. When a writer in another process extends the chain first, the whole
+// transaction is rolled back and run again, so fn may be called more than
+// once.
+func (s *service) Transaction(ctx context.Context, fn func(tx *gorm.DB, recorder Recorder) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		err := s.repository.Transaction(ctx, func(repository Repository, tx *gorm.DB) error {
+			return fn(tx, &recorder{repository: repository, now: s.now})
+		})
+		if !errors.Is(err, ErrChainConflict) || attempt == appendAttempts {
+			return err
+		}
+	}
+}
+
+// List returns one page of the context tenant's events, newest first
+func (s *service) List(ctx context.Context, filter ListFilter, limit, offset int) (ListResult, error) {
+	events, total, err := s.repository.List(ctx, filter, limit, offset)
+	return ListResult{Events: events, Total: total}, err
+}
+
+// Verify walks every chain visible to the context, recomputing each event's
+// hash and checking it links to the event before it. Both checks are made on
+// every event, so an event can break twice; checking continues from the
+// event after it.
+func (s *service) Verify(ctx context.Context) (VerifyReport, error) {
+	report := VerifyReport{Heads: make(map[uint]string)}
+
+	err := s.repository.Each(ctx, func(event Event) error {
+		report.Events++
+
+		if event.PrevHash != report.Heads[event.TenantID] {
+			report.Breaks = append(report.Breaks, Break{
+				EventID:  event.ID,
+				TenantID: event.TenantID,
+				Reason:   "previous hash does not match the event before it, an event was removed or altered",
+			})
+		}
+		if computeHash(event) != event.Hash {
+			report.Breaks = append(report.Breaks, Break{
+				EventID:  event.ID,
+				TenantID: event.TenantID,
+				Reason:   "content does not match its hash, the event was altered",
+			})
+		}
+		// A redacted event is verified by its kept digest, so any changes it
+		// still shows were not covered by the hash
+		if event.RedactedAt != nil && event.Changes != redactedChanges {
+			report.Breaks = append(report.Breaks, Break{
+				EventID:  event.ID,
+				TenantID: event.TenantID,
+				Reason:   "redacted event holds changes, the event was altered",
+			})
+		}
+
+		report.Heads[event.TenantID] = event.Hash
+		return nil
+	})
+
+	return report, err
+}
+
+// recorder appends events through the repository of one transaction
+type recorder struct {
+	repository Repository
+	now        func() time.Time
+}
+
+// Record appends a change made by the context's actor to the chain of the
+// context's tenant. Only the fields that differ between Before and After
+// are kept.
+func (r *recorder) Record(ctx context.Context, change Change) error {
+	tenant, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	changes, err := diff(change.Before, change.After)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	actor := ActorFrom(ctx)
+	event := Event{
+		TenantID:   tenant.ID,
+		Resource:   change.Resource,
+		ResourceID: change.ResourceID,
+		Action:     change.Action,
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		RequestID:  actor.RequestID,
+		Changes:    string(encoded),
+		CreatedAt:  r.now().UTC().Truncate(hashPrecision),
+	}
+
+	last, err := r.repository.Last(ctx)
+	if err != nil {
+		return err
+	}
+	event.PrevHash = last.Hash
+	event.Hash = computeHash(event)
+
+	_, err = r.repository.Append(ctx, event)
+	return err
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// challengeAudience marks login challenge tokens so they are never accepted
+// as access tokens or other signed tokens
+const challengeAudience = "two-factor-login"
+
+// challengeSigner issues the short-lived tokens that carry a password login
+// over to the second factor step. They are signed with a key derived from the
+// application secret, never with the access token key.
+type challengeSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+func newChallengeSigner(secret string, ttl time.Duration) *challengeSigner {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(challengeAudience))
+	return &challengeSigner{key: mac.Sum(nil), ttl: ttl, now: time.Now}
+}
+
+// sign issues a challenge token for a user who passed the password check
+func (s *challengeSigner) sign(userID uint) (string, time.Time, error) {
+	now := s.now()
+	expiresAt := now.Add(s.ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Audience:  jwt.ClaimStrings{challengeAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// parse checks the signature and expiry and returns the user ID
+func (s *challengeSigner) parse(token string) (uint, error) {
+	claims := new(jwt.RegisteredClaims)
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(challengeAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return 0, ErrInvalidChallenge
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return 0, ErrInvalidChallenge
+	}
+	return uint(userID), nil
+}
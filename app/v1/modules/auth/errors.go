@@ -0,0 +1,12 @@
+package auth
+
+import "errors"
+
+var (
+	// ErrInvalidCredentials is returned when the email or password is wrong. It
+	// deliberately does not say which one.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidChallenge is returned when a login challenge token is
+	// malformed, tampered with or expired
+	ErrInvalidChallenge = errors.New("invalid or expired login challenge")
+)
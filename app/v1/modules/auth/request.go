@@ -0,0 +1,22 @@
+package auth
+
+// LoginForm represents the request body for logging in with a password
+// @Description Login request form
+type LoginForm struct {
+	Email    string `form:"email" json:"email" validate:"required,email" example:"john.doe@example.com"`
+	Password string `form:"password" json:"password" validate:"required" example:"Secr3tPassword"`
+}
+
+// RefreshForm represents the request body carrying a refresh token
+// @Description Refresh token request form
+type RefreshForm struct {
+	RefreshToken string `form:"refresh_token" json:"refresh_token" validate:"required" example:"q3Jx0b2m8mJ6Yc1lQ0mX4o3VwKk1l7b2cXh4sV8tN0A"`
+}
+
+// SecondFactorForm represents the request body completing a login that needs
+// a second factor
+// @Description Second login step form
+type SecondFactorForm struct {
+	ChallengeToken string `json:"challenge_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Code           string `json:"code" validate:"required,max=32" example:"123456"`
+}
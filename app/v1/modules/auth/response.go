@@ -0,0 +1,35 @@
+package auth
+
+// TokenResponse represents the tokens issued after a successful login or refresh
+// @Description Issued access and refresh tokens
+type TokenResponse struct {
+	AccessToken      string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType        string `json:"token_type" example:"Bearer"`
+	ExpiresIn        int64  `json:"expires_in" example:"900"`
+	RefreshToken     string `json:"refresh_token" example:"q3Jx0b2m8mJ6Yc1lQ0mX4o3VwKk1l7b2cXh4sV8tN0A"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in" example:"2592000"`
+}
+
+// ChallengeResponse is returned by login instead of tokens when the account
+// has two-factor authentication enabled. The challenge token is sent back with
+// a code to /auth/login/2fa.
+// @Description Second factor required
+type ChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required" example:"true"`
+	ChallengeToken    string `json:"challenge_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresIn         int64  `json:"expires_in" example:"300"`
+}
+
+// LoginResult is the outcome of a password login: either tokens or, when a
+// second factor is needed, a challenge
+type LoginResult struct {
+	Tokens    *TokenResponse
+	Challenge *ChallengeResponse
+}
+
+// LoginCheck is the outcome of checking login credentials: either the
+// authenticated user or, when a second factor is needed, a challenge
+type LoginCheck struct {
+	UserID    uint
+	Challenge *ChallengeResponse
+}
@@ -0,0 +1,270 @@
+// Package auth contains authentication: password login, access tokens and
+// refresh token sessions
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/refreshtoken"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/twofactor"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+)
+
+// SecondFactor checks the second login step of users who enabled two-factor
+// authentication
+type SecondFactor interface {
+	Enabled(ctx context.Context, userID uint) (bool, error)
+	Verify(ctx context.Context, userID uint, code string) error
+}
+
+// TwoFactorOptions configures the second login step
+type TwoFactorOptions struct {
+	Verifier SecondFactor
+	// SecretKey derives the key signing challenge tokens
+	SecretKey string
+	// ChallengeTTL is how long the user has to enter their code
+	ChallengeTTL time.Duration
+}
+
+// LoginThrottle slows down and locks out repeated failed logins. Accounts are
+// identified by normalized email.
+type LoginThrottle interface {
+	Check(ctx context.Context, account, ip string) error
+	RecordFailure(ctx context.Context, account, ip string) error
+	RecordSuccess(ctx context.Context, account, ip string) error
+}
+
+type Service interface {
+	CheckCredentials(ctx context.Context, input *LoginForm, clientIP string) (LoginCheck, error)
+	CheckSecondFactor(ctx context.Context, input *SecondFactorForm, clientIP string) (uint, error)
+	Login(ctx context.Context, input *LoginForm, clientIP string) (LoginResult, error)
+	LoginSecondFactor(ctx context.Context, input *SecondFactorForm, clientIP string) (TokenResponse, error)
+	Refresh(ctx context.Context, input *RefreshForm) (TokenResponse, error)
+	Logout(ctx context.Context, input *RefreshForm) error
+	LogoutAll(ctx context.Context, userID uint) error
+}
+
+type service struct {
+	users  user.Repository
+	hasher user.PasswordHasher
+	tokens *TokenManager
+	// refreshTokens stores and rotates refresh tokens
+	refreshTokens refreshtoken.Service
+	secondFactor  SecondFactor
+	challenges    *challengeSigner
+	throttle      LoginThrottle
+	// dummyHash is compared against when the email is unknown so that
+	// failed logins take the same time whether or not the account exists
+	dummyHash string
+}
+
+func NewService(users user.Repository, hasher user.PasswordHasher, tokens *TokenManager, refreshTokens refreshtoken.Service, twoFactor TwoFactorOptions, throttle LoginThrottle) (*service, error) {
+	if twoFactor.SecretKey == "" {
+		return nil, errors.New("secret key is required to sign two-factor challenges")
+	}
+
+	dummyHash, _ := hasher.Hash("not-a-real-password")
+	return &service{
+		users:         users,
+		hasher:        hasher,
+		tokens:        tokens,
+		refreshTokens: refreshTokens,
+		secondFactor:  twoFactor.Verifier,
+		challenges:    newChallengeSigner(twoFactor.SecretKey, twoFactor.ChallengeTTL),
+		throttle:      throttle,
+		dummyHash:     dummyHash,
+	}, nil
+}
+
+// CheckCredentials checks the email and password without starting a session.
+// When the user has two-factor authentication enabled the check returns a
+// challenge for the second step instead. Wrong passwords count towards the
+// throttle of the email and the client IP.
+func (s *service) CheckCredentials(ctx context.Context, input *LoginForm, clientIP string) (LoginCheck, error) {
+	email := user.NormalizeEmail(input.Email)
+	if err := s.throttle.Check(ctx, email, clientIP); err != nil {
+		return LoginCheck{}, err
+	}
+
+	account, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, user.ErrUserNotFound) || (err == nil && account.PasswordHash == "") {
+		_ = s.hasher.Compare(s.dummyHash, input.Password)
+		return LoginCheck{}, s.loginFailed(ctx, email, clientIP, ErrInvalidCredentials)
+	}
+	if err != nil {
+		return LoginCheck{}, err
+	}
+
+	if err = s.hasher.Compare(account.PasswordHash, input.Password); err != nil {
+		if errors.Is(err, user.ErrPasswordMismatch) {
+			return LoginCheck{}, s.loginFailed(ctx, email, clientIP, ErrInvalidCredentials)
+		}
+		return LoginCheck{}, err
+	}
+
+	enabled, err := s.secondFactor.Enabled(ctx, account.ID)
+	if err != nil {
+		return LoginCheck{}, err
+	}
+	if enabled {
+		// The throttle is only reset once the second step succeeds, so a
+		// known password does not give unlimited guesses at the code
+		challenge, expiresAt, err := s.challenges.sign(account.ID)
+		if err != nil {
+			return LoginCheck{}, err
+		}
+		return LoginCheck{Challenge: &ChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+			ExpiresIn:         secondsUntil(expiresAt),
+		}}, nil
+	}
+
+	if err = s.throttle.RecordSuccess(ctx, email, clientIP); err != nil {
+		return LoginCheck{}, err
+	}
+	return LoginCheck{UserID: account.ID}, nil
+}
+
+// CheckSecondFactor checks the second login step without starting a session
+// and returns the authenticated user. The code is a TOTP code or one of the
+// user's recovery codes. Wrong codes count towards the same throttle as wrong
+// passwords.
+func (s *service) CheckSecondFactor(ctx context.Context, input *SecondFactorForm, clientIP string) (uint, error) {
+	userID, err := s.challenges.parse(input.ChallengeToken)
+	if err != nil {
+		return 0, err
+	}
+
+	account, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return 0, ErrInvalidChallenge
+		}
+		return 0, err
+	}
+
+	email := user.NormalizeEmail(account.EmailAddress())
+	if err = s.throttle.Check(ctx, email, clientIP); err != nil {
+		return 0, err
+	}
+
+	if err = s.secondFactor.Verify(ctx, userID, input.Code); err != nil {
+		if errors.Is(err, twofactor.ErrInvalidCode) {
+			return 0, s.loginFailed(ctx, email, clientIP, err)
+		}
+		return 0, err
+	}
+
+	if err = s.throttle.RecordSuccess(ctx, email, clientIP); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// Login checks the email and password and issues tokens, or a challenge for
+// the second login step when the user has two-factor authentication enabled
+func (s *service) Login(ctx context.Context, input *LoginForm, clientIP string) (LoginResult, error) {
+	check, err := s.CheckCredentials(ctx, input, clientIP)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if check.Challenge != nil {
+		return LoginResult{Challenge: check.Challenge}, nil
+	}
+
+	tokens, err := s.startSession(ctx, check.UserID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	return LoginResult{Tokens: &tokens}, nil
+}
+
+// LoginSecondFactor completes a login challenged for a second factor and
+// issues tokens
+func (s *service) LoginSecondFactor(ctx context.Context, input *SecondFactorForm, clientIP string) (TokenResponse, error) {
+	userID, err := s.CheckSecondFactor(ctx, input, clientIP)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return s.startSession(ctx, userID)
+}
+
+// loginFailed counts a failed login and returns the error to report
+func (s *service) loginFailed(ctx context.Context, email, clientIP string, cause error) error {
+	if err := s.throttle.RecordFailure(ctx, email, clientIP); err != nil {
+		return err
+	}
+	return cause
+}
+
+// Refresh rotates a refresh token and issues a new access token. The old
+// refresh token can no longer be used; presenting it again revokes the whole
+// session.
+func (s *service) Refresh(ctx context.Context, input *RefreshForm) (TokenResponse, error) {
+	refresh, err := s.refreshTokens.Rotate(ctx, input.RefreshToken)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	// Deleted users keep their refresh tokens until purge, so make sure the
+	// account still exists before handing out a new access token
+	if _, err = s.users.FindByID(ctx, refresh.UserID); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return TokenResponse{}, refreshtoken.ErrInvalidToken
+		}
+		return TokenResponse{}, err
+	}
+
+	return s.issueTokens(refresh)
+}
+
+// Logout revokes the session the refresh token belongs to. Unknown tokens are
+// ignored so logging out twice is not an error.
+func (s *service) Logout(ctx context.Context, input *RefreshForm) error {
+	err := s.refreshTokens.Revoke(ctx, input.RefreshToken)
+	if errors.Is(err, refreshtoken.ErrInvalidToken) {
+		return nil
+	}
+	return err
+}
+
+// LogoutAll revokes every session of the user
+func (s *service) LogoutAll(ctx context.Context, userID uint) error {
+	return s.refreshTokens.RevokeAll(ctx, userID, refreshtoken.RevokedLogout)
+}
+
+// startSession issues the tokens of a new login
+func (s *service) startSession(ctx context.Context, userID uint) (TokenResponse, error) {
+	refresh, err := s.refreshTokens.Issue(ctx, userID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return s.issueTokens(refresh)
+}
+
+// issueTokens builds the token response for an authenticated user from their
+// refresh token
+func (s *service) issueTokens(refresh refreshtoken.Issued) (TokenResponse, error) {
+	accessToken, expiresAt, err := s.tokens.IssueAccessToken(refresh.UserID, refresh.TenantID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        secondsUntil(expiresAt),
+		RefreshToken:     refresh.Token,
+		RefreshExpiresIn: secondsUntil(refresh.ExpiresAt),
+	}, nil
+}
+
+// secondsUntil returns the whole seconds left until t
+func secondsUntil(t time.Time) int64 {
+	return int64(time.Until(t).Round(time.Second) / time.Second)
+}
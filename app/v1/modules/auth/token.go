@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned when a token is malformed, tampered with, expired
+// or issued for another purpose
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Signing methods supported by the token manager
+const (
+	SigningMethodHS256 = "HS256"
+	SigningMethodRS256 = "RS256"
+	SigningMethodEdDSA = "EdDSA"
+)
+
+// TokenOptions configures how access tokens are signed and verified
+type TokenOptions struct {
+	SigningMethod  string
+	SecretKey      string
+	PrivateKeyFile string
+	PublicKeyFile  string
+	Issuer         string
+	AccessTTL      time.Duration
+}
+
+// AccessClaims are the claims carried by an access token. The subject is the
+// user ID.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	// TenantID is the tenant the user belongs to. The token is only accepted
+	// on requests for that tenant.
+	TenantID uint `json:"tid"`
+}
+
+// TokenManager issues and verifies signed access tokens
+type TokenManager struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	issuer    string
+	accessTTL time.Duration
+	now       func() time.Time
+}
+
+// NewTokenManager loads the signing keys described by the options. HS256 signs
+// with the secret key; RS256 and EdDSA read PEM keys from disk, deriving the
+// public key from the private key when no public key file is given.
+func NewTokenManager(opts TokenOptions) (*TokenManager, error) {
+	m := &TokenManager{
+		issuer:    opts.Issuer,
+		accessTTL: opts.AccessTTL,
+		now:       time.Now,
+	}
+
+	switch opts.SigningMethod {
+	case SigningMethodHS256:
+		if opts.SecretKey == "" {
+			return nil, errors.New("secret key is required for HS256")
+		}
+		m.method = jwt.SigningMethodHS256
+		m.signKey = []byte(opts.SecretKey)
+		m.verifyKey = []byte(opts.SecretKey)
+
+	case SigningMethodRS256:
+		privatePEM, err := os.ReadFile(opts.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+
+		publicKey := &privateKey.PublicKey
+		if opts.PublicKeyFile != "" {
+			publicPEM, err := os.ReadFile(opts.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read public key: %w", err)
+			}
+			if publicKey, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM); err != nil {
+				return nil, fmt.Errorf("failed to parse RSA public key: %w", err)
+			}
+		}
+
+		m.method = jwt.SigningMethodRS256
+		m.signKey = privateKey
+		m.verifyKey = publicKey
+
+	case SigningMethodEdDSA:
+		privatePEM, err := os.ReadFile(opts.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 private key: %w", err)
+		}
+
+		edKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key is not an Ed25519 key")
+		}
+
+		var publicKey crypto.PublicKey = edKey.Public()
+		if opts.PublicKeyFile != "" {
+			publicPEM, err := os.ReadFile(opts.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read public key: %w", err)
+			}
+			if publicKey, err = jwt.ParseEdPublicKeyFromPEM(publicPEM); err != nil {
+				return nil, fmt.Errorf("failed to parse Ed25519 public key: %w", err)
+			}
+		}
+
+		m.method = jwt.SigningMethodEdDSA
+		m.signKey = edKey
+		m.verifyKey = publicKey
+
+	default:
+		return nil, fmt.Errorf("unsupported signing method %q", opts.SigningMethod)
+	}
+
+	return m, nil
+}
+
+// IssueAccessToken returns a signed access token for the user of the tenant
+// and its expiry time
+func (m *TokenManager) IssueAccessToken(userID, tenantID uint) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.accessTTL)
+
+	claims := AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		TenantID: tenantID,
+	}
+
+	token, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// VerifyAccessToken checks the signature, issuer and expiry of an access token
+// and returns the user and tenant IDs it was issued for
+func (m *TokenManager) VerifyAccessToken(token string) (uint, uint, error) {
+	claims := new(AccessClaims)
+	_, err := jwt.ParseWithClaims(token, claims, m.keyFunc,
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+	)
+	if err != nil {
+		return 0, 0, ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 || claims.TenantID == 0 {
+		return 0, 0, ErrInvalidToken
+	}
+
+	return uint(userID), claims.TenantID, nil
+}
+
+func (m *TokenManager) keyFunc(*jwt.Token) (interface{}, error) {
+	return m.verifyKey, nil
+}
@@ -0,0 +1,14 @@
+package avatar
+
+import "errors"
+
+var (
+	// ErrNoAvatar is returned when the user has no avatar
+	ErrNoAvatar = errors.New("user has no avatar")
+	// ErrInvalidImage is returned when an upload of an allowed type cannot be
+	// decoded as an image
+	ErrInvalidImage = errors.New("file is not a valid image")
+	// ErrImageDimensions is returned when an image is wider or taller than
+	// MaxDimension pixels
+	ErrImageDimensions = errors.New("image is too large")
+)
@@ -0,0 +1,65 @@
+package avatar
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+
+	// Decoders of the accepted image types
+	_ "image/gif"
+	_ "image/jpeg"
+
+	_ "golang.org/x/image/webp"
+
+	"golang.org/x/image/draw"
+)
+
+// MaxDimension is the largest accepted width and height of an avatar. It
+// bounds the memory needed to decode one, however well it compresses.
+const MaxDimension = 4096
+
+// ImageTypes are the media types accepted as avatars
+var ImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// extensions maps accepted media types to the extension of stored originals
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// thumbnail decodes an image and returns a size by size PNG of its centre.
+// The dimensions are checked before the pixels are decoded.
+func thumbnail(data []byte, size int) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension {
+		return nil, ErrImageDimensions
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// Crop the largest centred square, then scale it
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	origin := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+	crop := image.Rectangle{Min: origin, Max: origin.Add(image.Pt(side, side))}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
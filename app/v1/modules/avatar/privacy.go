@@ -0,0 +1,76 @@
+package avatar
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/internal/storage"
+)
+
+// UserFinder finds users, including soft deleted ones
+type UserFinder interface {
+	FindByIDUnscoped(ctx context.Context, id uint) (user.User, error)
+}
+
+// PersonalData exports and erases a user's avatar for data subject requests.
+// The user row's avatar columns are cleared when the row is anonymized.
+type PersonalData struct {
+	users UserFinder
+	store storage.Storage
+}
+
+func NewPersonalData(users UserFinder, store storage.Storage) *PersonalData {
+	return &PersonalData{users, store}
+}
+
+// ExportedAvatar is the original avatar image in a data subject export.
+// Image is encoded as base64 in JSON.
+type ExportedAvatar struct {
+	ContentType string `json:"content_type"`
+	Image       []byte `json:"image"`
+}
+
+// ExportUser returns the user's avatar image, or nil if there is none
+func (p *PersonalData) ExportUser(ctx context.Context, userID uint) (interface{}, error) {
+	found, err := p.users.FindByIDUnscoped(ctx, userID)
+	if err != nil || !found.Avatar.Set() {
+		return nil, err
+	}
+
+	r, object, err := p.store.Get(ctx, found.Avatar.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	image, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &ExportedAvatar{ContentType: object.ContentType, Image: image}, nil
+}
+
+// EraseUser deletes the user's avatar images and returns how many there were
+func (p *PersonalData) EraseUser(ctx context.Context, userID uint) (int64, error) {
+	found, err := p.users.FindByIDUnscoped(ctx, userID)
+	if err != nil || !found.Avatar.Set() {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, key := range []string{found.Avatar.Key, found.Avatar.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err = p.store.Delete(ctx, key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,152 @@
+// Package avatar stores the profile images of users. The original upload and
+// a square thumbnail are kept in file storage, the user row names them, and
+// clients download them through signed, expiring URLs.
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/internal/storage"
+)
+
+// Users finds users and records which avatar they have
+type Users interface {
+	GetUserByID(ctx context.Context, id uint) (user.User, error)
+	SetAvatar(ctx context.Context, id, version uint, avatar user.Avatar) (user.User, error)
+}
+
+// Options configures avatar images
+type Options struct {
+	// ThumbnailSize is the width and height of thumbnails in pixels
+	ThumbnailSize int
+}
+
+// URLs are signed download URLs of a user's avatar
+type URLs struct {
+	URL          string
+	ThumbnailURL string
+	ExpiresAt    time.Time
+}
+
+type Service interface {
+	Upload(ctx context.Context, userID uint, upload storage.Upload) (user.User, error)
+	URLs(ctx context.Context, userID uint) (URLs, error)
+	Remove(ctx context.Context, userID uint) (user.User, error)
+}
+
+type service struct {
+	users  Users
+	store  storage.Storage
+	signer *storage.URLSigner
+	opts   Options
+}
+
+func NewService(users Users, store storage.Storage, signer *storage.URLSigner, opts Options) *service {
+	return &service{users, store, signer, opts}
+}
+
+// Upload makes an uploaded image the user's avatar. The upload must already
+// have passed the type and size limits; its dimensions are checked here. The
+// previous images are deleted once the user points at the new ones.
+func (s *service) Upload(ctx context.Context, userID uint, upload storage.Upload) (user.User, error) {
+	found, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return found, err
+	}
+
+	ext, ok := extensions[upload.ContentType]
+	if !ok {
+		return found, storage.ErrUnsupportedType
+	}
+	thumb, err := thumbnail(upload.Data, s.opts.ThumbnailSize)
+	if err != nil {
+		return found, err
+	}
+
+	// Every upload gets new keys, so URLs handed out for the previous avatar
+	// never serve the new one from a cache
+	name, err := randomName()
+	if err != nil {
+		return found, err
+	}
+	avatar := user.Avatar{
+		Key:          fmt.Sprintf("avatars/%d/%s%s", userID, name, ext),
+		ThumbnailKey: fmt.Sprintf("avatars/%d/%s_%d.png", userID, name, s.opts.ThumbnailSize),
+	}
+
+	if err = s.store.Put(ctx, avatar.Key, bytes.NewReader(upload.Data), int64(len(upload.Data)), upload.ContentType); err != nil {
+		return found, err
+	}
+	if err = s.store.Put(ctx, avatar.ThumbnailKey, bytes.NewReader(thumb), int64(len(thumb)), "image/png"); err != nil {
+		s.delete(ctx, avatar)
+		return found, err
+	}
+
+	updated, err := s.users.SetAvatar(ctx, userID, found.Version, avatar)
+	if err != nil {
+		s.delete(ctx, avatar)
+		return updated, err
+	}
+
+	s.delete(ctx, found.Avatar)
+	return updated, nil
+}
+
+// URLs returns signed download URLs of the user's avatar and its thumbnail
+func (s *service) URLs(ctx context.Context, userID uint) (URLs, error) {
+	found, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return URLs{}, err
+	}
+	if !found.Avatar.Set() {
+		return URLs{}, ErrNoAvatar
+	}
+
+	url, expiresAt := s.signer.URL(found.Avatar.Key)
+	thumbnailURL, _ := s.signer.URL(found.Avatar.ThumbnailKey)
+	return URLs{URL: url, ThumbnailURL: thumbnailURL, ExpiresAt: expiresAt}, nil
+}
+
+// Remove clears the user's avatar and deletes its images
+func (s *service) Remove(ctx context.Context, userID uint) (user.User, error) {
+	found, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return found, err
+	}
+	if !found.Avatar.Set() {
+		return found, ErrNoAvatar
+	}
+
+	updated, err := s.users.SetAvatar(ctx, userID, found.Version, user.Avatar{})
+	if err != nil {
+		return updated, err
+	}
+
+	s.delete(ctx, found.Avatar)
+	return updated, nil
+}
+
+// delete removes the images of an avatar. Failures are ignored: no user
+// points at the images any more, so at worst they are left behind unused.
+func (s *service) delete(ctx context.Context, avatar user.Avatar) {
+	for _, key := range []string{avatar.Key, avatar.ThumbnailKey} {
+		if key != "" {
+			_ = s.store.Delete(ctx, key)
+		}
+	}
+}
+
+// randomName returns a hard to guess object name
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,18 @@
+// Package identity links users to their accounts at external OpenID Connect
+// providers and resolves provider logins to users
+package identity
+
+import "time"
+
+// Identity is a user's account at an external provider, identified by the
+// provider's stable subject rather than by email
+type Identity struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TenantID  uint   `gorm:"not null;uniqueIndex:idx_identity_tenant_subject,priority:1"`
+	UserID    uint   `gorm:"not null;index"`
+	Provider  string `gorm:"type:varchar(64);not null;uniqueIndex:idx_identity_tenant_subject"`
+	Subject   string `gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_tenant_subject"`
+	// Email is what the provider last reported, kept for display only
+	Email string `gorm:"type:varchar(320)"`
+}
@@ -0,0 +1,21 @@
+package identity
+
+import "errors"
+
+var (
+	// ErrIdentityNotFound is returned when no identity matches
+	ErrIdentityNotFound = errors.New("linked identity not found")
+	// ErrIdentityTaken is returned when linking a provider account that is
+	// already linked to another user
+	ErrIdentityTaken = errors.New("this provider account is linked to another user")
+	// ErrEmailUnverified is returned when a provider login would create a
+	// user but the provider did not vouch for the email
+	ErrEmailUnverified = errors.New("the identity provider did not supply a verified email")
+	// ErrAccountExists is returned when a provider login matches the email of
+	// an existing user. The user must log in and link the provider instead,
+	// so a provider cannot take over an account by asserting its email.
+	ErrAccountExists = errors.New("an account with this email already exists, log in and link the provider from your account")
+	// ErrLastLoginMethod is returned when unlinking would leave a user
+	// without a password or any other linked provider to log in with
+	ErrLastLoginMethod = errors.New("cannot unlink the only way to log in, set a password first")
+)
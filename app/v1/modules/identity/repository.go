@@ -0,0 +1,85 @@
+package identity
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Save(ctx context.Context, identity Identity) (Identity, error)
+	FindBySubject(ctx context.Context, provider, subject string) (Identity, error)
+	FindByUser(ctx context.Context, userID uint, id uint) (Identity, error)
+	ListByUser(ctx context.Context, userID uint) ([]Identity, error)
+	UpdateEmail(ctx context.Context, id uint, email string) error
+	Delete(ctx context.Context, identity Identity) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+// WithTx returns a repository whose queries run in the transaction tx
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{tx}
+}
+
+func (r *repository) Save(ctx context.Context, identity Identity) (Identity, error) {
+	err := r.db.WithContext(ctx).Create(&identity).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return identity, ErrIdentityTaken
+	}
+	if err != nil {
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+func (r *repository) FindBySubject(ctx context.Context, provider, subject string) (Identity, error) {
+	var identity Identity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return identity, ErrIdentityNotFound
+	}
+	if err != nil {
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+// FindByUser returns an identity only if it belongs to the given user
+func (r *repository) FindByUser(ctx context.Context, userID uint, id uint) (Identity, error) {
+	var identity Identity
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return identity, ErrIdentityNotFound
+	}
+	if err != nil {
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+// ListByUser returns every identity of a user, oldest first
+func (r *repository) ListByUser(ctx context.Context, userID uint) ([]Identity, error) {
+	var identities []Identity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+func (r *repository) UpdateEmail(ctx context.Context, id uint, email string) error {
+	return r.db.WithContext(ctx).Model(&Identity{}).Where("id = ?", id).UpdateColumn("email", email).Error
+}
+
+func (r *repository) Delete(ctx context.Context, identity Identity) error {
+	return r.db.WithContext(ctx).Delete(&identity).Error
+}
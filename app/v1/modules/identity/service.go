@@ -0,0 +1,200 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/oidc"
+	"gorm.io/gorm"
+)
+
+// maxNameLength matches the width of the users name column
+const maxNameLength = 250
+
+type Service interface {
+	Login(ctx context.Context, provider string, claims oidc.Claims) (uint, error)
+	Link(ctx context.Context, userID uint, provider string, claims oidc.Claims) (Identity, error)
+	List(ctx context.Context, userID uint) ([]Identity, error)
+	Unlink(ctx context.Context, userID uint, id uint) error
+}
+
+// Accounts creates the users of first provider logins, recording them in the
+// audit log
+type Accounts interface {
+	CreateUser(ctx context.Context, account user.User, with func(tx *gorm.DB, account user.User) error) (user.User, error)
+}
+
+type service struct {
+	repository Repository
+	users      user.Repository
+	accounts   Accounts
+	now        func() time.Time
+	logger     *appLogger.LogrusLogger
+}
+
+func NewService(repository Repository, users user.Repository, accounts Accounts) *service {
+	return &service{
+		repository: repository,
+		users:      users,
+		accounts:   accounts,
+		now:        time.Now,
+		logger:     appLogger.SimpleLogger("identity"),
+	}
+}
+
+// Login resolves a provider login to a user. A linked identity logs its user
+// in. Otherwise a new user is created from the provider's verified email;
+// emails that already belong to a user are refused so that user has to link
+// the provider while logged in.
+func (s *service) Login(ctx context.Context, provider string, claims oidc.Claims) (uint, error) {
+	identity, err := s.repository.FindBySubject(ctx, provider, claims.Subject)
+	if err == nil {
+		if _, err = s.users.FindByID(ctx, identity.UserID); err != nil {
+			return 0, err
+		}
+		s.refreshEmail(ctx, identity, claims)
+		return identity.UserID, nil
+	}
+	if !errors.Is(err, ErrIdentityNotFound) {
+		return 0, err
+	}
+
+	email := user.NormalizeEmail(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		return 0, ErrEmailUnverified
+	}
+	if _, err = s.users.FindByEmail(ctx, email); err == nil {
+		return 0, ErrAccountExists
+	} else if !errors.Is(err, user.ErrUserNotFound) {
+		return 0, err
+	}
+
+	verifiedAt := s.now()
+	account := user.User{
+		Name:            displayName(claims.Name, email),
+		Email:           &email,
+		EmailVerifiedAt: &verifiedAt,
+		Version:         1,
+	}
+	// The user and their first identity are saved together so a failed link
+	// never leaves an account nobody can log in to
+	account, err = s.accounts.CreateUser(ctx, account, func(tx *gorm.DB, account user.User) error {
+		var err error
+		identity, err = s.repository.WithTx(tx).Save(ctx, Identity{
+			UserID:   account.ID,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    email,
+		})
+		return err
+	})
+	if errors.Is(err, user.ErrEmailTaken) {
+		// A soft deleted user still holds the email
+		return 0, ErrAccountExists
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("User created from identity provider", "user_id", account.ID, "provider", provider, "identity_id", identity.ID)
+	return account.ID, nil
+}
+
+// Link attaches a provider account to the user. Linking the same account
+// twice returns the existing identity.
+func (s *service) Link(ctx context.Context, userID uint, provider string, claims oidc.Claims) (Identity, error) {
+	if _, err := s.users.FindByID(ctx, userID); err != nil {
+		return Identity{}, err
+	}
+
+	existing, err := s.repository.FindBySubject(ctx, provider, claims.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return Identity{}, ErrIdentityTaken
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, ErrIdentityNotFound) {
+		return Identity{}, err
+	}
+
+	identity, err := s.repository.Save(ctx, Identity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    user.NormalizeEmail(claims.Email),
+	})
+	if err != nil {
+		return identity, err
+	}
+
+	s.logger.Info("Identity linked", "user_id", userID, "provider", provider, "identity_id", identity.ID)
+	return identity, nil
+}
+
+// List returns the identities linked to the user
+func (s *service) List(ctx context.Context, userID uint) ([]Identity, error) {
+	return s.repository.ListByUser(ctx, userID)
+}
+
+// Unlink removes one of the user's identities, unless it is the only way
+// left for them to log in
+func (s *service) Unlink(ctx context.Context, userID uint, id uint) error {
+	identity, err := s.repository.FindByUser(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if account.PasswordHash == "" {
+		identities, err := s.repository.ListByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if len(identities) <= 1 {
+			return ErrLastLoginMethod
+		}
+	}
+
+	if err = s.repository.Delete(ctx, identity); err != nil {
+		return err
+	}
+
+	s.logger.Info("Identity unlinked", "user_id", userID, "provider", identity.Provider, "identity_id", identity.ID)
+	return nil
+}
+
+// refreshEmail keeps the displayed provider email current. Failures only
+// cost a stale display value, so they are logged rather than failing the
+// login.
+func (s *service) refreshEmail(ctx context.Context, identity Identity, claims oidc.Claims) {
+	email := user.NormalizeEmail(claims.Email)
+	if email == identity.Email {
+		return
+	}
+	if err := s.repository.UpdateEmail(ctx, identity.ID, email); err != nil {
+		s.logger.Warn("Failed to update identity email", "identity_id", identity.ID, "error", err)
+	}
+}
+
+// displayName picks the provider's name for a new user, falling back to the
+// part of the email before the @
+func displayName(name, email string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	for utf8.RuneCountInString(name) > maxNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
@@ -0,0 +1,78 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// databaseStore keeps counters in the login_attempts table so every instance
+// of the API sees the same counts
+type databaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *databaseStore {
+	return &databaseStore{db}
+}
+
+func (s *databaseStore) Get(ctx context.Context, kind, value string) (LoginAttempt, error) {
+	var attempt LoginAttempt
+	err := s.db.WithContext(ctx).Where("kind = ? AND value = ?", kind, value).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return LoginAttempt{Kind: kind, Value: value}, nil
+	}
+	if err != nil {
+		return attempt, err
+	}
+
+	return attempt, nil
+}
+
+// RecordFailure counts the failure with a single upsert, so concurrent
+// failures from several instances are never lost
+func (s *databaseStore) RecordFailure(ctx context.Context, kind, value string, now time.Time, window time.Duration) (LoginAttempt, error) {
+	attempt := LoginAttempt{Kind: kind, Value: value, Failures: 1, LastFailureAt: now}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "kind"}, {Name: "value"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures": gorm.Expr(
+				"CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END",
+				now.Add(-window),
+			),
+			"last_failure_at": now,
+		}),
+	}).Create(&attempt).Error
+	if err != nil {
+		return attempt, err
+	}
+
+	return s.Get(ctx, kind, value)
+}
+
+func (s *databaseStore) Block(ctx context.Context, kind, value string, until time.Time, locked bool) error {
+	return s.db.WithContext(ctx).Model(&LoginAttempt{}).
+		Where("kind = ? AND value = ?", kind, value).
+		Updates(map[string]interface{}{"blocked_until": until, "locked": locked}).Error
+}
+
+func (s *databaseStore) Reset(ctx context.Context, kind, value string) error {
+	return s.db.WithContext(ctx).Where("kind = ? AND value = ?", kind, value).Delete(&LoginAttempt{}).Error
+}
+
+func (s *databaseStore) List(ctx context.Context) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+	err := s.db.WithContext(ctx).Order("last_failure_at DESC").Find(&attempts).Error
+	return attempts, err
+}
+
+func (s *databaseStore) DeleteStale(ctx context.Context, now time.Time, window time.Duration) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("last_failure_at < ?", now.Add(-window)).
+		Where("blocked_until IS NULL OR blocked_until <= ?", now).
+		Delete(&LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,35 @@
+package lockout
+
+import "time"
+
+// Kinds of login attempt counters
+const (
+	KindAccount = "account"
+	KindIP      = "ip"
+)
+
+// LoginAttempt counts recent failed logins for an account or a client IP.
+// Accounts are identified by normalized email so unknown emails are counted
+// the same way as registered ones. Counters are kept per tenant.
+type LoginAttempt struct {
+	TenantID      uint      `gorm:"primaryKey;autoIncrement:false"`
+	Kind          string    `gorm:"type:varchar(16);primaryKey"`
+	Value         string    `gorm:"type:varchar(320);primaryKey"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null;index"`
+	// BlockedUntil is when the next attempt is allowed. Locked tells a
+	// lockout apart from a back-off delay.
+	BlockedUntil *time.Time
+	Locked       bool `gorm:"not null;default:false"`
+}
+
+// Blocked reports whether attempts are refused at now
+func (a LoginAttempt) Blocked(now time.Time) bool {
+	return a.BlockedUntil != nil && now.Before(*a.BlockedUntil)
+}
+
+// Stale reports whether the counter has nothing left to enforce: its last
+// failure is older than the window and it blocks nothing
+func (a LoginAttempt) Stale(now time.Time, window time.Duration) bool {
+	return !a.Blocked(now) && now.Sub(a.LastFailureAt) >= window
+}
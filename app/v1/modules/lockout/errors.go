@@ -0,0 +1,37 @@
+package lockout
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnknownKind is returned when unlocking a counter of an unknown kind
+var ErrUnknownKind = errors.New("unknown lockout kind")
+
+// BlockedError is returned when a login is refused because of earlier
+// failures
+type BlockedError struct {
+	// Until is when the next attempt is allowed
+	Until time.Time
+	// Locked is true for a lockout and false for a back-off delay
+	Locked bool
+}
+
+func (e *BlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("login temporarily locked until %s", e.Until.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("too many failed logins, retry after %s", e.Until.Format(time.RFC3339))
+}
+
+// RetryAfter returns the whole seconds until the next attempt is allowed,
+// rounded up
+func (e *BlockedError) RetryAfter(now time.Time) int64 {
+	wait := e.Until.Sub(now)
+	seconds := int64(wait / time.Second)
+	if wait%time.Second > 0 {
+		seconds++
+	}
+	return seconds
+}
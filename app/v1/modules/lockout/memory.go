@@ -0,0 +1,139 @@
+package lockout
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// memoryStore keeps counters in process memory. Counters are lost on restart
+// and not shared between instances. Counters are kept per tenant like the
+// database store's.
+type memoryStore struct {
+	mu       sync.Mutex
+	attempts map[memoryKey]LoginAttempt
+}
+
+type memoryKey struct {
+	tenantID uint
+	kind     string
+	value    string
+}
+
+// key returns the map key of a counter of the context's tenant
+func key(ctx context.Context, kind, value string) (memoryKey, error) {
+	t, err := tenancy.Require(ctx)
+	if err != nil {
+		return memoryKey{}, err
+	}
+	return memoryKey{t.ID, kind, value}, nil
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{attempts: make(map[memoryKey]LoginAttempt)}
+}
+
+func (s *memoryStore) Get(ctx context.Context, kind, value string) (LoginAttempt, error) {
+	key, err := key(ctx, kind, value)
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return LoginAttempt{Kind: kind, Value: value}, nil
+	}
+	return attempt, nil
+}
+
+func (s *memoryStore) RecordFailure(ctx context.Context, kind, value string, now time.Time, window time.Duration) (LoginAttempt, error) {
+	key, err := key(ctx, kind, value)
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok || now.Sub(attempt.LastFailureAt) >= window {
+		attempt.Failures = 0
+	}
+	attempt.TenantID, attempt.Kind, attempt.Value = key.tenantID, kind, value
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	s.attempts[key] = attempt
+
+	return attempt, nil
+}
+
+func (s *memoryStore) Block(ctx context.Context, kind, value string, until time.Time, locked bool) error {
+	key, err := key(ctx, kind, value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil
+	}
+	attempt.BlockedUntil = &until
+	attempt.Locked = locked
+	s.attempts[key] = attempt
+
+	return nil
+}
+
+func (s *memoryStore) Reset(ctx context.Context, kind, value string) error {
+	key, err := key(ctx, kind, value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := make([]LoginAttempt, 0, len(s.attempts))
+	for _, attempt := range s.attempts {
+		if tenancy.Matches(ctx, attempt.TenantID) {
+			attempts = append(attempts, attempt)
+		}
+	}
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].LastFailureAt.After(attempts[j].LastFailureAt)
+	})
+
+	return attempts, nil
+}
+
+func (s *memoryStore) DeleteStale(ctx context.Context, now time.Time, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, attempt := range s.attempts {
+		if attempt.Stale(now, window) && tenancy.Matches(ctx, key.tenantID) {
+			delete(s.attempts, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
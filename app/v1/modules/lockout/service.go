@@ -0,0 +1,203 @@
+// Package lockout protects password logins against brute force. Failed
+// logins are counted per account and per client IP; repeated failures on an
+// account are slowed down with an exponential back-off, and either counter
+// reaching its threshold locks logins for a while.
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// Options configures the thresholds, see config.LoginProtectionConfigurations
+type Options struct {
+	Window               time.Duration
+	DelayAfter           int
+	BaseDelay            time.Duration
+	MaxDelay             time.Duration
+	AccountLockThreshold int
+	IPLockThreshold      int
+	LockDuration         time.Duration
+}
+
+type Service interface {
+	Check(ctx context.Context, account, ip string) error
+	RecordFailure(ctx context.Context, account, ip string) error
+	RecordSuccess(ctx context.Context, account, ip string) error
+	List(ctx context.Context) ([]LoginAttempt, error)
+	Unlock(ctx context.Context, kind, value string) error
+}
+
+type service struct {
+	store  Store
+	opts   Options
+	logger *appLogger.LogrusLogger
+	now    func() time.Time
+
+	// lastPrune limits stale counter cleanup to once per window
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+}
+
+func NewService(store Store, opts Options) *service {
+	return &service{
+		store:  store,
+		opts:   opts,
+		logger: appLogger.SimpleLogger("lockout"),
+		now:    time.Now,
+	}
+}
+
+// Check returns a *BlockedError when logins for the account or from the IP
+// are currently refused
+func (s *service) Check(ctx context.Context, account, ip string) error {
+	now := s.now()
+	var blocked *BlockedError
+	for _, counter := range [][2]string{{KindAccount, account}, {KindIP, ip}} {
+		if counter[1] == "" {
+			continue
+		}
+		attempt, err := s.store.Get(ctx, counter[0], counter[1])
+		if err != nil {
+			return err
+		}
+		if !attempt.Blocked(now) {
+			continue
+		}
+		if blocked == nil || attempt.BlockedUntil.After(blocked.Until) {
+			blocked = &BlockedError{Until: *attempt.BlockedUntil, Locked: attempt.Locked}
+		}
+	}
+	if blocked == nil {
+		return nil
+	}
+
+	s.logger.Warn("Login refused while blocked",
+		"event", "login_blocked", "account", account, "ip", ip,
+		"locked", blocked.Locked, "until", blocked.Until.Format(time.RFC3339))
+	return blocked
+}
+
+// RecordFailure counts a failed login for the account and the IP and blocks
+// further attempts once a threshold is reached
+func (s *service) RecordFailure(ctx context.Context, account, ip string) error {
+	now := s.now()
+	s.pruneStale(ctx, now)
+
+	var accountFailures, ipFailures int
+	if account != "" {
+		attempt, err := s.store.RecordFailure(ctx, KindAccount, account, now, s.opts.Window)
+		if err != nil {
+			return err
+		}
+		accountFailures = attempt.Failures
+		if err = s.block(ctx, attempt, s.opts.AccountLockThreshold, true, now); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		attempt, err := s.store.RecordFailure(ctx, KindIP, ip, now, s.opts.Window)
+		if err != nil {
+			return err
+		}
+		ipFailures = attempt.Failures
+		if err = s.block(ctx, attempt, s.opts.IPLockThreshold, false, now); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Warn("Login failed",
+		"event", "login_failed", "account", account, "ip", ip,
+		"account_failures", accountFailures, "ip_failures", ipFailures)
+	return nil
+}
+
+// RecordSuccess clears the account counter after a completed login. The IP
+// counter is kept so one valid account cannot be used to reset it.
+func (s *service) RecordSuccess(ctx context.Context, account, ip string) error {
+	if account == "" {
+		return nil
+	}
+	return s.store.Reset(ctx, KindAccount, account)
+}
+
+// List returns the counters with their lock state, stale ones excluded
+func (s *service) List(ctx context.Context) ([]LoginAttempt, error) {
+	attempts, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	active := make([]LoginAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		if !attempt.Stale(now, s.opts.Window) {
+			active = append(active, attempt)
+		}
+	}
+	return active, nil
+}
+
+// Unlock clears a counter, lifting any lock or delay on it
+func (s *service) Unlock(ctx context.Context, kind, value string) error {
+	if kind != KindAccount && kind != KindIP {
+		return ErrUnknownKind
+	}
+	if err := s.store.Reset(ctx, kind, value); err != nil {
+		return err
+	}
+
+	s.logger.Info("Login lock cleared", "event", "login_unlocked", "kind", kind, "value", value)
+	return nil
+}
+
+// block applies the lock or back-off delay earned by the counter's failures.
+// Only accounts are slowed down; an IP is just locked at its threshold since
+// many users may share it.
+func (s *service) block(ctx context.Context, attempt LoginAttempt, lockThreshold int, backOff bool, now time.Time) error {
+	if attempt.Failures >= lockThreshold {
+		until := now.Add(s.opts.LockDuration)
+		s.logger.Warn("Login locked after repeated failures",
+			"event", "login_locked", "kind", attempt.Kind, "value", attempt.Value,
+			"failures", attempt.Failures, "until", until.Format(time.RFC3339))
+		return s.store.Block(ctx, attempt.Kind, attempt.Value, until, true)
+	}
+	if !backOff || attempt.Failures < s.opts.DelayAfter {
+		return nil
+	}
+
+	return s.store.Block(ctx, attempt.Kind, attempt.Value, now.Add(s.delay(attempt.Failures)), false)
+}
+
+// delay is BaseDelay doubled for every failure past DelayAfter, capped at
+// MaxDelay
+func (s *service) delay(failures int) time.Duration {
+	delay := s.opts.BaseDelay
+	for i := s.opts.DelayAfter; i < failures && delay < s.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.opts.MaxDelay {
+		delay = s.opts.MaxDelay
+	}
+	return delay
+}
+
+// pruneStale deletes counters of every tenant with nothing left to enforce,
+// at most once per window. Failures are only logged since pruning is housekeeping.
+func (s *service) pruneStale(ctx context.Context, now time.Time) {
+	s.pruneMu.Lock()
+	if now.Sub(s.lastPrune) < s.opts.Window {
+		s.pruneMu.Unlock()
+		return
+	}
+	s.lastPrune = now
+	s.pruneMu.Unlock()
+
+	if _, err := s.store.DeleteStale(tenancy.AllTenants(ctx), now, s.opts.Window); err != nil {
+		s.logger.Warn("Failed to prune stale login counters", "error", err)
+	}
+}
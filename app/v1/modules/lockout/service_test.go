@@ -0,0 +1,202 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// acme is the tenant the counters of the tests belong to
+var acme = tenancy.Tenant{ID: 1, Slug: "acme"}
+
+// newTestService returns a service counting in memory, slowing an account
+// down from its third failure and locking it at its tenth, on a clock the
+// test moves with the returned pointer
+func newTestService(t *testing.T) (*service, *time.Time) {
+	t.Helper()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewService(NewMemoryStore(), Options{
+		Window:               15 * time.Minute,
+		DelayAfter:           3,
+		BaseDelay:            time.Second,
+		MaxDelay:             8 * time.Second,
+		AccountLockThreshold: 10,
+		IPLockThreshold:      20,
+		LockDuration:         time.Hour,
+	})
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+// blocked returns the error of Check as a *BlockedError, nil when the login
+// is allowed
+func blocked(t *testing.T, s *service, ctx context.Context, account, ip string) *BlockedError {
+	t.Helper()
+	err := s.Check(ctx, account, ip)
+	if err == nil {
+		return nil
+	}
+	var blockedErr *BlockedError
+	if !errors.As(err, &blockedErr) {
+		t.Fatalf("check: %v", err)
+	}
+	return blockedErr
+}
+
+// failures returns the failures counted for the counter
+func failures(t *testing.T, s *service, ctx context.Context, kind, value string) int {
+	t.Helper()
+	attempt, err := s.store.Get(ctx, kind, value)
+	if err != nil {
+		t.Fatalf("get %s counter: %v", kind, err)
+	}
+	return attempt.Failures
+}
+
+func TestAccountFailuresBackOffThenLock(t *testing.T) {
+	s, now := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	// The delay doubles from the third failure and stays at MaxDelay
+	delays := map[int]time.Duration{3: time.Second, 4: 2 * time.Second, 5: 4 * time.Second, 6: 8 * time.Second, 7: 8 * time.Second, 8: 8 * time.Second, 9: 8 * time.Second}
+	for failure := 1; failure < 10; failure++ {
+		if err := s.RecordFailure(ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("record failure %d: %v", failure, err)
+		}
+		err := blocked(t, s, ctx, "road@acme.example.com", "10.0.0.1")
+		delay, delayed := delays[failure]
+		if !delayed {
+			if err != nil {
+				t.Fatalf("after %d failures: %v, want the login allowed", failure, err)
+			}
+			continue
+		}
+		if err == nil || err.Locked || !err.Until.Equal(now.Add(delay)) {
+			t.Fatalf("after %d failures: blocked = %v, want a delay of %v", failure, err, delay)
+		}
+		*now = err.Until
+		if err = blocked(t, s, ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("after the delay of failure %d: %v, want the login allowed", failure, err)
+		}
+	}
+
+	if err := s.RecordFailure(ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("record failure 10: %v", err)
+	}
+	err := blocked(t, s, ctx, "road@acme.example.com", "10.0.0.1")
+	if err == nil || !err.Locked || !err.Until.Equal(now.Add(time.Hour)) {
+		t.Fatalf("after 10 failures: blocked = %v, want locked for an hour", err)
+	}
+	if err = blocked(t, s, ctx, "coyote@acme.example.com", "10.0.0.1"); err != nil {
+		t.Errorf("other account from the same IP: %v, want the login allowed", err)
+	}
+}
+
+func TestIPFailuresLock(t *testing.T) {
+	s, now := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	for failure := 1; failure <= 20; failure++ {
+		if err := s.RecordFailure(ctx, fmt.Sprintf("user%d@acme.example.com", failure), "10.0.0.1"); err != nil {
+			t.Fatalf("record failure %d: %v", failure, err)
+		}
+		err := blocked(t, s, ctx, "road@acme.example.com", "10.0.0.1")
+		if failure < 20 && err != nil {
+			t.Fatalf("after %d failures from the IP: %v, want the login allowed", failure, err)
+		}
+		if failure == 20 && (err == nil || !err.Locked || !err.Until.Equal(now.Add(time.Hour))) {
+			t.Fatalf("after 20 failures from the IP: blocked = %v, want locked for an hour", err)
+		}
+	}
+	if err := blocked(t, s, ctx, "road@acme.example.com", "10.0.0.2"); err != nil {
+		t.Errorf("same account from another IP: %v, want the login allowed", err)
+	}
+}
+
+func TestFailuresOutsideTheWindowStartOver(t *testing.T) {
+	s, now := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	for failure := 1; failure <= 5; failure++ {
+		if err := s.RecordFailure(ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("record failure %d: %v", failure, err)
+		}
+	}
+
+	*now = now.Add(15 * time.Minute)
+	if err := s.RecordFailure(ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("record failure after the window: %v", err)
+	}
+	if got := failures(t, s, ctx, KindAccount, "road@acme.example.com"); got != 1 {
+		t.Errorf("account failures = %d, want 1", got)
+	}
+	if got := failures(t, s, ctx, KindIP, "10.0.0.1"); got != 1 {
+		t.Errorf("IP failures = %d, want 1", got)
+	}
+	if err := blocked(t, s, ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+		t.Errorf("after a single failure: %v, want the login allowed", err)
+	}
+}
+
+func TestSuccessKeepsTheIPCounter(t *testing.T) {
+	s, now := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	for failure := 1; failure <= 3; failure++ {
+		if err := s.RecordFailure(ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("record failure %d: %v", failure, err)
+		}
+	}
+	*now = now.Add(time.Second)
+	if err := s.RecordSuccess(ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("record success: %v", err)
+	}
+
+	if got := failures(t, s, ctx, KindAccount, "road@acme.example.com"); got != 0 {
+		t.Errorf("account failures = %d, want 0", got)
+	}
+	if got := failures(t, s, ctx, KindIP, "10.0.0.1"); got != 3 {
+		t.Errorf("IP failures = %d, want 3", got)
+	}
+	if err := s.RecordFailure(ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("record failure after success: %v", err)
+	}
+	if err := blocked(t, s, ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+		t.Errorf("first failure after success: %v, want the login allowed", err)
+	}
+}
+
+func TestUnlockLiftsALock(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	for failure := 1; failure <= 20; failure++ {
+		if err := s.RecordFailure(ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("record failure %d: %v", failure, err)
+		}
+	}
+
+	if err := s.Unlock(ctx, KindAccount, "road@acme.example.com"); err != nil {
+		t.Fatalf("unlock account: %v", err)
+	}
+	if err := blocked(t, s, ctx, "road@acme.example.com", "10.0.0.1"); err == nil || !err.Locked {
+		t.Fatalf("account unlocked: blocked = %v, want the IP still locked", err)
+	}
+	if err := s.Unlock(ctx, KindIP, "10.0.0.1"); err != nil {
+		t.Fatalf("unlock IP: %v", err)
+	}
+	if err := blocked(t, s, ctx, "road@acme.example.com", "10.0.0.1"); err != nil {
+		t.Errorf("both unlocked: %v, want the login allowed", err)
+	}
+	if got := failures(t, s, ctx, KindAccount, "road@acme.example.com"); got != 0 {
+		t.Errorf("account failures after unlock = %d, want 0", got)
+	}
+
+	if err := s.Unlock(ctx, "email", "road@acme.example.com"); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("unlock unknown kind: err = %v, want ErrUnknownKind", err)
+	}
+}
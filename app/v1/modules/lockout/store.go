@@ -0,0 +1,24 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps login attempt counters. Implementations must make
+// RecordFailure atomic so concurrent failures are all counted.
+type Store interface {
+	// Get returns the counter, or a zero counter when there is none
+	Get(ctx context.Context, kind, value string) (LoginAttempt, error)
+	// RecordFailure adds a failure at now and returns the updated counter.
+	// The count restarts at 1 when the last failure is older than window.
+	RecordFailure(ctx context.Context, kind, value string, now time.Time, window time.Duration) (LoginAttempt, error)
+	// Block refuses attempts until the given time
+	Block(ctx context.Context, kind, value string, until time.Time, locked bool) error
+	// Reset forgets the counter
+	Reset(ctx context.Context, kind, value string) error
+	// List returns every counter, most recent failure first
+	List(ctx context.Context) ([]LoginAttempt, error)
+	// DeleteStale removes counters with nothing left to enforce
+	DeleteStale(ctx context.Context, now time.Time, window time.Duration) (int64, error)
+}
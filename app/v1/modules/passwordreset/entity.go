@@ -0,0 +1,20 @@
+package passwordreset
+
+import "time"
+
+// PasswordResetToken is an outstanding password reset. Only an HMAC of the
+// token is kept, keyed with the application secret. A user has at most one
+// outstanding token; it is deleted when used or replaced by a new request.
+type PasswordResetToken struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TenantID  uint      `gorm:"not null;index"`
+	UserID    uint      `gorm:"not null;uniqueIndex"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+// Expired reports whether the token can no longer be used
+func (t PasswordResetToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,10 @@
+package passwordreset
+
+import "errors"
+
+var (
+	// ErrInvalidToken is returned for unknown, used and expired reset tokens
+	ErrInvalidToken = errors.New("invalid or expired password reset token")
+	// ErrTokenNotFound is returned by the repository when no token matches
+	ErrTokenNotFound = errors.New("password reset token not found")
+)
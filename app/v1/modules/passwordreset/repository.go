@@ -0,0 +1,65 @@
+package passwordreset
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Replace(ctx context.Context, token PasswordResetToken) (PasswordResetToken, error)
+	FindByHash(ctx context.Context, hash string) (PasswordResetToken, error)
+	Consume(ctx context.Context, token PasswordResetToken) (bool, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+// WithTx returns a repository whose queries run in the transaction tx
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{tx}
+}
+
+// Replace stores a new token for the user, discarding any token they already
+// had, in one transaction
+func (r *repository) Replace(ctx context.Context, token PasswordResetToken) (PasswordResetToken, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&token).Error
+	})
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// FindByHash returns the token with the given hash
+func (r *repository) FindByHash(ctx context.Context, hash string) (PasswordResetToken, error) {
+	var token PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return token, ErrTokenNotFound
+	}
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// Consume deletes the token and reports whether this call removed it, so two
+// concurrent resets with the same token cannot both succeed
+func (r *repository) Consume(ctx context.Context, token PasswordResetToken) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ?", token.ID).Delete(&PasswordResetToken{})
+	return result.RowsAffected == 1, result.Error
+}
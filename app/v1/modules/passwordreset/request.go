@@ -0,0 +1,16 @@
+package passwordreset
+
+// ForgotPasswordForm represents the request data structure for asking for a
+// password reset link
+// @Description Password reset request form
+type ForgotPasswordForm struct {
+	Email string `json:"email" validate:"required,email,max=320" example:"john.doe@example.com"`
+}
+
+// ResetPasswordForm represents the request data structure for choosing a new
+// password with a reset token
+// @Description Password reset form
+type ResetPasswordForm struct {
+	Token    string `json:"token" validate:"required" example:"Q2hhbmdlIG1lIHRvIGEgcmVhbCB0b2tlbiBwbGVhc2U"`
+	Password string `json:"password" validate:"required,password" example:"N3wSecr3tPassword"`
+}
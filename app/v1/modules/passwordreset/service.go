@@ -0,0 +1,217 @@
+// Package passwordreset lets users who forgot their password choose a new one
+// through a single-use link mailed to them
+package passwordreset
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/mailer"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
+)
+
+const (
+	// tokenBytes is the amount of randomness in a reset token
+	tokenBytes = 32
+	// templateName is the email template used for reset messages
+	templateName = "password_reset"
+	// keyPurpose derives the token hashing key from the application secret
+	keyPurpose = "password-reset"
+)
+
+// Options configures reset tokens and the emailed link
+type Options struct {
+	SecretKey   string
+	TTL         time.Duration
+	ResetURL    string
+	ServiceName string
+}
+
+// emailData is passed to the password reset email templates
+type emailData struct {
+	Name        string
+	Email       string
+	ResetURL    string
+	ExpiresIn   string
+	ServiceName string
+}
+
+type Service interface {
+	RequestReset(ctx context.Context, input *ForgotPasswordForm) error
+	ResetPassword(ctx context.Context, input *ResetPasswordForm) error
+}
+
+// Accounts sets the passwords of users, recording the change in the audit log
+type Accounts interface {
+	SetPassword(ctx context.Context, id uint, password string, with func(tx *gorm.DB, account user.User) error) (user.User, error)
+}
+
+// Revoker ends the access a user has through one module, such as their
+// refresh tokens or sessions, in the transaction tx
+type Revoker interface {
+	RevokeUser(ctx context.Context, tx *gorm.DB, userID uint, at time.Time) error
+}
+
+type service struct {
+	repository Repository
+	users      user.Repository
+	accounts   Accounts
+	revokers   []Revoker
+	mailer     mailer.Mailer
+	templates  *mailer.Templates
+	opts       Options
+	key        []byte
+	now        func() time.Time
+	logger     *appLogger.LogrusLogger
+}
+
+func NewService(repository Repository, users user.Repository, accounts Accounts, revokers []Revoker, m mailer.Mailer, templates *mailer.Templates, opts Options) (*service, error) {
+	if opts.SecretKey == "" {
+		return nil, errors.New("secret key is required to key password reset tokens")
+	}
+
+	mac := hmac.New(sha256.New, []byte(opts.SecretKey))
+	mac.Write([]byte(keyPurpose))
+
+	return &service{
+		repository: repository,
+		users:      users,
+		accounts:   accounts,
+		revokers:   revokers,
+		mailer:     m,
+		templates:  templates,
+		opts:       opts,
+		key:        mac.Sum(nil),
+		now:        time.Now,
+		logger:     appLogger.SimpleLogger("passwordreset"),
+	}, nil
+}
+
+// RequestReset mails a reset link to the account with the given email. Unknown
+// emails are not an error, so callers cannot use this to find out which
+// emails are registered. A new request replaces any earlier link.
+func (s *service) RequestReset(ctx context.Context, input *ForgotPasswordForm) error {
+	account, err := s.users.FindByEmail(ctx, user.NormalizeEmail(input.Email))
+	if errors.Is(err, user.ErrUserNotFound) {
+		s.logger.Info("Password reset requested for unknown email")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repository.Replace(ctx, PasswordResetToken{
+		UserID:    account.ID,
+		TokenHash: s.hash(token),
+		ExpiresAt: s.now().Add(s.opts.TTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = s.sendLink(ctx, account, token); err != nil {
+		return err
+	}
+
+	s.logger.Info("Password reset requested", "user_id", account.ID)
+	return nil
+}
+
+// ResetPassword sets a new password for the owner of the token. Using up the
+// token, saving the password and revoking the user's refresh tokens and
+// sessions happen in one transaction, so either the old password and
+// everything started with it are gone or the link still works.
+func (s *service) ResetPassword(ctx context.Context, input *ResetPasswordForm) error {
+	stored, err := s.repository.FindByHash(ctx, s.hash(input.Token))
+	if errors.Is(err, ErrTokenNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	if stored.Expired(s.now()) {
+		return ErrInvalidToken
+	}
+
+	account, err := s.accounts.SetPassword(ctx, stored.UserID, input.Password, func(tx *gorm.DB, account user.User) error {
+		consumed, err := s.repository.WithTx(tx).Consume(ctx, stored)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return ErrInvalidToken
+		}
+		at := s.now()
+		for _, revoker := range s.revokers {
+			if err = revoker.RevokeUser(ctx, tx, account.ID, at); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, user.ErrUserNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Password reset completed, sessions revoked", "user_id", account.ID)
+	return nil
+}
+
+// sendLink mails the reset link carrying the plaintext token
+func (s *service) sendLink(ctx context.Context, account user.User, token string) error {
+	link, err := url.Parse(tenancy.ExpandURL(ctx, s.opts.ResetURL))
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	msg, err := s.templates.Render(templateName, emailData{
+		Name:        account.Name,
+		Email:       account.EmailAddress(),
+		ResetURL:    link.String(),
+		ExpiresIn:   mailer.FormatDuration(s.opts.TTL),
+		ServiceName: s.opts.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = account.EmailAddress()
+
+	return s.mailer.Send(msg)
+}
+
+// hash returns the HMAC stored in place of a token
+func (s *service) hash(token string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// randomToken returns a URL-safe random token
+func randomToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
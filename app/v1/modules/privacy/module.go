@@ -0,0 +1,18 @@
+// Package privacy answers data subject requests: a machine-readable export
+// of everything stored about a user, and erasure of it
+package privacy
+
+import "context"
+
+// Module is a part of the application that stores data about users. Every
+// module holding rows that refer to a user is registered with the service
+// under a name, which keys its data in exports and its count in erasure
+// reports.
+type Module interface {
+	// ExportUser returns what the module stores about the user, ready to
+	// be encoded as JSON. Secrets such as token hashes are left out.
+	ExportUser(ctx context.Context, userID uint) (interface{}, error)
+	// EraseUser removes or anonymizes what the module stores about the user
+	// and returns the number of rows affected. Erasing twice is harmless.
+	EraseUser(ctx context.Context, userID uint) (int64, error)
+}
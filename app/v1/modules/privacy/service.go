@@ -0,0 +1,131 @@
+package privacy
+
+import (
+	"context"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+)
+
+// userModule keys the user row in exports and erasure reports
+const userModule = "user"
+
+// Auditor records erasures in the audit log
+type Auditor interface {
+	Record(ctx context.Context, change audit.Change) error
+}
+
+// Export is everything stored about a user, by module
+type Export struct {
+	UserID     uint                   `json:"user_id" example:"1"`
+	ExportedAt time.Time              `json:"exported_at" example:"2025-06-15T19:22:47.091+07:00"`
+	Data       map[string]interface{} `json:"data" swaggertype:"object"`
+}
+
+// ErasureReport tells how many rows each module removed or anonymized
+type ErasureReport struct {
+	UserID   uint             `json:"user_id" example:"1"`
+	ErasedAt time.Time        `json:"erased_at" example:"2025-06-15T19:22:47.091+07:00"`
+	Rows     map[string]int64 `json:"rows" swaggertype:"object,integer" example:"user:1,sessions:2,audit:4"`
+}
+
+// userRecord is the user row in an export. The password hash is left out.
+type userRecord struct {
+	ID              uint       `json:"id"`
+	Name            string     `json:"name"`
+	Email           *string    `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at"`
+}
+
+type Service interface {
+	Export(ctx context.Context, userID uint) (Export, error)
+	Erase(ctx context.Context, userID uint) (ErasureReport, error)
+}
+
+type service struct {
+	users   user.Repository
+	auditor Auditor
+	names   []string
+	modules []Module
+	now     func() time.Time
+}
+
+func NewService(users user.Repository, auditor Auditor) *service {
+	return &service{users: users, auditor: auditor, now: time.Now}
+}
+
+// Register adds a module to every later export and erasure. Modules are
+// erased in the order they were registered, all before the user row.
+func (s *service) Register(name string, module Module) {
+	s.names = append(s.names, name)
+	s.modules = append(s.modules, module)
+}
+
+// Export collects the user row, soft deleted or not, and the data of every
+// registered module. It returns user.ErrUserNotFound if no row exists.
+func (s *service) Export(ctx context.Context, userID uint) (Export, error) {
+	export := Export{UserID: userID, ExportedAt: s.now(), Data: make(map[string]interface{})}
+
+	found, err := s.users.FindByIDUnscoped(ctx, userID)
+	if err != nil {
+		return export, err
+	}
+	record := userRecord{
+		ID:              found.ID,
+		Name:            found.Name,
+		Email:           found.Email,
+		EmailVerifiedAt: found.EmailVerifiedAt,
+		CreatedAt:       found.CreatedAt,
+		UpdatedAt:       found.UpdatedAt,
+	}
+	if found.DeletedAt.Valid {
+		record.DeletedAt = &found.DeletedAt.Time
+	}
+	export.Data[userModule] = record
+
+	for i, module := range s.modules {
+		data, err := module.ExportUser(ctx, userID)
+		if err != nil {
+			return export, err
+		}
+		export.Data[s.names[i]] = data
+	}
+
+	return export, nil
+}
+
+// Erase erases the data of every registered module, then anonymizes and soft
+// deletes the user row, which is kept so references to its ID stay valid.
+// The erasure is recorded in the audit log without any personal data. A
+// failed erasure can be run again to finish it.
+func (s *service) Erase(ctx context.Context, userID uint) (ErasureReport, error) {
+	report := ErasureReport{UserID: userID, ErasedAt: s.now(), Rows: make(map[string]int64)}
+
+	found, err := s.users.FindByIDUnscoped(ctx, userID)
+	if err != nil {
+		return report, err
+	}
+
+	for i, module := range s.modules {
+		rows, err := module.EraseUser(ctx, userID)
+		if err != nil {
+			return report, err
+		}
+		report.Rows[s.names[i]] = rows
+	}
+
+	if err = s.users.Anonymize(ctx, found, report.ErasedAt); err != nil {
+		return report, err
+	}
+	report.Rows[userModule] = 1
+
+	return report, s.auditor.Record(ctx, audit.Change{
+		Resource:   user.AuditResource,
+		ResourceID: userID,
+		Action:     audit.ActionErase,
+	})
+}
@@ -0,0 +1,41 @@
+package privacy
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// table is a Module for a model whose rows belong to one user through a
+// user_id column and mean nothing without them
+type table struct {
+	db    *gorm.DB
+	model interface{}
+	omit  []string
+}
+
+// NewTable returns a Module that exports the rows of model belonging to the
+// user, without the omitted columns, and erases them by deleting them
+func NewTable(db *gorm.DB, model interface{}, omit ...string) *table {
+	return &table{db, model, omit}
+}
+
+func (t *table) ExportUser(ctx context.Context, userID uint) (interface{}, error) {
+	rows := []map[string]interface{}{}
+	err := t.db.WithContext(ctx).Model(t.model).Where("user_id = ?", userID).Order("created_at").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		for _, column := range t.omit {
+			delete(row, column)
+		}
+	}
+	return rows, nil
+}
+
+func (t *table) EraseUser(ctx context.Context, userID uint) (int64, error) {
+	result := t.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID).Delete(t.model)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,46 @@
+package rbac
+
+import (
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+)
+
+// Permission is a named action such as "users:delete"
+type Permission struct {
+	ID          uint   `gorm:"primarykey"`
+	Name        string `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string `gorm:"type:varchar(250)"`
+}
+
+// Role groups permissions so they can be granted to users together. Roles
+// are not tenant scoped, so every tenant sees the same roles and only the
+// admin token may create them.
+type Role struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string       `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string       `gorm:"type:varchar(250)"`
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE"`
+}
+
+// PermissionNames returns the names of the role's permissions
+func (r Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// UserRole assigns a role to a user. Rows go away with the user or role.
+// Roles are shared by every tenant; the assignment belongs to the user's.
+type UserRole struct {
+	UserID    uint `gorm:"primaryKey"`
+	RoleID    uint `gorm:"primaryKey;index"`
+	TenantID  uint `gorm:"not null;index"`
+	CreatedAt time.Time
+	User      user.User `gorm:"constraint:OnDelete:CASCADE"`
+	Role      Role      `gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,13 @@
+package rbac
+
+import "errors"
+
+var (
+	// ErrRoleNotFound is returned when no role has the given name
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists is returned when creating a role whose name is taken
+	ErrRoleExists = errors.New("role already exists")
+	// ErrUnknownPermission is returned when a role refers to a permission that
+	// does not exist
+	ErrUnknownPermission = errors.New("unknown permission")
+)
@@ -0,0 +1,47 @@
+package rbac
+
+// Permissions checked by the API
+const (
+	PermUsersRead      = "users:read"
+	PermUsersWrite     = "users:write"
+	PermUsersDelete    = "users:delete"
+	PermUsersRestore   = "users:restore"
+	PermUsersPurge     = "users:purge"
+	PermUsersImport    = "users:import"
+	PermUsersExport    = "users:export"
+	PermRolesManage    = "roles:manage"
+	PermSecurityManage = "security:manage"
+	PermAuditRead      = "audit:read"
+	PermPrivacyManage  = "privacy:manage"
+)
+
+// Built-in roles created at bootstrap
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+)
+
+// DefaultPermissions describes every permission known to the API
+var DefaultPermissions = []Permission{
+	{Name: PermUsersRead, Description: "View users"},
+	{Name: PermUsersWrite, Description: "Update users"},
+	{Name: PermUsersDelete, Description: "Soft delete users"},
+	{Name: PermUsersRestore, Description: "View and restore soft deleted users"},
+	{Name: PermUsersPurge, Description: "Permanently remove users"},
+	{Name: PermUsersImport, Description: "Bulk import users"},
+	{Name: PermUsersExport, Description: "Bulk export users"},
+	{Name: PermRolesManage, Description: "Manage roles and role assignments"},
+	{Name: PermSecurityManage, Description: "Manage login lockouts and user sessions"},
+	{Name: PermAuditRead, Description: "View the audit log"},
+	{Name: PermPrivacyManage, Description: "Export and erase the personal data of users on their behalf"},
+}
+
+// defaultRoles maps the built-in roles to their permissions
+var defaultRoles = []struct {
+	name        string
+	description string
+	permissions []string
+}{
+	{RoleAdmin, "Full access", nil},
+	{RoleViewer, "Read-only access to users", []string{PermUsersRead, PermUsersExport}},
+}
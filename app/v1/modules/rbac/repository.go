@@ -0,0 +1,137 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	ListRoles(ctx context.Context) ([]Role, error)
+	FindRoleByName(ctx context.Context, name string) (Role, error)
+	SaveRole(ctx context.Context, role Role) (Role, error)
+	FindPermissions(ctx context.Context, names []string) ([]Permission, error)
+	EnsurePermission(ctx context.Context, permission Permission) (Permission, error)
+	EnsureRole(ctx context.Context, role Role) (Role, error)
+	UserRoles(ctx context.Context, userID uint) ([]Role, error)
+	AssignRole(ctx context.Context, userID uint, roleID uint) error
+	UnassignRole(ctx context.Context, userID uint, roleID uint) error
+	UserPermissions(ctx context.Context, userID uint) ([]string, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+// ListRoles returns every role with its permissions, ordered by name
+func (r *repository) ListRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *repository) FindRoleByName(ctx context.Context, name string) (Role, error) {
+	var role Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return role, ErrRoleNotFound
+	}
+	if err != nil {
+		return role, err
+	}
+
+	return role, nil
+}
+
+// SaveRole creates a role together with its permission links
+func (r *repository) SaveRole(ctx context.Context, role Role) (Role, error) {
+	err := r.db.WithContext(ctx).Omit("Permissions.*").Create(&role).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return role, ErrRoleExists
+	}
+	if err != nil {
+		return role, err
+	}
+
+	return role, nil
+}
+
+// FindPermissions returns the permissions with the given names. Unknown names
+// are left out.
+func (r *repository) FindPermissions(ctx context.Context, names []string) ([]Permission, error) {
+	var permissions []Permission
+	err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&permissions).Error
+	return permissions, err
+}
+
+// EnsurePermission creates the permission unless one with its name exists
+func (r *repository) EnsurePermission(ctx context.Context, permission Permission) (Permission, error) {
+	err := r.db.WithContext(ctx).Where(Permission{Name: permission.Name}).
+		Attrs(Permission{Description: permission.Description}).
+		FirstOrCreate(&permission).Error
+	return permission, err
+}
+
+// EnsureRole creates the role unless one with its name exists. Permissions of
+// an existing role are topped up, never removed.
+func (r *repository) EnsureRole(ctx context.Context, role Role) (Role, error) {
+	permissions := role.Permissions
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(Role{Name: role.Name}).
+			Attrs(Role{Description: role.Description}).
+			Omit("Permissions").
+			FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+		return tx.Model(&role).Omit("Permissions.*").Association("Permissions").Append(permissions)
+	})
+	return role, err
+}
+
+// UserRoles returns the roles assigned to a user
+func (r *repository) UserRoles(ctx context.Context, userID uint) ([]Role, error) {
+	var roles []Role
+	err := r.db.WithContext(ctx).Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Scopes(tenancy.Joined("user_roles")).
+		Order("roles.name").
+		Find(&roles).Error
+	return roles, err
+}
+
+// AssignRole grants a role to a user. Assigning it twice is a no-op.
+func (r *repository) AssignRole(ctx context.Context, userID uint, roleID uint) error {
+	return r.db.WithContext(ctx).Omit("User", "Role").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&UserRole{UserID: userID, RoleID: roleID}).Error
+}
+
+// UnassignRole takes a role away from a user
+func (r *repository) UnassignRole(ctx context.Context, userID uint, roleID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&UserRole{}).Error
+}
+
+// UserPermissions returns the distinct permission names granted to a user
+// through any of their roles
+func (r *repository) UserPermissions(ctx context.Context, userID uint) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Scopes(tenancy.Joined("user_roles")).
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
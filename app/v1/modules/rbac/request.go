@@ -0,0 +1,9 @@
+package rbac
+
+// CreateRoleForm represents the request body for creating a role
+// @Description Create role request form
+type CreateRoleForm struct {
+	Name        string   `form:"name" json:"name" validate:"required,max=100" example:"support"`
+	Description string   `form:"description" json:"description" validate:"max=250" example:"Customer support staff"`
+	Permissions []string `form:"permissions" json:"permissions" validate:"required,min=1,dive,required" example:"users:read,users:write"`
+}
@@ -0,0 +1,142 @@
+// Package rbac implements role based access control: roles, the permissions
+// they grant and their assignment to users
+package rbac
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+)
+
+type Service interface {
+	ListRoles(ctx context.Context) ([]Role, error)
+	CreateRole(ctx context.Context, input *CreateRoleForm) (Role, error)
+	UserRoles(ctx context.Context, userID uint) ([]Role, error)
+	AssignRole(ctx context.Context, userID uint, roleName string) ([]Role, error)
+	UnassignRole(ctx context.Context, userID uint, roleName string) ([]Role, error)
+	UserPermissions(ctx context.Context, userID uint) ([]string, error)
+	EnsureDefaults(ctx context.Context) error
+}
+
+type service struct {
+	repository Repository
+	users      user.Repository
+}
+
+func NewService(repository Repository, users user.Repository) *service {
+	return &service{repository, users}
+}
+
+func (s *service) ListRoles(ctx context.Context) ([]Role, error) {
+	return s.repository.ListRoles(ctx)
+}
+
+// CreateRole creates a role granting the named permissions, which must all exist
+func (s *service) CreateRole(ctx context.Context, input *CreateRoleForm) (Role, error) {
+	permissions, err := s.repository.FindPermissions(ctx, input.Permissions)
+	if err != nil {
+		return Role{}, err
+	}
+	if missing := missingPermissions(input.Permissions, permissions); len(missing) > 0 {
+		return Role{}, fmt.Errorf("%w: %v", ErrUnknownPermission, missing)
+	}
+
+	return s.repository.SaveRole(ctx, Role{
+		Name:        input.Name,
+		Description: input.Description,
+		Permissions: permissions,
+	})
+}
+
+// UserRoles returns the roles of an existing user
+func (s *service) UserRoles(ctx context.Context, userID uint) ([]Role, error) {
+	if _, err := s.users.FindByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repository.UserRoles(ctx, userID)
+}
+
+// AssignRole grants a role to a user and returns the user's roles afterwards
+func (s *service) AssignRole(ctx context.Context, userID uint, roleName string) ([]Role, error) {
+	role, err := s.userAndRole(ctx, userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.AssignRole(ctx, userID, role.ID); err != nil {
+		return nil, err
+	}
+	return s.repository.UserRoles(ctx, userID)
+}
+
+// UnassignRole takes a role away from a user and returns the user's roles afterwards
+func (s *service) UnassignRole(ctx context.Context, userID uint, roleName string) ([]Role, error) {
+	role, err := s.userAndRole(ctx, userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.UnassignRole(ctx, userID, role.ID); err != nil {
+		return nil, err
+	}
+	return s.repository.UserRoles(ctx, userID)
+}
+
+// UserPermissions returns every permission granted to a user through their roles
+func (s *service) UserPermissions(ctx context.Context, userID uint) ([]string, error) {
+	return s.repository.UserPermissions(ctx, userID)
+}
+
+// EnsureDefaults creates the known permissions and built-in roles. It is safe
+// to run on every start.
+func (s *service) EnsureDefaults(ctx context.Context) error {
+	all := make([]Permission, 0, len(DefaultPermissions))
+	byName := make(map[string]Permission, len(DefaultPermissions))
+	for _, p := range DefaultPermissions {
+		saved, err := s.repository.EnsurePermission(ctx, p)
+		if err != nil {
+			return err
+		}
+		all = append(all, saved)
+		byName[saved.Name] = saved
+	}
+
+	for _, r := range defaultRoles {
+		role := Role{Name: r.name, Description: r.description, Permissions: all}
+		if r.permissions != nil {
+			role.Permissions = make([]Permission, 0, len(r.permissions))
+			for _, name := range r.permissions {
+				role.Permissions = append(role.Permissions, byName[name])
+			}
+		}
+		if _, err := s.repository.EnsureRole(ctx, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userAndRole checks the user exists and looks up the role by name
+func (s *service) userAndRole(ctx context.Context, userID uint, roleName string) (Role, error) {
+	if _, err := s.users.FindByID(ctx, userID); err != nil {
+		return Role{}, err
+	}
+	return s.repository.FindRoleByName(ctx, roleName)
+}
+
+// missingPermissions returns the requested names that were not found
+func missingPermissions(names []string, found []Permission) []string {
+	known := make(map[string]bool, len(found))
+	for _, p := range found {
+		known[p.Name] = true
+	}
+
+	var missing []string
+	for _, name := range names {
+		if !known[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,33 @@
+package refreshtoken
+
+import (
+	"context"
+	"time"
+
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// RunCleanup deletes expired refresh tokens of every tenant each interval
+// until the context is cancelled. It is meant to run in its own goroutine.
+func RunCleanup(ctx context.Context, service Service, interval time.Duration) {
+	logger := appLogger.SimpleLogger("refreshtoken-cleanup")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := service.DeleteExpired(tenancy.AllTenants(ctx))
+			if err != nil {
+				logger.Error("Failed to delete expired refresh tokens", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				logger.Info("Deleted expired refresh tokens", "count", deleted)
+			}
+		}
+	}
+}
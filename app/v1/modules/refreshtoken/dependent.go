@@ -0,0 +1,28 @@
+package refreshtoken
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserDependent keeps a user's refresh tokens in step with the user: they
+// are revoked when the user is soft deleted and deleted when it is purged
+type UserDependent struct{}
+
+func (UserDependent) RevokeUser(ctx context.Context, tx *gorm.DB, userID uint, at time.Time) error {
+	return NewRepository(tx).RevokeUser(ctx, userID, RevokedUserDeleted, at)
+}
+
+func (UserDependent) DeleteUser(ctx context.Context, tx *gorm.DB, userID uint) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&RefreshToken{}).Error
+}
+
+// PasswordResetRevoker revokes a user's refresh tokens in the transaction
+// that resets their password, so sessions started with the old password end
+type PasswordResetRevoker struct{}
+
+func (PasswordResetRevoker) RevokeUser(ctx context.Context, tx *gorm.DB, userID uint, at time.Time) error {
+	return NewRepository(tx).RevokeUser(ctx, userID, RevokedPasswordReset, at)
+}
@@ -0,0 +1,34 @@
+package refreshtoken
+
+import "time"
+
+// Reasons a refresh token was revoked
+const (
+	RevokedRotated       = "rotated"
+	RevokedLogout        = "logout"
+	RevokedReuse         = "reuse"
+	RevokedPasswordReset = "password_reset"
+	RevokedUserDeleted   = "user_deleted"
+)
+
+// RefreshToken is a stored refresh token. Only the SHA-256 hash of the token is
+// kept. Tokens rotated from the same login share a FamilyID so that reuse of an
+// old token can revoke the whole chain.
+type RefreshToken struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TenantID  uint      `gorm:"not null;index"`
+	UserID    uint      `gorm:"not null;index"`
+	FamilyID  string    `gorm:"type:varchar(32);not null;index"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	RevokedAt *time.Time
+	// RevokedReason tells rotated tokens, whose reuse signals theft, apart
+	// from tokens ended by logout
+	RevokedReason string `gorm:"type:varchar(16)"`
+}
+
+// Revoked reports whether the token has been rotated or revoked
+func (t RefreshToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
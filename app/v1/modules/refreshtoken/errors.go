@@ -0,0 +1,13 @@
+package refreshtoken
+
+import "errors"
+
+var (
+	// ErrInvalidToken is returned for unknown or expired refresh tokens
+	ErrInvalidToken = errors.New("invalid or expired refresh token")
+	// ErrTokenReused is returned when an already rotated or revoked refresh
+	// token is presented again. Its whole family is revoked when this happens.
+	ErrTokenReused = errors.New("refresh token has already been used")
+	// ErrTokenNotFound is returned by the repository when no token matches
+	ErrTokenNotFound = errors.New("refresh token not found")
+)
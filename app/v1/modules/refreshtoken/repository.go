@@ -0,0 +1,95 @@
+package refreshtoken
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Save(ctx context.Context, token RefreshToken) (RefreshToken, error)
+	FindByHash(ctx context.Context, hash string) (RefreshToken, error)
+	Rotate(ctx context.Context, current RefreshToken, next RefreshToken, at time.Time) (RefreshToken, error)
+	RevokeFamily(ctx context.Context, familyID string, reason string, at time.Time) error
+	RevokeUser(ctx context.Context, userID uint, reason string, at time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Save(ctx context.Context, token RefreshToken) (RefreshToken, error) {
+	err := r.db.WithContext(ctx).Create(&token).Error
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// FindByHash returns the token with the given hash, revoked or not
+func (r *repository) FindByHash(ctx context.Context, hash string) (RefreshToken, error) {
+	var token RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return token, ErrTokenNotFound
+	}
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// Rotate revokes the current token and stores its replacement in one
+// transaction. The revoke only succeeds while the current token is still
+// active, so two concurrent rotations of the same token cannot both win; the
+// loser gets ErrTokenReused.
+func (r *repository) Rotate(ctx context.Context, current RefreshToken, next RefreshToken, at time.Time) (RefreshToken, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": RevokedRotated})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenReused
+		}
+		return tx.Create(&next).Error
+	})
+	if err != nil {
+		return next, err
+	}
+
+	return next, nil
+}
+
+// RevokeFamily revokes every active token rotated from the same login
+func (r *repository) RevokeFamily(ctx context.Context, familyID string, reason string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason}).Error
+}
+
+// RevokeUser revokes every active token of a user
+func (r *repository) RevokeUser(ctx context.Context, userID uint, reason string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": reason}).Error
+}
+
+// DeleteExpired deletes tokens that expired before the given time and returns
+// how many were removed. Revoked tokens are kept until they expire so reuse
+// can still be detected.
+func (r *repository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&RefreshToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,186 @@
+// Package refreshtoken stores long-lived refresh tokens, rotates them on use
+// and detects reuse of rotated tokens
+package refreshtoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+)
+
+// tokenBytes is the amount of randomness in a refresh token
+const tokenBytes = 32
+
+// Issued is a freshly issued refresh token. Token is the only copy of the
+// plaintext value; it is not stored.
+type Issued struct {
+	Token     string
+	UserID    uint
+	TenantID  uint
+	ExpiresAt time.Time
+}
+
+type Service interface {
+	Issue(ctx context.Context, userID uint) (Issued, error)
+	Rotate(ctx context.Context, token string) (Issued, error)
+	Revoke(ctx context.Context, token string) error
+	RevokeAll(ctx context.Context, userID uint, reason string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type service struct {
+	repository Repository
+	ttl        time.Duration
+	now        func() time.Time
+	logger     *appLogger.LogrusLogger
+}
+
+func NewService(repository Repository, ttl time.Duration) *service {
+	return &service{
+		repository: repository,
+		ttl:        ttl,
+		now:        time.Now,
+		logger:     appLogger.SimpleLogger("refreshtoken"),
+	}
+}
+
+// Issue creates a refresh token starting a new token family
+func (s *service) Issue(ctx context.Context, userID uint) (Issued, error) {
+	familyID, err := randomHex(16)
+	if err != nil {
+		return Issued{}, err
+	}
+
+	token, record, err := s.newToken(userID, familyID)
+	if err != nil {
+		return Issued{}, err
+	}
+
+	if record, err = s.repository.Save(ctx, record); err != nil {
+		return Issued{}, err
+	}
+
+	return Issued{Token: token, UserID: userID, TenantID: record.TenantID, ExpiresAt: record.ExpiresAt}, nil
+}
+
+// Rotate exchanges a refresh token for a new one in the same family. Presenting
+// a token that was already rotated revokes the whole family and returns
+// ErrTokenReused; tokens revoked by logout are simply invalid.
+func (s *service) Rotate(ctx context.Context, token string) (Issued, error) {
+	current, err := s.find(ctx, token)
+	if err != nil {
+		return Issued{}, err
+	}
+
+	now := s.now()
+	if current.Revoked() {
+		if current.RevokedReason == RevokedRotated {
+			return Issued{}, s.reused(ctx, current, now)
+		}
+		return Issued{}, ErrInvalidToken
+	}
+	if !now.Before(current.ExpiresAt) {
+		return Issued{}, ErrInvalidToken
+	}
+
+	next, record, err := s.newToken(current.UserID, current.FamilyID)
+	if err != nil {
+		return Issued{}, err
+	}
+
+	_, err = s.repository.Rotate(ctx, current, record, now)
+	if errors.Is(err, ErrTokenReused) {
+		return Issued{}, s.reused(ctx, current, now)
+	}
+	if err != nil {
+		return Issued{}, err
+	}
+
+	return Issued{Token: next, UserID: current.UserID, TenantID: current.TenantID, ExpiresAt: record.ExpiresAt}, nil
+}
+
+// Revoke revokes the token family the given token belongs to, ending that
+// login session
+func (s *service) Revoke(ctx context.Context, token string) error {
+	current, err := s.find(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return s.repository.RevokeFamily(ctx, current.FamilyID, RevokedLogout, s.now())
+}
+
+// RevokeAll revokes every refresh token of a user, ending all their sessions.
+// The reason is one of the Revoked constants.
+func (s *service) RevokeAll(ctx context.Context, userID uint, reason string) error {
+	return s.repository.RevokeUser(ctx, userID, reason, s.now())
+}
+
+// DeleteExpired removes expired tokens from storage
+func (s *service) DeleteExpired(ctx context.Context) (int64, error) {
+	return s.repository.DeleteExpired(ctx, s.now())
+}
+
+// find looks a token up by its hash
+func (s *service) find(ctx context.Context, token string) (RefreshToken, error) {
+	if token == "" {
+		return RefreshToken{}, ErrInvalidToken
+	}
+
+	current, err := s.repository.FindByHash(ctx, hashToken(token))
+	if errors.Is(err, ErrTokenNotFound) {
+		return current, ErrInvalidToken
+	}
+
+	return current, err
+}
+
+// reused revokes the family of a token that was presented after being
+// rotated, since either the client or an attacker holds a stolen copy
+func (s *service) reused(ctx context.Context, token RefreshToken, now time.Time) error {
+	s.logger.Warn("Refresh token reuse detected, revoking token family",
+		"user_id", token.UserID, "family_id", token.FamilyID)
+
+	if err := s.repository.RevokeFamily(ctx, token.FamilyID, RevokedReuse, now); err != nil {
+		return err
+	}
+	return ErrTokenReused
+}
+
+// newToken generates a random token and the record storing its hash
+func (s *service) newToken(userID uint, familyID string) (string, RefreshToken, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", RefreshToken{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	return token, RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.now().Add(s.ttl),
+	}, nil
+}
+
+// hashToken returns the hex SHA-256 of a token. Refresh tokens are random, so
+// a fast unsalted hash is enough to make a leaked table useless.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,198 @@
+package refreshtoken
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// acme is the tenant the tokens of the tests belong to
+var acme = tenancy.Tenant{ID: 1, Slug: "acme"}
+
+// newTestService returns a service issuing tokens valid for an hour from an
+// in-memory database, on a clock the test moves with the returned pointer
+func newTestService(t *testing.T) (*service, *time.Time) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err = db.Use(tenancy.Plugin{}); err != nil {
+		t.Fatalf("register tenancy plugin: %v", err)
+	}
+	if err = db.AutoMigrate(&RefreshToken{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewService(NewRepository(db), time.Hour)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+// stored returns the record of a plaintext token of acme
+func stored(t *testing.T, s *service, token string) RefreshToken {
+	t.Helper()
+	record, err := s.repository.FindByHash(tenancy.WithTenant(context.Background(), acme), hashToken(token))
+	if err != nil {
+		t.Fatalf("find token: %v", err)
+	}
+	return record
+}
+
+func TestRotateReplacesTheToken(t *testing.T) {
+	s, now := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	first, err := s.Issue(ctx, 7)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	*now = now.Add(30 * time.Minute)
+	second, err := s.Rotate(ctx, first.Token)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if second.Token == first.Token || second.UserID != 7 || second.TenantID != acme.ID {
+		t.Fatalf("rotated = %+v, want a new token of user 7 in acme", second)
+	}
+	if !second.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("rotated token expires at %v, want an hour after rotation", second.ExpiresAt)
+	}
+
+	old, next := stored(t, s, first.Token), stored(t, s, second.Token)
+	if old.RevokedReason != RevokedRotated || next.Revoked() || next.FamilyID != old.FamilyID {
+		t.Errorf("old token revoked for %q, new token revoked %v in family %s of %s; want the old one rotated into the same family",
+			old.RevokedReason, next.Revoked(), next.FamilyID, old.FamilyID)
+	}
+
+	*now = now.Add(time.Hour)
+	if _, err = s.Rotate(ctx, second.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("rotate expired token: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestReusedTokenRevokesItsFamily(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	first, err := s.Issue(ctx, 7)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	other, err := s.Issue(ctx, 7)
+	if err != nil {
+		t.Fatalf("issue other login: %v", err)
+	}
+	second, err := s.Rotate(ctx, first.Token)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	if _, err = s.Rotate(ctx, first.Token); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("replay rotated token: err = %v, want ErrTokenReused", err)
+	}
+	if record := stored(t, s, second.Token); record.RevokedReason != RevokedReuse {
+		t.Errorf("latest token of the family revoked for %q, want %q", record.RevokedReason, RevokedReuse)
+	}
+	if _, err = s.Rotate(ctx, second.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("rotate revoked token: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err = s.Rotate(ctx, other.Token); err != nil {
+		t.Errorf("rotate token of another login: %v", err)
+	}
+}
+
+func TestConcurrentRotationHasOneWinner(t *testing.T) {
+	s, now := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	issued, err := s.Issue(ctx, 7)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	current := stored(t, s, issued.Token)
+	_, first, _ := s.newToken(7, current.FamilyID)
+	_, second, _ := s.newToken(7, current.FamilyID)
+
+	if _, err = s.repository.Rotate(ctx, current, first, *now); err != nil {
+		t.Fatalf("first rotation: %v", err)
+	}
+	if _, err = s.repository.Rotate(ctx, current, second, *now); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("second rotation: err = %v, want ErrTokenReused", err)
+	}
+	if _, err = s.repository.FindByHash(ctx, second.TokenHash); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("losing replacement: err = %v, want it not stored", err)
+	}
+}
+
+func TestLogoutEndsOneLogin(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	first, err := s.Issue(ctx, 7)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	other, err := s.Issue(ctx, 7)
+	if err != nil {
+		t.Fatalf("issue other login: %v", err)
+	}
+	second, err := s.Rotate(ctx, first.Token)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	if err = s.Revoke(ctx, second.Token); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if record := stored(t, s, second.Token); record.RevokedReason != RevokedLogout {
+		t.Errorf("token revoked for %q, want %q", record.RevokedReason, RevokedLogout)
+	}
+	// A token ended by logout is invalid, not a sign of theft
+	if _, err = s.Rotate(ctx, second.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("rotate after logout: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err = s.Rotate(ctx, other.Token); err != nil {
+		t.Errorf("rotate token of another login: %v", err)
+	}
+	if err = s.Revoke(ctx, "unknown"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("logout with unknown token: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestLogoutAllEndsEveryLogin(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := tenancy.WithTenant(context.Background(), acme)
+
+	var tokens []string
+	for _, userID := range []uint{7, 7, 8} {
+		issued, err := s.Issue(ctx, userID)
+		if err != nil {
+			t.Fatalf("issue: %v", err)
+		}
+		tokens = append(tokens, issued.Token)
+	}
+
+	if err := s.RevokeAll(ctx, 7, RevokedLogout); err != nil {
+		t.Fatalf("logout everywhere: %v", err)
+	}
+	for _, token := range tokens[:2] {
+		if _, err := s.Rotate(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("rotate after logout everywhere: err = %v, want ErrInvalidToken", err)
+		}
+	}
+	if _, err := s.Rotate(ctx, tokens[2]); err != nil {
+		t.Errorf("rotate token of another user: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// databaseStore keeps sessions in the sessions table so every instance of the
+// API sees the same sessions
+type databaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *databaseStore {
+	return &databaseStore{db}
+}
+
+func (d *databaseStore) Create(ctx context.Context, s *Session) error {
+	return d.db.WithContext(ctx).Create(s).Error
+}
+
+func (d *databaseStore) FindByHash(ctx context.Context, hash string) (Session, error) {
+	var s Session
+	err := d.db.WithContext(ctx).Where("token_hash = ?", hash).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s, ErrSessionNotFound
+	}
+	return s, err
+}
+
+func (d *databaseStore) Touch(ctx context.Context, id uint, lastSeenAt, expiresAt time.Time) error {
+	return d.db.WithContext(ctx).Model(&Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": lastSeenAt, "expires_at": expiresAt}).Error
+}
+
+func (d *databaseStore) Delete(ctx context.Context, id uint) error {
+	return d.db.WithContext(ctx).Delete(&Session{}, id).Error
+}
+
+func (d *databaseStore) DeleteForUser(ctx context.Context, userID, id uint) error {
+	result := d.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&Session{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (d *databaseStore) DeleteAllForUser(ctx context.Context, userID uint) (int64, error) {
+	result := d.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&Session{})
+	return result.RowsAffected, result.Error
+}
+
+func (d *databaseStore) ListByUser(ctx context.Context, userID uint, now time.Time) ([]Session, error) {
+	var sessions []Session
+	err := d.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (d *databaseStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := d.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&Session{})
+	return result.RowsAffected, result.Error
+}
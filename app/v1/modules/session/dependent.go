@@ -0,0 +1,32 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserDependent ends a user's sessions when the user is soft deleted or
+// purged. Sessions in the database are deleted in the user's transaction;
+// a memory store cannot join it and deletes them at once.
+type UserDependent struct {
+	store Store
+}
+
+func NewUserDependent(store Store) *UserDependent {
+	return &UserDependent{store}
+}
+
+func (d *UserDependent) RevokeUser(ctx context.Context, tx *gorm.DB, userID uint, _ time.Time) error {
+	return d.DeleteUser(ctx, tx, userID)
+}
+
+func (d *UserDependent) DeleteUser(ctx context.Context, tx *gorm.DB, userID uint) error {
+	store := d.store
+	if _, ok := store.(*databaseStore); ok {
+		store = NewDatabaseStore(tx)
+	}
+	_, err := store.DeleteAllForUser(ctx, userID)
+	return err
+}
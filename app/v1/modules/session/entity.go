@@ -0,0 +1,28 @@
+package session
+
+import "time"
+
+// Session is a server-side login session identified by an opaque cookie. Only
+// the SHA-256 hash of the cookie value is stored.
+type Session struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TenantID  uint   `gorm:"not null;index"`
+	UserID    uint   `gorm:"not null;index"`
+	TokenHash string `gorm:"type:char(64);not null;uniqueIndex"`
+	// CSRFToken must accompany state-changing requests made with the session
+	// cookie. It is not a credential on its own, so it is kept in plain text
+	// to be rendered into pages.
+	CSRFToken  string    `gorm:"type:varchar(64);not null"`
+	IPAddress  string    `gorm:"type:varchar(45)"`
+	UserAgent  string    `gorm:"type:varchar(255)"`
+	LastSeenAt time.Time `gorm:"not null"`
+	// ExpiresAt slides forward on use but never past the session's maximum
+	// lifetime
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// Expired reports whether the session has ended at now
+func (s Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,11 @@
+package session
+
+import "errors"
+
+var (
+	// ErrInvalidSession is returned for unknown, expired or revoked session
+	// cookies
+	ErrInvalidSession = errors.New("invalid or expired session")
+	// ErrSessionNotFound is returned when no session matches
+	ErrSessionNotFound = errors.New("session not found")
+)
@@ -0,0 +1,139 @@
+package session
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// memoryStore keeps sessions in process memory. Sessions are lost on restart
+// and not shared between instances. Sessions are kept apart by tenant the
+// same way the database store's queries are scoped.
+type memoryStore struct {
+	mu       sync.Mutex
+	nextID   uint
+	sessions map[uint]Session
+	// byHash indexes session IDs by token hash
+	byHash map[string]uint
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{sessions: make(map[uint]Session), byHash: make(map[string]uint)}
+}
+
+func (m *memoryStore) Create(ctx context.Context, s *Session) error {
+	t, err := tenancy.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.TenantID = t.ID
+	m.nextID++
+	s.ID = m.nextID
+	m.sessions[s.ID] = *s
+	m.byHash[s.TokenHash] = s.ID
+	return nil
+}
+
+func (m *memoryStore) FindByHash(ctx context.Context, hash string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[m.byHash[hash]]
+	if !ok || !tenancy.Matches(ctx, s.TenantID) {
+		return Session{}, ErrSessionNotFound
+	}
+	return s, nil
+}
+
+func (m *memoryStore) Touch(ctx context.Context, id uint, lastSeenAt, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || !tenancy.Matches(ctx, s.TenantID) {
+		return nil
+	}
+	s.LastSeenAt = lastSeenAt
+	s.ExpiresAt = expiresAt
+	m.sessions[id] = s
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[id]; ok && tenancy.Matches(ctx, s.TenantID) {
+		m.remove(id)
+	}
+	return nil
+}
+
+func (m *memoryStore) DeleteForUser(ctx context.Context, userID, id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.UserID != userID || !tenancy.Matches(ctx, s.TenantID) {
+		return ErrSessionNotFound
+	}
+	m.remove(id)
+	return nil
+}
+
+func (m *memoryStore) DeleteAllForUser(ctx context.Context, userID uint) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, s := range m.sessions {
+		if s.UserID == userID && tenancy.Matches(ctx, s.TenantID) {
+			m.remove(id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memoryStore) ListByUser(ctx context.Context, userID uint, now time.Time) ([]Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessions := make([]Session, 0)
+	for _, s := range m.sessions {
+		if s.UserID == userID && !s.Expired(now) && tenancy.Matches(ctx, s.TenantID) {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (m *memoryStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, s := range m.sessions {
+		if s.Expired(now) && tenancy.Matches(ctx, s.TenantID) {
+			m.remove(id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// remove deletes a session and its hash index entry. The caller holds the lock.
+func (m *memoryStore) remove(id uint) {
+	delete(m.byHash, m.sessions[id].TokenHash)
+	delete(m.sessions, id)
+}
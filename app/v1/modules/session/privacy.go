@@ -0,0 +1,54 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// PersonalData exports and erases a user's sessions for data subject
+// requests. It works on the store so sessions kept in memory are covered too.
+type PersonalData struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewPersonalData(store Store) *PersonalData {
+	return &PersonalData{store: store, now: time.Now}
+}
+
+// ExportedSession is a session in a data subject export. The token hash and
+// CSRF token are left out.
+type ExportedSession struct {
+	ID         uint      `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// ExportUser returns the user's active sessions
+func (p *PersonalData) ExportUser(ctx context.Context, userID uint) (interface{}, error) {
+	sessions, err := p.store.ListByUser(ctx, userID, p.now())
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]ExportedSession, 0, len(sessions))
+	for _, s := range sessions {
+		exported = append(exported, ExportedSession{
+			ID:         s.ID,
+			IPAddress:  s.IPAddress,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+		})
+	}
+	return exported, nil
+}
+
+// EraseUser deletes every session of the user, ending them
+func (p *PersonalData) EraseUser(ctx context.Context, userID uint) (int64, error) {
+	return p.store.DeleteAllForUser(ctx, userID)
+}
@@ -0,0 +1,257 @@
+// Package session keeps server-side login sessions for browsers. The client
+// only holds an opaque random ID in a cookie; the session itself lives in a
+// store and ends after a period of inactivity or a maximum lifetime.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+const (
+	// tokenBytes is the amount of randomness in a session ID and CSRF token
+	tokenBytes = 32
+	// touchInterval limits how often use of a session is written to the
+	// store, lowered to a tenth of the idle timeout for short timeouts.
+	// Expiry may lag behind actual use by up to this much.
+	touchInterval = time.Minute
+	// maxUserAgent is the stored length of the client's User-Agent
+	maxUserAgent = 255
+)
+
+// Options configures session expiry
+type Options struct {
+	// IdleTimeout ends a session that has not been used for this long
+	IdleTimeout time.Duration
+	// MaxLifetime ends a session this long after it was created
+	MaxLifetime time.Duration
+}
+
+// Issued is a newly created session. Token is the only copy of the cookie
+// value; it is not stored.
+type Issued struct {
+	Session
+	Token string
+}
+
+type Service interface {
+	Create(ctx context.Context, userID uint, ipAddress, userAgent string) (Issued, error)
+	Authenticate(ctx context.Context, token string) (Session, error)
+	VerifySession(ctx context.Context, token string) (uint, uint, string, error)
+	Revoke(ctx context.Context, token string) error
+	List(ctx context.Context, userID uint) ([]Session, error)
+	RevokeByID(ctx context.Context, userID, id uint) error
+	RevokeAll(ctx context.Context, userID uint) error
+}
+
+type service struct {
+	store      Store
+	opts       Options
+	touchEvery time.Duration
+	now        func() time.Time
+	logger     *appLogger.LogrusLogger
+
+	// lastCleanup limits expired session cleanup to once per idle timeout
+	cleanupMu   sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewService(store Store, opts Options) *service {
+	touchEvery := touchInterval
+	if opts.IdleTimeout/10 < touchEvery {
+		touchEvery = opts.IdleTimeout / 10
+	}
+
+	return &service{
+		store:      store,
+		opts:       opts,
+		touchEvery: touchEvery,
+		now:        time.Now,
+		logger:     appLogger.SimpleLogger("session"),
+	}
+}
+
+// Create starts a session for a user who just logged in
+func (s *service) Create(ctx context.Context, userID uint, ipAddress, userAgent string) (Issued, error) {
+	now := s.now()
+	s.deleteExpired(ctx, now)
+
+	token, err := randomToken()
+	if err != nil {
+		return Issued{}, err
+	}
+	csrf, err := randomToken()
+	if err != nil {
+		return Issued{}, err
+	}
+	userAgent = truncate(userAgent, maxUserAgent)
+
+	session := Session{
+		CreatedAt:  now,
+		UserID:     userID,
+		TokenHash:  hashToken(token),
+		CSRFToken:  csrf,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		LastSeenAt: now,
+		ExpiresAt:  s.expiry(now, now),
+	}
+	if err = s.store.Create(ctx, &session); err != nil {
+		return Issued{}, err
+	}
+
+	s.logger.Info("Session created", "user_id", userID, "session_id", session.ID)
+	return Issued{Session: session, Token: token}, nil
+}
+
+// Authenticate returns the session the cookie value belongs to and pushes its
+// expiry back by the idle timeout
+func (s *service) Authenticate(ctx context.Context, token string) (Session, error) {
+	session, err := s.find(ctx, token)
+	if err != nil {
+		return session, err
+	}
+
+	now := s.now()
+	if session.Expired(now) {
+		return session, ErrInvalidSession
+	}
+	if now.Sub(session.LastSeenAt) < s.touchEvery {
+		return session, nil
+	}
+
+	session.LastSeenAt = now
+	session.ExpiresAt = s.expiry(session.CreatedAt, now)
+	if err = s.store.Touch(ctx, session.ID, session.LastSeenAt, session.ExpiresAt); err != nil {
+		return session, err
+	}
+	return session, nil
+}
+
+// VerifySession authenticates a cookie value for middlewares.SessionCookie,
+// returning the user, the session ID and the session's CSRF token
+func (s *service) VerifySession(ctx context.Context, token string) (uint, uint, string, error) {
+	session, err := s.Authenticate(ctx, token)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	return session.UserID, session.ID, session.CSRFToken, nil
+}
+
+// Revoke ends the session the cookie value belongs to. Unknown sessions are
+// ignored so logging out twice is not an error.
+func (s *service) Revoke(ctx context.Context, token string) error {
+	session, err := s.find(ctx, token)
+	if errors.Is(err, ErrInvalidSession) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.store.Delete(ctx, session.ID)
+}
+
+// List returns the user's active sessions, most recently used first
+func (s *service) List(ctx context.Context, userID uint) ([]Session, error) {
+	return s.store.ListByUser(ctx, userID, s.now())
+}
+
+// RevokeByID ends one of the user's sessions
+func (s *service) RevokeByID(ctx context.Context, userID, id uint) error {
+	if err := s.store.DeleteForUser(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("Session revoked", "user_id", userID, "session_id", id)
+	return nil
+}
+
+// RevokeAll ends every session of the user
+func (s *service) RevokeAll(ctx context.Context, userID uint) error {
+	deleted, err := s.store.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("All sessions revoked", "user_id", userID, "count", deleted)
+	return nil
+}
+
+// find looks a session up by the hash of its cookie value
+func (s *service) find(ctx context.Context, token string) (Session, error) {
+	if token == "" {
+		return Session{}, ErrInvalidSession
+	}
+
+	session, err := s.store.FindByHash(ctx, hashToken(token))
+	if errors.Is(err, ErrSessionNotFound) {
+		return session, ErrInvalidSession
+	}
+	return session, err
+}
+
+// expiry is the idle timeout from now, capped at the maximum lifetime of a
+// session created at createdAt
+func (s *service) expiry(createdAt, now time.Time) time.Time {
+	expiresAt := now.Add(s.opts.IdleTimeout)
+	if limit := createdAt.Add(s.opts.MaxLifetime); expiresAt.After(limit) {
+		return limit
+	}
+	return expiresAt
+}
+
+// deleteExpired removes ended sessions of every tenant, at most once per idle
+// timeout.
+// Failures are only logged since cleanup is housekeeping.
+func (s *service) deleteExpired(ctx context.Context, now time.Time) {
+	s.cleanupMu.Lock()
+	if now.Sub(s.lastCleanup) < s.opts.IdleTimeout {
+		s.cleanupMu.Unlock()
+		return
+	}
+	s.lastCleanup = now
+	s.cleanupMu.Unlock()
+
+	if _, err := s.store.DeleteExpired(tenancy.AllTenants(ctx), now); err != nil {
+		s.logger.Warn("Failed to delete expired sessions", "error", err)
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// randomToken returns a random URL-safe token
+func randomToken() (string, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken returns the hex SHA-256 of a session ID. Session IDs are random,
+// so a fast unsalted hash is enough to make a leaked table useless.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
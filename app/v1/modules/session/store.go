@@ -0,0 +1,24 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps sessions
+type Store interface {
+	Create(ctx context.Context, s *Session) error
+	// FindByHash returns the session with the token hash, expired or not
+	FindByHash(ctx context.Context, hash string) (Session, error)
+	// Touch records use of the session and moves its expiry
+	Touch(ctx context.Context, id uint, lastSeenAt, expiresAt time.Time) error
+	Delete(ctx context.Context, id uint) error
+	// DeleteForUser deletes one of a user's sessions, returning
+	// ErrSessionNotFound when the user has no session with that ID
+	DeleteForUser(ctx context.Context, userID, id uint) error
+	DeleteAllForUser(ctx context.Context, userID uint) (int64, error)
+	// ListByUser returns the user's sessions active at now, most recently
+	// used first
+	ListByUser(ctx context.Context, userID uint, now time.Time) ([]Session, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
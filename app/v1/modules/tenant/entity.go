@@ -0,0 +1,24 @@
+// Package tenant contains the customers that share one deployment
+package tenant
+
+import (
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// Tenant is a customer whose users and data are kept apart from every other
+// customer's. Slug names the tenant in headers and subdomains and cannot be
+// changed once created.
+type Tenant struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Slug      string `gorm:"type:varchar(63);not null;uniqueIndex"`
+	Name      string `gorm:"type:varchar(250);not null"`
+}
+
+// Ref returns what the request context carries about the tenant
+func (t Tenant) Ref() tenancy.Tenant {
+	return tenancy.Tenant{ID: t.ID, Slug: t.Slug}
+}
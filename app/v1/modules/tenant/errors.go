@@ -0,0 +1,16 @@
+package tenant
+
+import (
+	"errors"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+var (
+	// ErrTenantNotFound is returned when no tenant has the slug or ID
+	ErrTenantNotFound = tenancy.ErrUnknownTenant
+	// ErrTenantExists is returned when creating a tenant whose slug is taken
+	ErrTenantExists = errors.New("a tenant with this slug already exists")
+	// ErrInvalidSlug is returned for slugs that are not a lowercase DNS label
+	ErrInvalidSlug = errors.New("slug must be lowercase letters, digits and -, and start and end with a letter or digit")
+)
@@ -0,0 +1,68 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Save(ctx context.Context, tenant Tenant) (Tenant, error)
+	FindByID(ctx context.Context, id uint) (Tenant, error)
+	FindBySlug(ctx context.Context, slug string) (Tenant, error)
+	List(ctx context.Context) ([]Tenant, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Save(ctx context.Context, tenant Tenant) (Tenant, error) {
+	err := r.db.WithContext(ctx).Create(&tenant).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return tenant, ErrTenantExists
+	}
+	if err != nil {
+		return tenant, err
+	}
+
+	return tenant, nil
+}
+
+func (r *repository) FindByID(ctx context.Context, id uint) (Tenant, error) {
+	var tenant Tenant
+	err := r.db.WithContext(ctx).First(&tenant, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tenant, ErrTenantNotFound
+	}
+	if err != nil {
+		return tenant, err
+	}
+
+	return tenant, nil
+}
+
+func (r *repository) FindBySlug(ctx context.Context, slug string) (Tenant, error) {
+	var tenant Tenant
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&tenant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tenant, ErrTenantNotFound
+	}
+	if err != nil {
+		return tenant, err
+	}
+
+	return tenant, nil
+}
+
+// List returns every tenant, oldest first
+func (r *repository) List(ctx context.Context) ([]Tenant, error) {
+	var tenants []Tenant
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&tenants).Error
+	return tenants, err
+}
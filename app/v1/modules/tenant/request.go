@@ -0,0 +1,8 @@
+package tenant
+
+// CreateTenantForm represents the request body for creating a tenant
+// @Description Create tenant request form
+type CreateTenantForm struct {
+	Slug string `form:"slug" json:"slug" validate:"required,max=63" example:"acme"`
+	Name string `form:"name" json:"name" validate:"required,max=250" example:"Acme Corporation"`
+}
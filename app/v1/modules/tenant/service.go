@@ -0,0 +1,115 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+type Service interface {
+	// TenantBySlug and TenantByID resolve the tenant of a request. Tenants
+	// are cached once found since they are never renamed or deleted.
+	TenantBySlug(ctx context.Context, slug string) (tenancy.Tenant, error)
+	TenantByID(ctx context.Context, id uint) (tenancy.Tenant, error)
+	List(ctx context.Context) ([]Tenant, error)
+	Create(ctx context.Context, input *CreateTenantForm) (Tenant, error)
+	// Ensure returns the tenant with the slug, creating it if missing
+	Ensure(ctx context.Context, slug, name string) (Tenant, error)
+}
+
+type service struct {
+	repository Repository
+	logger     *appLogger.LogrusLogger
+
+	mu     sync.RWMutex
+	bySlug map[string]tenancy.Tenant
+	byID   map[uint]tenancy.Tenant
+}
+
+func NewService(repository Repository) *service {
+	return &service{
+		repository: repository,
+		logger:     appLogger.SimpleLogger("tenant"),
+		bySlug:     map[string]tenancy.Tenant{},
+		byID:       map[uint]tenancy.Tenant{},
+	}
+}
+
+func (s *service) TenantBySlug(ctx context.Context, slug string) (tenancy.Tenant, error) {
+	s.mu.RLock()
+	t, ok := s.bySlug[slug]
+	s.mu.RUnlock()
+	if ok {
+		return t, nil
+	}
+	if !tenancy.ValidSlug(slug) {
+		return tenancy.Tenant{}, ErrTenantNotFound
+	}
+
+	tenant, err := s.repository.FindBySlug(ctx, slug)
+	if err != nil {
+		return tenancy.Tenant{}, err
+	}
+	return s.remember(tenant), nil
+}
+
+func (s *service) TenantByID(ctx context.Context, id uint) (tenancy.Tenant, error) {
+	s.mu.RLock()
+	t, ok := s.byID[id]
+	s.mu.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	tenant, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return tenancy.Tenant{}, err
+	}
+	return s.remember(tenant), nil
+}
+
+// List returns every tenant, oldest first
+func (s *service) List(ctx context.Context) ([]Tenant, error) {
+	return s.repository.List(ctx)
+}
+
+func (s *service) Create(ctx context.Context, input *CreateTenantForm) (Tenant, error) {
+	slug := strings.ToLower(strings.TrimSpace(input.Slug))
+	if !tenancy.ValidSlug(slug) {
+		return Tenant{}, ErrInvalidSlug
+	}
+
+	tenant, err := s.repository.Save(ctx, Tenant{Slug: slug, Name: strings.TrimSpace(input.Name)})
+	if err != nil {
+		return tenant, err
+	}
+
+	s.logger.Info("Tenant created", "tenant_id", tenant.ID, "slug", tenant.Slug)
+	return tenant, nil
+}
+
+func (s *service) Ensure(ctx context.Context, slug, name string) (Tenant, error) {
+	tenant, err := s.repository.FindBySlug(ctx, slug)
+	if errors.Is(err, ErrTenantNotFound) {
+		tenant, err = s.Create(ctx, &CreateTenantForm{Slug: slug, Name: name})
+		if errors.Is(err, ErrTenantExists) {
+			// Another instance created it first
+			return s.repository.FindBySlug(ctx, slug)
+		}
+	}
+	return tenant, err
+}
+
+// remember caches a tenant found in the database
+func (s *service) remember(tenant Tenant) tenancy.Tenant {
+	t := tenant.Ref()
+	s.mu.Lock()
+	s.bySlug[t.Slug] = t
+	s.byID[t.ID] = t
+	s.mu.Unlock()
+	return t
+}
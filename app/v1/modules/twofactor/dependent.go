@@ -0,0 +1,20 @@
+package twofactor
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserDependent deletes a user's enrolment and recovery codes when the user
+// is purged. Soft deleted users keep them for when they are restored.
+type UserDependent struct{}
+
+func (UserDependent) RevokeUser(context.Context, *gorm.DB, uint, time.Time) error {
+	return nil
+}
+
+func (UserDependent) DeleteUser(ctx context.Context, tx *gorm.DB, userID uint) error {
+	return deleteUser(tx.WithContext(ctx), userID)
+}
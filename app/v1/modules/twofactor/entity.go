@@ -0,0 +1,34 @@
+package twofactor
+
+import "time"
+
+// TwoFactor is a user's TOTP enrolment. The secret is stored encrypted. The
+// enrolment only protects logins once ConfirmedAt is set by a first valid code.
+type TwoFactor struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	TenantID  uint   `gorm:"not null;index"`
+	UserID    uint   `gorm:"not null;uniqueIndex"`
+	Secret    string `gorm:"type:varchar(255);not null"`
+	// LastUsedStep is the time step of the last accepted code; codes from
+	// this step or earlier are rejected so each code works once
+	LastUsedStep int64 `gorm:"not null;default:0"`
+	ConfirmedAt  *time.Time
+}
+
+// Enabled reports whether the enrolment has been confirmed
+func (t TwoFactor) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+// RecoveryCode is a one-time code that replaces a TOTP code when the
+// authenticator is lost. Only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TenantID  uint   `gorm:"not null;index"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"type:char(64);not null;index"`
+	UsedAt    *time.Time
+}
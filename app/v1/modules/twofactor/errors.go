@@ -0,0 +1,15 @@
+package twofactor
+
+import "errors"
+
+var (
+	// ErrNotEnrolled is returned when the user has not started enrolment
+	ErrNotEnrolled = errors.New("two-factor authentication is not set up")
+	// ErrAlreadyEnabled is returned when enrolling while two-factor
+	// authentication is already on
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrNotEnabled is returned when an action needs confirmed enrolment
+	ErrNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrInvalidCode is returned for wrong, reused and expired codes
+	ErrInvalidCode = errors.New("invalid two-factor code")
+)
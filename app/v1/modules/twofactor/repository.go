@@ -0,0 +1,134 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindByUser(ctx context.Context, userID uint) (TwoFactor, error)
+	Replace(ctx context.Context, enrolment TwoFactor) (TwoFactor, error)
+	Confirm(ctx context.Context, enrolment TwoFactor, step int64, at time.Time, codes []RecoveryCode) (bool, error)
+	UseStep(ctx context.Context, enrolment TwoFactor, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []RecoveryCode) error
+	UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uint) (int64, error)
+	Delete(ctx context.Context, userID uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+// FindByUser returns the enrolment of a user, confirmed or not
+func (r *repository) FindByUser(ctx context.Context, userID uint) (TwoFactor, error) {
+	var enrolment TwoFactor
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&enrolment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return enrolment, ErrNotEnrolled
+	}
+	if err != nil {
+		return enrolment, err
+	}
+
+	return enrolment, nil
+}
+
+// Replace stores a new unconfirmed enrolment, discarding an earlier one and
+// its recovery codes
+func (r *repository) Replace(ctx context.Context, enrolment TwoFactor) (TwoFactor, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteUser(tx, enrolment.UserID); err != nil {
+			return err
+		}
+		return tx.Create(&enrolment).Error
+	})
+	if err != nil {
+		return enrolment, err
+	}
+
+	return enrolment, nil
+}
+
+// Confirm enables an unconfirmed enrolment and stores its recovery codes in
+// one transaction. It reports false when the enrolment was already confirmed
+// or replaced in the meantime.
+func (r *repository) Confirm(ctx context.Context, enrolment TwoFactor, step int64, at time.Time, codes []RecoveryCode) (bool, error) {
+	confirmed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&TwoFactor{}).
+			Where("id = ? AND confirmed_at IS NULL", enrolment.ID).
+			Updates(map[string]interface{}{"confirmed_at": at, "last_used_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		confirmed = true
+		return tx.Create(&codes).Error
+	})
+
+	return confirmed, err
+}
+
+// UseStep records step as the last used time step. It reports false when a
+// code from this or a later step was already accepted, which also makes two
+// concurrent uses of one code fail.
+func (r *repository) UseStep(ctx context.Context, enrolment TwoFactor, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&TwoFactor{}).
+		Where("id = ? AND last_used_step < ?", enrolment.ID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ReplaceRecoveryCodes swaps every recovery code of the user for a new set
+func (r *repository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode marks an unused recovery code as used and reports whether
+// one matched
+func (r *repository) UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left
+func (r *repository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// Delete removes the enrolment and recovery codes of the user
+func (r *repository) Delete(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteUser(tx, userID)
+	})
+}
+
+// deleteUser removes every two-factor row of the user inside a transaction
+func deleteUser(tx *gorm.DB, userID uint) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&TwoFactor{}).Error
+}
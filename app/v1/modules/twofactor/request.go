@@ -0,0 +1,7 @@
+package twofactor
+
+// CodeForm carries a code from the authenticator app or a recovery code
+// @Description Two-factor code
+type CodeForm struct {
+	Code string `json:"code" validate:"required,max=32" example:"123456"`
+}
@@ -0,0 +1,23 @@
+package twofactor
+
+// EnrollmentResponse carries a new TOTP secret. The otpauth URI is usually
+// shown as a QR code for the authenticator app to scan.
+// @Description New TOTP secret
+type EnrollmentResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OtpauthURI string `json:"otpauth_uri" example:"otpauth://totp/BoilerGo:john.doe@example.com?algorithm=SHA1&digits=6&issuer=BoilerGo&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// RecoveryCodesResponse carries freshly generated recovery codes. They are
+// shown once; only their hashes are kept.
+// @Description One-time recovery codes
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7p2q-x9m4t,b3n8w-r5c6d"`
+}
+
+// StatusResponse tells whether two-factor authentication is enabled
+// @Description Two-factor authentication status
+type StatusResponse struct {
+	Enabled           bool  `json:"enabled" example:"true"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left" example:"10"`
+}
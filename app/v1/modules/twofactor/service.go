@@ -0,0 +1,310 @@
+// Package twofactor adds optional RFC 6238 TOTP two-factor authentication
+// with one-time recovery codes
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes are issued at a time
+	recoveryCodeCount = 10
+	// recoveryCodeLength is the number of characters in a recovery code,
+	// giving 50 bits of randomness
+	recoveryCodeLength = 10
+	// recoveryAlphabet avoids characters that are easy to misread
+	recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+type Service interface {
+	Status(ctx context.Context, userID uint) (StatusResponse, error)
+	Enroll(ctx context.Context, userID uint) (EnrollmentResponse, error)
+	Confirm(ctx context.Context, userID uint, input *CodeForm) (RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID uint, input *CodeForm) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, input *CodeForm) (RecoveryCodesResponse, error)
+	Enabled(ctx context.Context, userID uint) (bool, error)
+	Verify(ctx context.Context, userID uint, code string) error
+}
+
+type service struct {
+	repository Repository
+	users      user.Repository
+	totp       *TOTP
+	cipher     *secretCipher
+	logger     *appLogger.LogrusLogger
+}
+
+func NewService(repository Repository, users user.Repository, totp *TOTP, secretKey string) (*service, error) {
+	cipher, err := newSecretCipher(secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service{
+		repository: repository,
+		users:      users,
+		totp:       totp,
+		cipher:     cipher,
+		logger:     appLogger.SimpleLogger("twofactor"),
+	}, nil
+}
+
+// Status reports whether the user has two-factor authentication enabled
+func (s *service) Status(ctx context.Context, userID uint) (StatusResponse, error) {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil || !enabled {
+		return StatusResponse{}, err
+	}
+
+	left, err := s.repository.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return StatusResponse{}, err
+	}
+
+	return StatusResponse{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// Enroll starts enrolment with a new secret. It has no effect on logins until
+// confirmed; enrolling again before that replaces the secret.
+func (s *service) Enroll(ctx context.Context, userID uint) (EnrollmentResponse, error) {
+	account, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return EnrollmentResponse{}, err
+	}
+
+	enrolment, err := s.repository.FindByUser(ctx, userID)
+	if err == nil && enrolment.Enabled() {
+		return EnrollmentResponse{}, ErrAlreadyEnabled
+	}
+	if err != nil && !errors.Is(err, ErrNotEnrolled) {
+		return EnrollmentResponse{}, err
+	}
+
+	accountName := account.EmailAddress()
+	if accountName == "" {
+		accountName = account.Name
+	}
+	key, err := s.totp.Generate(accountName)
+	if err != nil {
+		return EnrollmentResponse{}, err
+	}
+
+	encrypted, err := s.cipher.encrypt(key.Secret)
+	if err != nil {
+		return EnrollmentResponse{}, err
+	}
+	if _, err = s.repository.Replace(ctx, TwoFactor{UserID: userID, Secret: encrypted}); err != nil {
+		return EnrollmentResponse{}, err
+	}
+
+	return EnrollmentResponse{Secret: key.Secret, OtpauthURI: key.URI}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their
+// authenticator produces valid codes, and returns the first recovery codes
+func (s *service) Confirm(ctx context.Context, userID uint, input *CodeForm) (RecoveryCodesResponse, error) {
+	enrolment, err := s.repository.FindByUser(ctx, userID)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	if enrolment.Enabled() {
+		return RecoveryCodesResponse{}, ErrAlreadyEnabled
+	}
+
+	step, err := s.validateTOTP(enrolment, input.Code)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+
+	codes, records, err := s.newRecoveryCodes(userID)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+
+	confirmed, err := s.repository.Confirm(ctx, enrolment, step, s.totp.Now(), records)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	if !confirmed {
+		return RecoveryCodesResponse{}, ErrInvalidCode
+	}
+
+	s.logger.Info("Two-factor authentication enabled", "user_id", userID)
+	return RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor authentication off after checking a current code
+func (s *service) Disable(ctx context.Context, userID uint, input *CodeForm) error {
+	if err := s.Verify(ctx, userID, input.Code); err != nil {
+		return err
+	}
+
+	if err := s.repository.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Two-factor authentication disabled", "user_id", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after checking a
+// current code
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, userID uint, input *CodeForm) (RecoveryCodesResponse, error) {
+	if err := s.Verify(ctx, userID, input.Code); err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+
+	codes, records, err := s.newRecoveryCodes(userID)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	if err = s.repository.ReplaceRecoveryCodes(ctx, userID, records); err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+
+	s.logger.Info("Recovery codes regenerated", "user_id", userID)
+	return RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Enabled reports whether logins of the user need a second factor
+func (s *service) Enabled(ctx context.Context, userID uint) (bool, error) {
+	enrolment, err := s.repository.FindByUser(ctx, userID)
+	if errors.Is(err, ErrNotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return enrolment.Enabled(), nil
+}
+
+// Verify checks a TOTP code or an unused recovery code. Every code is
+// accepted only once.
+func (s *service) Verify(ctx context.Context, userID uint, code string) error {
+	enrolment, err := s.repository.FindByUser(ctx, userID)
+	if errors.Is(err, ErrNotEnrolled) {
+		return ErrNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if !enrolment.Enabled() {
+		return ErrNotEnabled
+	}
+
+	code = normalizeCode(code)
+	if isTOTPCode(code) {
+		step, err := s.validateTOTP(enrolment, code)
+		if err != nil {
+			return err
+		}
+
+		used, err := s.repository.UseStep(ctx, enrolment, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+
+	used, err := s.repository.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), s.totp.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidCode
+	}
+
+	s.logger.Info("Recovery code used", "user_id", userID)
+	return nil
+}
+
+// validateTOTP checks a TOTP code against the enrolment's secret and returns
+// its time step. Codes from steps already used are rejected.
+func (s *service) validateTOTP(enrolment TwoFactor, code string) (int64, error) {
+	secret, err := s.cipher.decrypt(enrolment.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := s.totp.Validate(secret, normalizeCode(code))
+	if !ok || step <= enrolment.LastUsedStep {
+		return 0, ErrInvalidCode
+	}
+
+	return step, nil
+}
+
+// newRecoveryCodes returns a set of recovery codes to show the user and the
+// records to store for them
+func (s *service) newRecoveryCodes(userID uint) ([]string, []RecoveryCode, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]RecoveryCode, recoveryCodeCount)
+
+	for i := range codes {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		records[i] = RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)}
+	}
+
+	return codes, records, nil
+}
+
+// randomRecoveryCode returns recoveryCodeLength random characters from
+// recoveryAlphabet
+func randomRecoveryCode() (string, error) {
+	// 256 is not a multiple of the alphabet size, so bytes at or above limit
+	// are drawn again to keep every character equally likely
+	limit := byte(256 - 256%len(recoveryAlphabet))
+	code := make([]byte, 0, recoveryCodeLength)
+	b := make([]byte, 1)
+	for len(code) < recoveryCodeLength {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		if b[0] < limit {
+			code = append(code, recoveryAlphabet[int(b[0])%len(recoveryAlphabet)])
+		}
+	}
+
+	return string(code), nil
+}
+
+// hashRecoveryCode returns the hash stored for a normalized recovery code
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCode drops the spaces and dashes users type into codes and lower
+// cases recovery codes
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// isTOTPCode reports whether the code looks like a six digit TOTP code
+func isTOTPCode(code string) bool {
+	if len(code) != int(validateOpts.Digits) {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package twofactor
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	// period is the RFC 6238 time step
+	period = 30
+	// skew is how many steps before and after the current one are accepted,
+	// allowing for clock drift between server and authenticator
+	skew = 1
+)
+
+// validateOpts are the RFC 6238 defaults understood by common authenticator
+// apps
+var validateOpts = totp.ValidateOpts{
+	Period:    period,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// Key is a freshly generated TOTP secret together with its otpauth:// URI,
+// which authenticator apps read from a QR code
+type Key struct {
+	Secret string
+	URI    string
+}
+
+// TOTP generates and checks RFC 6238 time-based one-time passwords. The clock
+// is injected so codes can be checked against a fixed time in tests.
+type TOTP struct {
+	issuer string
+	now    func() time.Time
+}
+
+// NewTOTP returns a TOTP using the given issuer name in otpauth URIs and the
+// clock now, usually time.Now
+func NewTOTP(issuer string, now func() time.Time) *TOTP {
+	return &TOTP{issuer: issuer, now: now}
+}
+
+// Now returns the current time of the TOTP clock
+func (t *TOTP) Now() time.Time {
+	return t.now()
+}
+
+// Generate creates a new random secret for the account
+func (t *TOTP) Generate(accountName string) (Key, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      t.issuer,
+		AccountName: accountName,
+		Period:      period,
+		Digits:      validateOpts.Digits,
+		Algorithm:   validateOpts.Algorithm,
+	})
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{Secret: key.Secret(), URI: key.URL()}, nil
+}
+
+// Code returns the code for the secret at time at
+func (t *TOTP) Code(secret string, at time.Time) (string, error) {
+	return totp.GenerateCodeCustom(secret, at, validateOpts)
+}
+
+// Validate checks a code against the secret and returns the time step it
+// belongs to. Callers store the step and reject codes from steps not after
+// it, so a code cannot be used twice.
+func (t *TOTP) Validate(secret, code string) (int64, bool) {
+	current := t.now().Unix() / period
+
+	for step := current - skew; step <= current+skew; step++ {
+		expected, err := t.Code(secret, time.Unix(step*period, 0))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,238 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the RFC 6238 SHA-1 test key "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// fixedClock returns a clock stopped at the given Unix time
+func fixedClock(unix int64) func() time.Time {
+	return func() time.Time { return time.Unix(unix, 0) }
+}
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		totp := NewTOTP("Test", fixedClock(v.unix))
+
+		code, err := totp.Code(rfcSecret, totp.Now())
+		if err != nil {
+			t.Fatalf("Code at %d: %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("Code at %d = %s, want %s", v.unix, code, v.code)
+		}
+
+		step, ok := totp.Validate(rfcSecret, v.code)
+		if !ok || step != v.unix/period {
+			t.Errorf("Validate at %d = (%d, %v), want (%d, true)", v.unix, step, ok, v.unix/period)
+		}
+	}
+}
+
+func TestTOTPValidateAllowsOneStepOfDrift(t *testing.T) {
+	const now = 1234567890
+	totp := NewTOTP("Test", fixedClock(now))
+
+	for _, offset := range []int64{-period, 0, period} {
+		code, _ := totp.Code(rfcSecret, time.Unix(now+offset, 0))
+		step, ok := totp.Validate(rfcSecret, code)
+		if !ok || step != (now+offset)/period {
+			t.Errorf("code from offset %ds rejected", offset)
+		}
+	}
+
+	for _, offset := range []int64{-2 * period, 2 * period} {
+		code, _ := totp.Code(rfcSecret, time.Unix(now+offset, 0))
+		if _, ok := totp.Validate(rfcSecret, code); ok {
+			t.Errorf("code from offset %ds accepted", offset)
+		}
+	}
+
+	if _, ok := totp.Validate(rfcSecret, "000000"); ok {
+		t.Error("wrong code accepted")
+	}
+}
+
+func TestTOTPGenerate(t *testing.T) {
+	totp := NewTOTP("BoilerGo", time.Now)
+
+	key, err := totp.Generate("john.doe@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uri, err := url.Parse(key.URI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("unexpected URI %s", key.URI)
+	}
+	if uri.Query().Get("secret") != key.Secret || uri.Query().Get("issuer") != "BoilerGo" {
+		t.Errorf("URI %s does not carry the secret and issuer", key.URI)
+	}
+	if !strings.Contains(uri.Path, "john.doe@example.com") {
+		t.Errorf("URI %s does not name the account", key.URI)
+	}
+
+	code, _ := totp.Code(key.Secret, totp.Now())
+	if _, ok := totp.Validate(key.Secret, code); !ok {
+		t.Error("code for a generated secret rejected")
+	}
+}
+
+func TestSecretCipher(t *testing.T) {
+	c, _ := newSecretCipher("app-secret")
+
+	encrypted, err := c.encrypt(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(encrypted, rfcSecret) {
+		t.Fatal("secret stored in clear")
+	}
+
+	decrypted, err := c.decrypt(encrypted)
+	if err != nil || decrypted != rfcSecret {
+		t.Fatalf("decrypt = (%q, %v), want %q", decrypted, err, rfcSecret)
+	}
+
+	other, _ := newSecretCipher("other-secret")
+	if _, err = other.decrypt(encrypted); err == nil {
+		t.Error("decrypted with the wrong key")
+	}
+}
+
+// memoryRepository is an in-memory Repository for service tests
+type memoryRepository struct {
+	enrolment *TwoFactor
+	codes     []RecoveryCode
+}
+
+func (r *memoryRepository) FindByUser(_ context.Context, userID uint) (TwoFactor, error) {
+	if r.enrolment == nil || r.enrolment.UserID != userID {
+		return TwoFactor{}, ErrNotEnrolled
+	}
+	return *r.enrolment, nil
+}
+
+func (r *memoryRepository) Replace(_ context.Context, enrolment TwoFactor) (TwoFactor, error) {
+	enrolment.ID = 1
+	r.enrolment, r.codes = &enrolment, nil
+	return enrolment, nil
+}
+
+func (r *memoryRepository) Confirm(_ context.Context, enrolment TwoFactor, step int64, at time.Time, codes []RecoveryCode) (bool, error) {
+	if r.enrolment.Enabled() {
+		return false, nil
+	}
+	r.enrolment.ConfirmedAt, r.enrolment.LastUsedStep, r.codes = &at, step, codes
+	return true, nil
+}
+
+func (r *memoryRepository) UseStep(_ context.Context, enrolment TwoFactor, step int64) (bool, error) {
+	if r.enrolment.LastUsedStep >= step {
+		return false, nil
+	}
+	r.enrolment.LastUsedStep = step
+	return true, nil
+}
+
+func (r *memoryRepository) ReplaceRecoveryCodes(_ context.Context, userID uint, codes []RecoveryCode) error {
+	r.codes = codes
+	return nil
+}
+
+func (r *memoryRepository) UseRecoveryCode(_ context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	for i := range r.codes {
+		if r.codes[i].CodeHash == hash && r.codes[i].UsedAt == nil {
+			r.codes[i].UsedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRepository) CountRecoveryCodes(_ context.Context, userID uint) (int64, error) {
+	var count int64
+	for _, code := range r.codes {
+		if code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryRepository) Delete(_ context.Context, userID uint) error {
+	r.enrolment, r.codes = nil, nil
+	return nil
+}
+
+func TestServiceRejectsReusedCodes(t *testing.T) {
+	now := int64(1234567890)
+	totp := NewTOTP("Test", func() time.Time { return time.Unix(now, 0) })
+	ctx := context.Background()
+	repo := &memoryRepository{}
+	s, _ := NewService(repo, nil, totp, "app-secret")
+
+	encrypted, _ := s.cipher.encrypt(rfcSecret)
+	repo.Replace(ctx, TwoFactor{UserID: 7, Secret: encrypted})
+
+	code, _ := totp.Code(rfcSecret, totp.Now())
+	recovery, err := s.Confirm(ctx, 7, &CodeForm{Code: code})
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if len(recovery.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes", len(recovery.RecoveryCodes))
+	}
+
+	// The code used to confirm cannot be used to log in
+	if err = s.Verify(ctx, 7, code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("reused confirmation code: %v", err)
+	}
+
+	// Nor can a code from the previous step once a later one was accepted
+	now += period
+	next, _ := totp.Code(rfcSecret, totp.Now())
+	if err = s.Verify(ctx, 7, next); err != nil {
+		t.Errorf("next code: %v", err)
+	}
+	if err = s.Verify(ctx, 7, code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("older code after newer one: %v", err)
+	}
+
+	// Recovery codes work once, typed in any case and with or without the dash
+	typed := strings.ToUpper(strings.Replace(recovery.RecoveryCodes[0], "-", " ", 1))
+	if err = s.Verify(ctx, 7, typed); err != nil {
+		t.Errorf("recovery code: %v", err)
+	}
+	if err = s.Verify(ctx, 7, recovery.RecoveryCodes[0]); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("reused recovery code: %v", err)
+	}
+
+	status, _ := s.Status(ctx, 7)
+	if !status.Enabled || status.RecoveryCodesLeft != recoveryCodeCount-1 {
+		t.Errorf("status = %+v", status)
+	}
+}
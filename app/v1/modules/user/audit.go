@@ -0,0 +1,31 @@
+package user
+
+import "time"
+
+// AuditResource names users in the audit log
+const AuditResource = "user"
+
+// auditRecord is the part of a user kept in the audit log. The password hash
+// and bookkeeping columns are left out.
+type auditRecord struct {
+	Name            string     `json:"name"`
+	Email           *string    `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Avatar          *string    `json:"avatar"`
+	DeletedAt       *time.Time `json:"deleted_at"`
+}
+
+func auditFields(u User) auditRecord {
+	record := auditRecord{
+		Name:            u.Name,
+		Email:           u.Email,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+	}
+	if u.Avatar.Set() {
+		record.Avatar = &u.Avatar.Key
+	}
+	if u.DeletedAt.Valid {
+		record.DeletedAt = &u.DeletedAt.Time
+	}
+	return record
+}
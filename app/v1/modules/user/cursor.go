@@ -0,0 +1,35 @@
+package user
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// cursor is the decoded form of an opaque pagination cursor. It remembers the
+// sort it was issued for so it cannot be replayed against a different order.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// encodeCursor turns a cursor into the opaque string handed to clients
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses an opaque cursor string issued for the given sort
+func decodeCursor(value string, sort string) (cursor, error) {
+	var c cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(raw, &c); err != nil || c.Sort != sort {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dependent is data another module keeps about users that must not outlive
+// them. Both methods run in the transaction that changes the user, so they
+// write through tx and a failure undoes the change to the user as well.
+type Dependent interface {
+	// RevokeUser ends the access the user has through the module when the
+	// user is soft deleted
+	RevokeUser(ctx context.Context, tx *gorm.DB, userID uint, at time.Time) error
+	// DeleteUser removes the module's rows of the user when the user is
+	// purged, so they cannot resolve to a later user given the same ID
+	DeleteUser(ctx context.Context, tx *gorm.DB, userID uint) error
+}
+
+// dependentTable is a Dependent for a model whose rows belong to one user
+// through a user_id column and grant nothing on their own. Soft deleting the
+// user keeps them, so restoring it brings them back.
+type dependentTable struct {
+	model interface{}
+}
+
+func NewDependentTable(model interface{}) *dependentTable {
+	return &dependentTable{model}
+}
+
+func (d *dependentTable) RevokeUser(context.Context, *gorm.DB, uint, time.Time) error {
+	return nil
+}
+
+func (d *dependentTable) DeleteUser(ctx context.Context, tx *gorm.DB, userID uint) error {
+	return tx.WithContext(ctx).Where("user_id = ?", userID).Delete(d.model).Error
+}
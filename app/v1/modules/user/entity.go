@@ -1,11 +1,63 @@
 // Package user contains entities and operations related to users
 package user
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // User represents a user entity in the system
 // @Description User account information
 type User struct {
 	gorm.Model
-	Name string `gorm:"type:varchar(250)" json:"name"`
+	// TenantID is the customer the user belongs to. Emails are unique per
+	// tenant, so the same person can have an account with several customers.
+	TenantID uint   `gorm:"not null;uniqueIndex:idx_users_tenant_email,priority:1" json:"-"`
+	Name     string `gorm:"type:varchar(250)" json:"name"`
+	// Email is nullable so rows created before emails were required migrate
+	// cleanly under the unique index
+	Email        *string `gorm:"type:varchar(320);uniqueIndex:idx_users_tenant_email,priority:2" json:"email"`
+	PasswordHash string  `gorm:"type:varchar(255)" json:"-"`
+	// EmailVerifiedAt is set once the user confirms they own Email and is
+	// cleared whenever Email changes
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// Avatar names the user's avatar images in file storage
+	Avatar Avatar `gorm:"embedded;embeddedPrefix:avatar_" json:"-"`
+	// Version is incremented on every change and guards updates against
+	// overwriting changes the client has not seen
+	Version uint `gorm:"not null;default:1" json:"-"`
+}
+
+// Avatar holds the storage keys of a user's avatar image and its thumbnail.
+// Both are empty when the user has no avatar.
+type Avatar struct {
+	Key          string `gorm:"type:varchar(255)"`
+	ThumbnailKey string `gorm:"type:varchar(255)"`
+}
+
+// Set reports whether the user has an avatar
+func (a Avatar) Set() bool {
+	return a.Key != ""
+}
+
+// EmailAddress returns the user's email, or an empty string if none is set
+func (u User) EmailAddress() string {
+	if u.Email == nil {
+		return ""
+	}
+	return *u.Email
+}
+
+// EmailVerified reports whether the user has confirmed their current email
+func (u User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// setEmail changes the email, dropping the verification if the address differs
+func (u *User) setEmail(email string) {
+	if u.EmailAddress() != email {
+		u.EmailVerifiedAt = nil
+	}
+	u.Email = &email
 }
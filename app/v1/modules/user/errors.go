@@ -0,0 +1,41 @@
+package user
+
+import "errors"
+
+var (
+	// ErrUserNotFound is returned when a user does not exist or has been soft deleted
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrUserNotDeleted is returned when restoring a user that is still active
+	ErrUserNotDeleted = errors.New("user is not deleted")
+
+	// ErrEmailTaken is returned when another user, active or soft deleted, already has the email
+	ErrEmailTaken = errors.New("email is already registered")
+
+	// ErrInvalidSort is returned when a listing is sorted by a field that is not whitelisted
+	ErrInvalidSort = errors.New("invalid sort field")
+
+	// ErrVersionMismatch is returned when a user was changed since the version the caller last read
+	ErrVersionMismatch = errors.New("user was changed by another request")
+
+	// ErrInvalidCursor is returned when a pagination cursor is malformed or was issued for another sort
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+	// ErrInvalidPatch is returned when a patch document is malformed
+	ErrInvalidPatch = errors.New("invalid patch document")
+
+	// ErrPatchConflict is returned when a well-formed patch cannot be applied to the user, for example when a test operation fails
+	ErrPatchConflict = errors.New("patch cannot be applied to the user")
+)
+
+// ReadOnlyFieldError is returned when a patch changes a field clients may not write
+type ReadOnlyFieldError struct {
+	Field string
+}
+
+func (e *ReadOnlyFieldError) Error() string {
+	if e.Field == "" {
+		return "the user cannot be replaced as a whole"
+	}
+	return e.Field + " is read-only"
+}
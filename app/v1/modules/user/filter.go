@@ -0,0 +1,120 @@
+package user
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sort fields accepted when listing users. Prefix a field with "-" to sort in
+// descending order, e.g. "-created_at".
+const (
+	SortName      = "name"
+	SortCreatedAt = "created_at"
+)
+
+// sortColumns whitelists the columns users may be sorted by
+var sortColumns = map[string]string{
+	SortName:      "name",
+	SortCreatedAt: "created_at",
+}
+
+// ListFilter narrows down and orders the users returned by a listing
+type ListFilter struct {
+	NameContains   string
+	CreatedAfter   *time.Time
+	IncludeDeleted bool
+	SortField      string
+	Descending     bool
+}
+
+// PageRequest selects a page either by offset or, when Cursor is set, by cursor
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// ListResult is a page of users together with paging information
+type ListResult struct {
+	Users      []User
+	Total      int64
+	NextCursor string
+}
+
+// ParseSort splits a sort expression like "-created_at" into a whitelisted
+// field and direction. An empty expression sorts by creation time.
+func ParseSort(expr string) (field string, descending bool, err error) {
+	if expr == "" {
+		return SortCreatedAt, false, nil
+	}
+
+	field = strings.TrimPrefix(expr, "-")
+	if _, ok := sortColumns[field]; !ok {
+		return "", false, ErrInvalidSort
+	}
+
+	return field, strings.HasPrefix(expr, "-"), nil
+}
+
+// sortKey is the canonical sort expression, used to bind cursors to an order
+func (f ListFilter) sortKey() string {
+	if f.Descending {
+		return "-" + f.SortField
+	}
+	return f.SortField
+}
+
+// sortColumn returns the database column for the sort field
+func (f ListFilter) sortColumn() string {
+	if column, ok := sortColumns[f.SortField]; ok {
+		return column
+	}
+	return sortColumns[SortCreatedAt]
+}
+
+// cursorValue returns the sort value of a user as stored in a cursor
+func (f ListFilter) cursorValue(u User) string {
+	if f.SortField == SortName {
+		return u.Name
+	}
+	return u.CreatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// cursorArg converts a cursor sort value back into a query argument
+func (f ListFilter) cursorArg(value string) (interface{}, error) {
+	if f.SortField == SortName {
+		return value, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return t.Local(), nil
+}
+
+// scope applies the filter conditions shared by every user listing. Names
+// are lowered on both sides because LIKE is case-sensitive on PostgreSQL, and
+// times are compared in the local zone they are written in, because SQLite
+// compares them as text.
+func (f ListFilter) scope(db *gorm.DB) *gorm.DB {
+	if f.IncludeDeleted {
+		db = db.Unscoped()
+	}
+	if f.NameContains != "" {
+		db = db.Where("LOWER(name) LIKE ? ESCAPE '!'", "%"+escapeLike(strings.ToLower(f.NameContains))+"%")
+	}
+	if f.CreatedAfter != nil {
+		db = db.Where("created_at > ?", f.CreatedAfter.Local())
+	}
+	return db
+}
+
+// likeEscaper escapes LIKE wildcards using "!" as the escape character
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,184 @@
+package user
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ImportBatchSize is the number of rows inserted per transaction during an import
+const ImportBatchSize = 500
+
+// maxImportLineBytes bounds a single NDJSON line so one bad row cannot exhaust memory
+const maxImportLineBytes = 64 * 1024
+
+// ImportRow is a single decoded row of an import file. Err is set when the row
+// could not be decoded; such rows are reported as rejected.
+type ImportRow struct {
+	Row  int
+	Form AddUserForm
+	Err  error
+}
+
+// RowDecoder reads import rows one at a time and returns io.EOF when done
+type RowDecoder interface {
+	Next() (ImportRow, error)
+}
+
+// ImportAccepted describes a row that passed validation (and was saved, unless dry run)
+type ImportAccepted struct {
+	Row  int    `json:"row" example:"1"`
+	ID   uint   `json:"id,omitempty" example:"42"`
+	Name string `json:"name" example:"John Doe"`
+}
+
+// ImportRejected describes a row that was not imported and why
+type ImportRejected struct {
+	Row    int    `json:"row" example:"2"`
+	Reason string `json:"reason" example:"name is a required field"`
+}
+
+// ImportReport summarizes the outcome of an import
+// @Description User import report
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run" example:"false"`
+	Total    int              `json:"total" example:"2"`
+	Accepted []ImportAccepted `json:"accepted"`
+	Rejected []ImportRejected `json:"rejected"`
+}
+
+// csvDecoder decodes rows from a CSV file whose first line names the columns.
+// Columns are matched to AddUserForm fields by their JSON name.
+type csvDecoder struct {
+	reader *csv.Reader
+	header []string
+	row    int
+}
+
+// NewCSVDecoder reads the header line of a CSV import and returns a decoder for its rows
+func NewCSVDecoder(r io.Reader) (RowDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("csv import is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+	// Drop a UTF-8 byte order mark left by spreadsheet exports
+	if len(columns) > 0 {
+		columns[0] = strings.TrimPrefix(columns[0], "\ufeff")
+	}
+
+	return &csvDecoder{reader: reader, header: columns}, nil
+}
+
+func (d *csvDecoder) Next() (ImportRow, error) {
+	record, err := d.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return ImportRow{}, io.EOF
+	}
+
+	d.row++
+	row := ImportRow{Row: d.row}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		row.Err = parseErr
+		return row, nil
+	}
+	if err != nil {
+		return row, err
+	}
+
+	if len(record) != len(d.header) {
+		row.Err = fmt.Errorf("expected %d columns, got %d", len(d.header), len(record))
+		return row, nil
+	}
+
+	fields := make(map[string]string, len(record))
+	for i, value := range record {
+		fields[d.header[i]] = value
+	}
+
+	// Round-trip through JSON so CSV columns follow the form's json tags
+	raw, _ := json.Marshal(fields)
+	if err = json.Unmarshal(raw, &row.Form); err != nil {
+		row.Err = err
+	}
+
+	return row, nil
+}
+
+// ndjsonDecoder decodes rows from newline delimited JSON, one object per line
+type ndjsonDecoder struct {
+	reader *bufio.Reader
+	row    int
+}
+
+// NewNDJSONDecoder returns a decoder for an NDJSON import
+func NewNDJSONDecoder(r io.Reader) RowDecoder {
+	return &ndjsonDecoder{reader: bufio.NewReader(r)}
+}
+
+func (d *ndjsonDecoder) Next() (ImportRow, error) {
+	for {
+		line, tooLong, err := d.readLine()
+		if errors.Is(err, io.EOF) && len(line) == 0 && !tooLong {
+			return ImportRow{}, io.EOF
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return ImportRow{}, err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 && !tooLong {
+			continue
+		}
+
+		d.row++
+		row := ImportRow{Row: d.row}
+		if tooLong {
+			row.Err = fmt.Errorf("line exceeds %d bytes", maxImportLineBytes)
+			return row, nil
+		}
+		if err := json.Unmarshal(line, &row.Form); err != nil {
+			row.Err = err
+		}
+		return row, nil
+	}
+}
+
+// readLine reads up to the next newline, discarding the rest of lines longer
+// than maxImportLineBytes
+func (d *ndjsonDecoder) readLine() (line []byte, tooLong bool, err error) {
+	for {
+		chunk, err := d.reader.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(chunk) > maxImportLineBytes {
+				tooLong = true
+				line = nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		return line, tooLong, err
+	}
+}
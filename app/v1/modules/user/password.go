@@ -0,0 +1,52 @@
+package user
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPasswordMismatch is returned when a password does not match its hash
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// PasswordHasher hashes passwords and checks passwords against stored hashes
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash string, password string) error
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher returns a PasswordHasher using bcrypt with the given cost.
+// Costs outside bcrypt's supported range fall back to bcrypt.DefaultCost.
+func NewBcryptHasher(cost int) PasswordHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &bcryptHasher{cost}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Compare(hash string, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+// NormalizeEmail trims and lower-cases an email address so lookups and the
+// unique index treat differently cased addresses as the same
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
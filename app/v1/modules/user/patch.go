@@ -0,0 +1,105 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Patch document media types
+const (
+	MIMEMergePatch = "application/merge-patch+json"
+	MIMEJSONPatch  = "application/json-patch+json"
+)
+
+// writableFields are the members of a user's JSON document a patch may change
+var writableFields = map[string]bool{
+	"name":  true,
+	"email": true,
+}
+
+// ApplyMergePatch applies an RFC 7396 JSON Merge Patch to the JSON document of
+// u and returns the resulting fields as a form. Members other than name and
+// email are rejected with a *ReadOnlyFieldError.
+func ApplyMergePatch(u User, patch []byte) (UpdateUserForm, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return UpdateUserForm{}, fmt.Errorf("%w: a merge patch must be a JSON object", ErrInvalidPatch)
+	}
+	for field := range members {
+		if !writableFields[field] {
+			return UpdateUserForm{}, &ReadOnlyFieldError{Field: field}
+		}
+	}
+
+	return applyPatch(u, func(doc []byte) ([]byte, error) {
+		return jsonpatch.MergePatch(doc, patch)
+	})
+}
+
+// ApplyJSONPatch applies an RFC 6902 JSON Patch to the JSON document of u and
+// returns the resulting fields as a form. Operations writing to, or moving
+// away from, anything but name and email are rejected with a
+// *ReadOnlyFieldError; test and copy may read any member.
+func ApplyJSONPatch(u User, patch []byte) (UpdateUserForm, error) {
+	ops, err := jsonpatch.DecodePatch(patch)
+	if err != nil {
+		return UpdateUserForm{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for _, op := range ops {
+		if op.Kind() == "test" {
+			continue
+		}
+		path, err := op.Path()
+		if err != nil {
+			return UpdateUserForm{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		if field := pointerField(path); !writableFields[field] {
+			return UpdateUserForm{}, &ReadOnlyFieldError{Field: field}
+		}
+		if op.Kind() == "move" {
+			from, err := op.From()
+			if err != nil {
+				return UpdateUserForm{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+			}
+			if field := pointerField(from); !writableFields[field] {
+				return UpdateUserForm{}, &ReadOnlyFieldError{Field: field}
+			}
+		}
+	}
+
+	return applyPatch(u, ops.Apply)
+}
+
+// applyPatch runs apply on the JSON document of u and reads the editable
+// fields back from the result. Operations that cannot be applied to the
+// document, such as a failed test, are reported as ErrPatchConflict.
+func applyPatch(u User, apply func(doc []byte) ([]byte, error)) (UpdateUserForm, error) {
+	var form UpdateUserForm
+
+	doc, err := json.Marshal(u)
+	if err != nil {
+		return form, err
+	}
+
+	patched, err := apply(doc)
+	if err != nil {
+		return form, fmt.Errorf("%w: %v", ErrPatchConflict, err)
+	}
+
+	if err = json.Unmarshal(patched, &form); err != nil {
+		return form, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return form, nil
+}
+
+// pointerField returns the top level member a JSON Pointer refers to, or an
+// empty string for the whole document
+func pointerField(pointer string) string {
+	field, _, _ := strings.Cut(strings.TrimPrefix(pointer, "/"), "/")
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(field)
+}
@@ -1,9 +1,29 @@
 package user
 
-import "gorm.io/gorm"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Repository interface {
-	Save(user User) (User, error)
+	Save(ctx context.Context, user User) (User, error)
+	SaveBatch(ctx context.Context, users []User) ([]User, error)
+	Update(ctx context.Context, user User) (User, error)
+	FindByID(ctx context.Context, id uint) (User, error)
+	FindByIDUnscoped(ctx context.Context, id uint) (User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
+	MarkEmailVerified(ctx context.Context, id uint, email string, at time.Time) (bool, error)
+	List(ctx context.Context, filter ListFilter, page PageRequest) (ListResult, error)
+	Each(ctx context.Context, filter ListFilter, fn func(user User) error) error
+	Delete(ctx context.Context, user User) error
+	Restore(ctx context.Context, user User) (User, error)
+	Purge(ctx context.Context, user User) error
+	Anonymize(ctx context.Context, user User, at time.Time) error
+	WithTx(tx *gorm.DB) Repository
 }
 
 type repository struct {
@@ -14,11 +34,246 @@ func NewRepository(db *gorm.DB) *repository {
 	return &repository{db}
 }
 
-func (r *repository) Save(user User) (User, error) {
-	err := r.db.Create(&user).Error
+// WithTx returns a repository whose queries run in the transaction tx
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{tx}
+}
+
+func (r *repository) Save(ctx context.Context, user User) (User, error) {
+	err := r.db.WithContext(ctx).Create(&user).Error
+	if err != nil {
+		return user, translateError(err)
+	}
+
+	return user, nil
+}
+
+// SaveBatch inserts all users in a single transaction; either every row is
+// saved or none is
+func (r *repository) SaveBatch(ctx context.Context, users []User) ([]User, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&users).Error
+	})
+	if err != nil {
+		return users, translateError(err)
+	}
+
+	return users, nil
+}
+
+// Update writes every field of an existing user back to the database and
+// increments its version. It returns ErrVersionMismatch if the stored version
+// is no longer the one the user was read with.
+func (r *repository) Update(ctx context.Context, user User) (User, error) {
+	version := user.Version
+	user.Version++
+	result := r.db.WithContext(ctx).Model(&user).Where("version = ?", version).Select("*").Updates(&user)
+	if result.Error != nil {
+		return user, translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return user, ErrVersionMismatch
+	}
+
+	return user, nil
+}
+
+// FindByID returns the user with the given ID, ignoring soft deleted rows
+func (r *repository) FindByID(ctx context.Context, id uint) (User, error) {
+	return r.findByID(r.db.WithContext(ctx), id)
+}
+
+// FindByIDUnscoped returns the user with the given ID, including soft deleted rows
+func (r *repository) FindByIDUnscoped(ctx context.Context, id uint) (User, error) {
+	return r.findByID(r.db.WithContext(ctx).Unscoped(), id)
+}
+
+// FindByEmail returns the active user with the given normalized email
+func (r *repository) FindByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, ErrUserNotFound
+	}
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// MarkEmailVerified records that the user confirmed the given email. It only
+// succeeds while that email is still the user's and not yet verified, and
+// reports whether a row was changed.
+func (r *repository) MarkEmailVerified(ctx context.Context, id uint, email string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND email = ? AND email_verified_at IS NULL", id, email).
+		Updates(map[string]interface{}{"email_verified_at": at, "version": gorm.Expr("version + 1")})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Delete soft deletes the user by setting DeletedAt. It returns
+// ErrVersionMismatch if the stored version is no longer the one the user was
+// read with.
+func (r *repository) Delete(ctx context.Context, user User) error {
+	result := r.db.WithContext(ctx).Where("version = ?", user.Version).Delete(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// Restore clears DeletedAt on a soft deleted user and increments its version
+func (r *repository) Restore(ctx context.Context, user User) (User, error) {
+	err := r.db.WithContext(ctx).Unscoped().Model(&user).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
 	if err != nil {
 		return user, err
 	}
+	user.Version++
 
 	return user, nil
 }
+
+// Purge permanently removes the user row
+func (r *repository) Purge(ctx context.Context, user User) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&user).Error
+}
+
+// Anonymize clears the personal data of the user row and soft deletes it
+// at the given time unless it already is. The row itself stays, so
+// references to the user's ID remain valid.
+func (r *repository) Anonymize(ctx context.Context, user User, at time.Time) error {
+	columns := map[string]interface{}{
+		"name":                 "",
+		"email":                nil,
+		"password_hash":        "",
+		"email_verified_at":    nil,
+		"avatar_key":           "",
+		"avatar_thumbnail_key": "",
+		"version":              gorm.Expr("version + 1"),
+	}
+	if !user.DeletedAt.Valid {
+		columns["deleted_at"] = at
+	}
+	return r.db.WithContext(ctx).Unscoped().Model(&user).Updates(columns).Error
+}
+
+// List returns one page of users matching the filter together with the total
+// number of matches. Pages are selected by offset, or by keyset when a cursor
+// is given, and a cursor for the following page is returned when there is one.
+func (r *repository) List(ctx context.Context, filter ListFilter, page PageRequest) (ListResult, error) {
+	var result ListResult
+
+	base := func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&User{}).Scopes(filter.scope)
+	}
+
+	if err := base().Count(&result.Total).Error; err != nil {
+		return result, err
+	}
+
+	column := filter.sortColumn()
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := base()
+	if page.Cursor != "" {
+		after, err := decodeCursor(page.Cursor, filter.sortKey())
+		if err != nil {
+			return result, err
+		}
+		value, err := filter.cursorArg(after.Value)
+		if err != nil {
+			return result, err
+		}
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, comparison),
+			value, value, after.ID,
+		)
+	} else {
+		query = query.Offset(page.Offset)
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	err := query.
+		Order(column + " " + direction).
+		Order("id " + direction).
+		Limit(page.Limit + 1).
+		Find(&result.Users).Error
+	if err != nil {
+		return result, err
+	}
+
+	if len(result.Users) > page.Limit {
+		result.Users = result.Users[:page.Limit]
+		last := result.Users[len(result.Users)-1]
+		result.NextCursor = encodeCursor(cursor{
+			Sort:  filter.sortKey(),
+			Value: filter.cursorValue(last),
+			ID:    last.ID,
+		})
+	}
+
+	return result, nil
+}
+
+// Each calls fn for every user matching the filter, in sort order. Rows are
+// read through a database cursor one at a time, so the full result set is
+// never loaded into memory. Iteration stops at the first error returned by fn.
+func (r *repository) Each(ctx context.Context, filter ListFilter, fn func(user User) error) error {
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	rows, err := r.db.WithContext(ctx).Model(&User{}).
+		Scopes(filter.scope).
+		Order(filter.sortColumn() + " " + direction).
+		Order("id " + direction).
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user User
+		if err := r.db.WithContext(ctx).ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *repository) findByID(db *gorm.DB, id uint) (User, error) {
+	var user User
+	err := db.First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, ErrUserNotFound
+	}
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// translateError maps database constraint errors to user module errors. The
+// only unique constraint on users besides the primary key is the email index.
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,169 @@
+package user
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDatabases returns the databases the repository tests run on: a SQLite
+// file, plus PostgreSQL and MySQL when TEST_POSTGRES_HOST or TEST_MYSQL_HOST
+// and their _PORT, _USER, _PASSWORD and _DB companions are set. The tables
+// of those databases are dropped and recreated.
+func testDatabases(t *testing.T) map[string]config.DbConfigurations {
+	t.Helper()
+	databases := map[string]config.DbConfigurations{
+		config.DriverSQLite: {Driver: config.DriverSQLite, DbName: filepath.Join(t.TempDir(), "users.db")},
+	}
+	for driver, prefix := range map[string]string{config.DriverPostgres: "TEST_POSTGRES_", config.DriverMySQL: "TEST_MYSQL_"} {
+		if os.Getenv(prefix+"HOST") == "" {
+			continue
+		}
+		databases[driver] = config.DbConfigurations{
+			Driver:     driver,
+			DbHost:     os.Getenv(prefix + "HOST"),
+			DbPort:     os.Getenv(prefix + "PORT"),
+			DbUsername: os.Getenv(prefix + "USER"),
+			DbPassword: os.Getenv(prefix + "PASSWORD"),
+			DbName:     os.Getenv(prefix + "DB"),
+			DbSSL:      "disable",
+		}
+	}
+	return databases
+}
+
+// openTestDB connects to the database and migrates a fresh users table
+func openTestDB(t *testing.T, conf config.DbConfigurations) *gorm.DB {
+	t.Helper()
+	dialector, err := config.Dialector(conf)
+	if err != nil {
+		t.Fatalf("dialector: %v", err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.Migrator().DropTable(&User{}); err != nil {
+		t.Fatalf("drop users: %v", err)
+	}
+	if err := db.AutoMigrate(&User{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// seedUsers saves users created an hour apart, except Alicia and Bob who
+// share a creation time, and soft deletes Alfred. It returns the creation
+// time of the first user.
+func seedUsers(t *testing.T, repository *repository) time.Time {
+	t.Helper()
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local)
+	seeds := []struct {
+		name  string
+		hours int
+	}{
+		{"Alice", 0}, {"Alicia", 1}, {"Bob", 1}, {"Carol_1", 2}, {"Dave", 3}, {"Alfred", 4},
+	}
+	for _, seed := range seeds {
+		email := seed.name + "@example.com"
+		created := base.Add(time.Duration(seed.hours) * time.Hour)
+		saved, err := repository.Save(ctx, User{TenantID: 1, Name: seed.name, Email: &email, Model: gorm.Model{CreatedAt: created, UpdatedAt: created}})
+		if err != nil {
+			t.Fatalf("save %s: %v", seed.name, err)
+		}
+		if seed.name == "Alfred" {
+			if err := repository.Delete(ctx, saved); err != nil {
+				t.Fatalf("delete %s: %v", seed.name, err)
+			}
+		}
+	}
+	return base
+}
+
+// userNames returns the names of users in order
+func userNames(users []User) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	return names
+}
+
+func TestListFilters(t *testing.T) {
+	for name, conf := range testDatabases(t) {
+		t.Run(name, func(t *testing.T) {
+			repository := NewRepository(openTestDB(t, conf))
+			base := seedUsers(t, repository)
+			after := base.Add(30 * time.Minute)
+
+			cases := []struct {
+				name   string
+				filter ListFilter
+				want   []string
+			}{
+				{"name is matched without case", ListFilter{NameContains: "ALI"}, []string{"Alice", "Alicia"}},
+				{"wildcards are literal", ListFilter{NameContains: "l_"}, []string{"Carol_1"}},
+				{"created after", ListFilter{CreatedAfter: &after}, []string{"Alicia", "Bob", "Carol_1", "Dave"}},
+				{"deleted included", ListFilter{NameContains: "al", IncludeDeleted: true}, []string{"Alfred", "Alice", "Alicia"}},
+			}
+			for _, tc := range cases {
+				tc.filter.SortField = SortName
+				result, err := repository.List(context.Background(), tc.filter, PageRequest{Limit: 10})
+				if err != nil {
+					t.Errorf("%s: %v", tc.name, err)
+					continue
+				}
+				if got := userNames(result.Users); !reflect.DeepEqual(got, tc.want) || result.Total != int64(len(tc.want)) {
+					t.Errorf("%s: users = %v of %d, want %v", tc.name, got, result.Total, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestListCursorPaging(t *testing.T) {
+	for name, conf := range testDatabases(t) {
+		t.Run(name, func(t *testing.T) {
+			repository := NewRepository(openTestDB(t, conf))
+			seedUsers(t, repository)
+
+			cases := []struct {
+				filter ListFilter
+				want   []string
+			}{
+				{ListFilter{SortField: SortName}, []string{"Alice", "Alicia", "Bob", "Carol_1", "Dave"}},
+				// Ties on the creation time are broken by ID
+				{ListFilter{SortField: SortCreatedAt, Descending: true}, []string{"Dave", "Carol_1", "Bob", "Alicia", "Alice"}},
+			}
+			for _, tc := range cases {
+				var got []string
+				page := PageRequest{Limit: 2}
+				for pages := 0; pages < 5; pages++ {
+					result, err := repository.List(context.Background(), tc.filter, page)
+					if err != nil {
+						t.Fatalf("%s: page %d: %v", tc.filter.sortKey(), pages+1, err)
+					}
+					got = append(got, userNames(result.Users)...)
+					if result.NextCursor == "" {
+						break
+					}
+					page.Cursor = result.NextCursor
+				}
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("%s: pages = %v, want %v", tc.filter.sortKey(), got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,87 @@
 package user
 
+import "time"
+
 // AddUserForm represents the request data structure for adding a new user
 // @Description User registration request form
 type AddUserForm struct {
-	Name string `param:"name" query:"name" form:"name" json:"name" validate:"required" example:"John Doe"`
+	Name     string `param:"name" query:"name" form:"name" json:"name" validate:"required" example:"John Doe"`
+	Email    string `form:"email" json:"email" validate:"required,email,max=320" example:"john.doe@example.com"`
+	Password string `form:"password" json:"password" validate:"required,password" example:"Secr3tPassword"`
 }
 
 // NewAdduser creates a new instance of AddUserForm
 func NewAdduser() AddUserForm {
 	return AddUserForm{}
 }
+
+// UpdateUserForm represents the request data structure for replacing a user
+// @Description User full update request form
+type UpdateUserForm struct {
+	Name  string `form:"name" json:"name" validate:"required,max=250" example:"John Doe"`
+	Email string `form:"email" json:"email" validate:"required,email,max=320" example:"john.doe@example.com"`
+}
+
+// PatchUserForm represents the request data structure for partially updating a user.
+// Fields left out of the request body are not changed.
+// @Description User partial update request form
+type PatchUserForm struct {
+	Name  *string `form:"name" json:"name" validate:"omitempty,min=1,max=250" example:"John Doe"`
+	Email *string `form:"email" json:"email" validate:"omitempty,email,max=320" example:"john.doe@example.com"`
+}
+
+// UserFilterQuery represents the filter and sort query parameters shared by
+// user listing and export
+type UserFilterQuery struct {
+	Sort           string `query:"sort" validate:"omitempty,oneof=name -name created_at -created_at" example:"-created_at"`
+	NameContains   string `query:"name_contains" validate:"omitempty,max=250" example:"john"`
+	CreatedAfter   string `query:"created_after" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" example:"2025-06-01T00:00:00Z"`
+	IncludeDeleted bool   `query:"include_deleted"`
+}
+
+// Filter converts the query parameters into a ListFilter
+func (q UserFilterQuery) Filter() (ListFilter, error) {
+	filter := ListFilter{
+		NameContains:   q.NameContains,
+		IncludeDeleted: q.IncludeDeleted,
+	}
+
+	field, descending, err := ParseSort(q.Sort)
+	if err != nil {
+		return filter, err
+	}
+	filter.SortField = field
+	filter.Descending = descending
+
+	if q.CreatedAfter != "" {
+		createdAfter, err := time.Parse(time.RFC3339, q.CreatedAfter)
+		if err != nil {
+			return filter, err
+		}
+		filter.CreatedAfter = &createdAfter
+	}
+
+	return filter, nil
+}
+
+// ListUsersQuery represents the query parameters accepted when listing users
+// @Description User listing query parameters
+type ListUsersQuery struct {
+	UserFilterQuery
+	Page    int    `query:"page" validate:"omitempty,min=1,max=100000" example:"1"`
+	PerPage int    `query:"per_page" validate:"omitempty,min=1" example:"20"`
+	Cursor  string `query:"cursor"`
+}
+
+// Export formats accepted when exporting users
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// ExportUsersQuery represents the query parameters accepted when exporting users
+// @Description User export query parameters
+type ExportUsersQuery struct {
+	UserFilterQuery
+	Format string `query:"format" validate:"omitempty,oneof=csv ndjson" example:"csv"`
+}
@@ -1,25 +1,489 @@
 package user
 
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// passwordAttempts is how often SetPassword tries to save a new password
+// while other changes to the user keep moving its version on
+const passwordAttempts = 3
+
 type Service interface {
-	RegisterUser(input *AddUserForm) (User, error)
+	RegisterUser(ctx context.Context, input *AddUserForm) (User, error)
+	ImportUsers(ctx context.Context, decoder RowDecoder, validate func(i interface{}) error, dryRun bool) (ImportReport, error)
+	GetUserByID(ctx context.Context, id uint) (User, error)
+	ListUsers(ctx context.Context, filter ListFilter, page PageRequest) (ListResult, error)
+	ExportUsers(ctx context.Context, filter ListFilter, fn func(user User) error) error
+	UpdateUser(ctx context.Context, id, version uint, input *UpdateUserForm) (User, error)
+	PatchUser(ctx context.Context, id, version uint, input *PatchUserForm) (User, error)
+	SetAvatar(ctx context.Context, id, version uint, avatar Avatar) (User, error)
+	DeleteUser(ctx context.Context, id, version uint) error
+	RestoreUser(ctx context.Context, id uint) (User, error)
+	PurgeUser(ctx context.Context, id uint) error
+}
+
+// Auditor records the changes made to users in the audit log. Transaction
+// runs fn in a database transaction together with the events it records.
+type Auditor interface {
+	Transaction(ctx context.Context, fn func(tx *gorm.DB, recorder audit.Recorder) error) error
 }
 
 type service struct {
 	repository Repository
+	hasher     PasswordHasher
+	auditor    Auditor
+	dependents []Dependent
+	now        func() time.Time
+}
+
+func NewService(repository Repository, hasher PasswordHasher, auditor Auditor) *service {
+	return &service{repository: repository, hasher: hasher, auditor: auditor, now: time.Now}
+}
+
+// AddDependent makes soft deleting a user revoke what the dependent holds
+// for it and purging the user delete it, in the same transaction
+func (s *service) AddDependent(dependent Dependent) {
+	s.dependents = append(s.dependents, dependent)
+}
+
+func (s *service) RegisterUser(ctx context.Context, input *AddUserForm) (User, error) {
+	user, err := s.newUser(input)
+	if err != nil {
+		return user, err
+	}
+
+	err = s.transaction(ctx, func(_ *gorm.DB, repository Repository, recorder audit.Recorder) error {
+		user, err = repository.Save(ctx, user)
+		if err != nil {
+			return err
+		}
+		return record(ctx, recorder, audit.ActionCreate, user.ID, nil, &user)
+	})
+	return user, err
+}
+
+// ImportUsers validates every row produced by the decoder and saves the valid
+// ones in batches of ImportBatchSize, each batch in its own transaction. When a
+// batch fails, its rows are retried one by one so a single bad row does not
+// reject its neighbours. With dryRun set, rows are only validated.
+func (s *service) ImportUsers(ctx context.Context, decoder RowDecoder, validate func(i interface{}) error, dryRun bool) (ImportReport, error) {
+	report := ImportReport{
+		DryRun:   dryRun,
+		Accepted: []ImportAccepted{},
+		Rejected: []ImportRejected{},
+	}
+
+	rows := make([]int, 0, ImportBatchSize)
+	batch := make([]AddUserForm, 0, ImportBatchSize)
+	flush := func() error {
+		var err error
+		if len(batch) > 0 {
+			err = s.importBatch(ctx, &report, rows, batch, dryRun)
+		}
+		rows = rows[:0]
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		row, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+
+		report.Total++
+		if row.Err == nil {
+			row.Err = validate(&row.Form)
+		}
+		if row.Err != nil {
+			report.Rejected = append(report.Rejected, ImportRejected{Row: row.Row, Reason: row.Err.Error()})
+			continue
+		}
+
+		rows = append(rows, row.Row)
+		batch = append(batch, row.Form)
+		if len(batch) == ImportBatchSize {
+			if err = flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, flush()
+}
+
+// importBatch saves one batch of validated rows and records the outcome in the
+// report. Passwords are hashed in parallel since hashing dominates import time.
+// Each user is saved in the same transaction as its audit event, and only a
+// failure to record one is returned.
+func (s *service) importBatch(ctx context.Context, report *ImportReport, rows []int, batch []AddUserForm, dryRun bool) error {
+	if dryRun {
+		for i := range batch {
+			report.Accepted = append(report.Accepted, ImportAccepted{Row: rows[i], Name: batch[i].Name})
+		}
+		return nil
+	}
+
+	users := make([]User, len(batch))
+	hashErrs := make([]error, len(batch))
+	var group errgroup.Group
+	group.SetLimit(runtime.NumCPU())
+	for i := range batch {
+		group.Go(func() error {
+			users[i], hashErrs[i] = s.newUser(&batch[i])
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	hashedRows := make([]int, 0, len(users))
+	hashed := make([]User, 0, len(users))
+	for i, err := range hashErrs {
+		if err != nil {
+			report.Rejected = append(report.Rejected, ImportRejected{Row: rows[i], Reason: err.Error()})
+			continue
+		}
+		hashedRows = append(hashedRows, rows[i])
+		hashed = append(hashed, users[i])
+	}
+	if len(hashed) == 0 {
+		return nil
+	}
+
+	var saved []User
+	var saveErr error
+	err := s.transaction(ctx, func(_ *gorm.DB, repository Repository, recorder audit.Recorder) error {
+		saved, saveErr = repository.SaveBatch(ctx, hashed)
+		if saveErr != nil {
+			return saveErr
+		}
+		for i := range saved {
+			if err := record(ctx, recorder, audit.ActionCreate, saved[i].ID, nil, &saved[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		for i, user := range saved {
+			report.Accepted = append(report.Accepted, ImportAccepted{Row: hashedRows[i], ID: user.ID, Name: user.Name})
+		}
+		return nil
+	}
+	if saveErr == nil {
+		return err
+	}
+
+	for i, user := range hashed {
+		user.ID = 0
+		err := s.transaction(ctx, func(_ *gorm.DB, repository Repository, recorder audit.Recorder) error {
+			user, saveErr = repository.Save(ctx, user)
+			if saveErr != nil {
+				return saveErr
+			}
+			return record(ctx, recorder, audit.ActionCreate, user.ID, nil, &user)
+		})
+		if saveErr != nil {
+			report.Rejected = append(report.Rejected, ImportRejected{Row: hashedRows[i], Reason: saveErr.Error()})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		report.Accepted = append(report.Accepted, ImportAccepted{Row: hashedRows[i], ID: user.ID, Name: user.Name})
+	}
+	return nil
+}
+
+// GetUserByID returns an active user, or ErrUserNotFound if it is missing or soft deleted
+func (s *service) GetUserByID(ctx context.Context, id uint) (User, error) {
+	return s.repository.FindByID(ctx, id)
 }
 
-func NewService(repository Repository) *service {
-	return &service{repository}
+// ListUsers returns one page of users matching the filter
+func (s *service) ListUsers(ctx context.Context, filter ListFilter, page PageRequest) (ListResult, error) {
+	return s.repository.List(ctx, filter, page)
 }
 
-func (s *service) RegisterUser(input *AddUserForm) (User, error) {
-	user := User{}
+// ExportUsers streams every user matching the filter to fn, one at a time
+func (s *service) ExportUsers(ctx context.Context, filter ListFilter, fn func(user User) error) error {
+	return s.repository.Each(ctx, filter, fn)
+}
+
+// UpdateUser replaces all editable fields of an active user. It returns
+// ErrVersionMismatch unless the user is still at the given version.
+func (s *service) UpdateUser(ctx context.Context, id, version uint, input *UpdateUserForm) (User, error) {
+	user, err := s.findVersion(ctx, id, version)
+	if err != nil {
+		return user, err
+	}
+
+	before := user
 	user.Name = input.Name
+	user.setEmail(NormalizeEmail(input.Email))
+
+	return s.update(ctx, before, user)
+}
+
+// PatchUser changes only the fields present in the input. It returns
+// ErrVersionMismatch unless the user is still at the given version.
+func (s *service) PatchUser(ctx context.Context, id, version uint, input *PatchUserForm) (User, error) {
+	user, err := s.findVersion(ctx, id, version)
+	if err != nil {
+		return user, err
+	}
+
+	before := user
+	if input.Name != nil {
+		user.Name = *input.Name
+	}
+	if input.Email != nil {
+		user.setEmail(NormalizeEmail(*input.Email))
+	}
+
+	return s.update(ctx, before, user)
+}
+
+// SetAvatar points an active user at new avatar images, or at none when
+// avatar is empty. It returns ErrVersionMismatch unless the user is still at
+// the given version. Storing and deleting the images is up to the caller.
+func (s *service) SetAvatar(ctx context.Context, id, version uint, avatar Avatar) (User, error) {
+	user, err := s.findVersion(ctx, id, version)
+	if err != nil {
+		return user, err
+	}
+
+	before := user
+	user.Avatar = avatar
+
+	return s.update(ctx, before, user)
+}
+
+// CreateUser saves a user built by another module, such as an account made
+// from an identity provider login, and runs with in the same transaction so
+// the rows that module keeps for the new user are only kept together with it
+func (s *service) CreateUser(ctx context.Context, user User, with func(tx *gorm.DB, user User) error) (User, error) {
+	err := s.transaction(ctx, func(tx *gorm.DB, repository Repository, recorder audit.Recorder) error {
+		var err error
+		user, err = repository.Save(ctx, user)
+		if err != nil {
+			return err
+		}
+		if err = with(tx, user); err != nil {
+			return err
+		}
+		return record(ctx, recorder, audit.ActionCreate, user.ID, nil, &user)
+	})
+	return user, err
+}
+
+// SetPassword replaces the password of an active user and runs with in the
+// same transaction, so what the caller changes alongside is only kept
+// together with the new password. A user changed by someone else meanwhile
+// is read again, up to passwordAttempts times. The audit log only records
+// that the user changed, never the hash.
+func (s *service) SetPassword(ctx context.Context, id uint, password string, with func(tx *gorm.DB, user User) error) (User, error) {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		user, err := s.repository.FindByID(ctx, id)
+		if err != nil {
+			return user, err
+		}
+		before := user
+		user.PasswordHash = hash
+
+		err = s.transaction(ctx, func(tx *gorm.DB, repository Repository, recorder audit.Recorder) error {
+			var err error
+			user, err = repository.Update(ctx, user)
+			if err != nil {
+				return err
+			}
+			if err = with(tx, user); err != nil {
+				return err
+			}
+			return record(ctx, recorder, audit.ActionUpdate, user.ID, &before, &user)
+		})
+		if errors.Is(err, ErrVersionMismatch) && attempt < passwordAttempts {
+			continue
+		}
+		return user, err
+	}
+}
+
+// VerifyEmail records that the user confirmed the given email. It only
+// changes the user while that email is still theirs and not yet verified,
+// and reports whether it did. The user is returned as it stands afterwards.
+func (s *service) VerifyEmail(ctx context.Context, id uint, email string) (User, bool, error) {
+	var user User
+	var verified bool
+	err := s.transaction(ctx, func(_ *gorm.DB, repository Repository, recorder audit.Recorder) error {
+		before, err := repository.FindByID(ctx, id)
+		if err != nil {
+			user = before
+			return err
+		}
+		verified, err = repository.MarkEmailVerified(ctx, id, email, s.now())
+		if err != nil || !verified {
+			user = before
+			return err
+		}
+		if user, err = repository.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return record(ctx, recorder, audit.ActionUpdate, id, &before, &user)
+	})
+	return user, verified, err
+}
+
+// DeleteUser soft deletes an active user and revokes its refresh tokens,
+// sessions and API keys through its dependents. It returns
+// ErrVersionMismatch unless the user is still at the given version.
+func (s *service) DeleteUser(ctx context.Context, id, version uint) error {
+	user, err := s.findVersion(ctx, id, version)
+	if err != nil {
+		return err
+	}
+
+	at := s.now()
+	return s.transaction(ctx, func(tx *gorm.DB, repository Repository, recorder audit.Recorder) error {
+		if err := repository.Delete(ctx, user); err != nil {
+			return err
+		}
+		for _, dependent := range s.dependents {
+			if err := dependent.RevokeUser(ctx, tx, user.ID, at); err != nil {
+				return err
+			}
+		}
+		return record(ctx, recorder, audit.ActionDelete, user.ID, &user, nil)
+	})
+}
+
+// RestoreUser brings back a soft deleted user. It returns ErrUserNotDeleted
+// if the user is still active.
+func (s *service) RestoreUser(ctx context.Context, id uint) (User, error) {
+	user, err := s.repository.FindByIDUnscoped(ctx, id)
+	if err != nil {
+		return user, err
+	}
+
+	if !user.DeletedAt.Valid {
+		return user, ErrUserNotDeleted
+	}
+
+	var restored User
+	err = s.transaction(ctx, func(_ *gorm.DB, repository Repository, recorder audit.Recorder) error {
+		restored, err = repository.Restore(ctx, user)
+		if err != nil {
+			return err
+		}
+		return record(ctx, recorder, audit.ActionRestore, restored.ID, &user, &restored)
+	})
+	return restored, err
+}
+
+// PurgeUser permanently removes a user, whether or not it was soft deleted,
+// together with the rows its dependents keep for it
+func (s *service) PurgeUser(ctx context.Context, id uint) error {
+	user, err := s.repository.FindByIDUnscoped(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.transaction(ctx, func(tx *gorm.DB, repository Repository, recorder audit.Recorder) error {
+		for _, dependent := range s.dependents {
+			if err := dependent.DeleteUser(ctx, tx, user.ID); err != nil {
+				return err
+			}
+		}
+		if err := repository.Purge(ctx, user); err != nil {
+			return err
+		}
+		return record(ctx, recorder, audit.ActionPurge, user.ID, &user, nil)
+	})
+}
+
+// update saves a changed user and records the difference
+func (s *service) update(ctx context.Context, before, after User) (User, error) {
+	var updated User
+	err := s.transaction(ctx, func(_ *gorm.DB, repository Repository, recorder audit.Recorder) error {
+		var err error
+		updated, err = repository.Update(ctx, after)
+		if err != nil {
+			return err
+		}
+		return record(ctx, recorder, audit.ActionUpdate, updated.ID, &before, &updated)
+	})
+	return updated, err
+}
+
+// transaction runs fn in one database transaction with a repository and an
+// audit recorder bound to it, so a change to users is only kept together
+// with its audit event and the changes to its dependents
+func (s *service) transaction(ctx context.Context, fn func(tx *gorm.DB, repository Repository, recorder audit.Recorder) error) error {
+	return s.auditor.Transaction(ctx, func(tx *gorm.DB, recorder audit.Recorder) error {
+		return fn(tx, s.repository.WithTx(tx), recorder)
+	})
+}
+
+// record adds a change to a user to the audit log. Nil stands for the user
+// not existing before or after the change.
+func record(ctx context.Context, recorder audit.Recorder, action string, id uint, before, after *User) error {
+	change := audit.Change{Resource: AuditResource, ResourceID: id, Action: action}
+	if before != nil {
+		change.Before = auditFields(*before)
+	}
+	if after != nil {
+		change.After = auditFields(*after)
+	}
+	return recorder.Record(ctx, change)
+}
+
+// findVersion returns the active user with the given ID, or
+// ErrVersionMismatch if it has moved past the given version. The repository
+// checks the version again when writing, so changes racing this read are
+// caught as well.
+func (s *service) findVersion(ctx context.Context, id, version uint) (User, error) {
+	user, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	if user.Version != version {
+		return user, ErrVersionMismatch
+	}
+
+	return user, nil
+}
+
+// newUser builds a new user entity from a registration form, normalizing the
+// email and hashing the password
+func (s *service) newUser(input *AddUserForm) (User, error) {
+	email := NormalizeEmail(input.Email)
+	user := User{
+		Name:    input.Name,
+		Email:   &email,
+		Version: 1,
+	}
 
-	newUser, err := s.repository.Save(user)
+	hash, err := s.hasher.Hash(input.Password)
 	if err != nil {
-		return newUser, err
+		return user, err
 	}
+	user.PasswordHash = hash
 
-	return newUser, nil
+	return user, nil
 }
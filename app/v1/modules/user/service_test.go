@@ -0,0 +1,192 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// staleRepository reads users a version behind for its first stale reads,
+// as if someone else changed them right after
+type staleRepository struct {
+	Repository
+	stale int
+}
+
+func (r *staleRepository) FindByID(ctx context.Context, id uint) (User, error) {
+	user, err := r.Repository.FindByID(ctx, id)
+	if err == nil && r.stale > 0 {
+		r.stale--
+		user.Version--
+	}
+	return user, err
+}
+
+// newAuditedService returns a user service recording its changes in the
+// audit log of an in-memory database, and that database
+func newAuditedService(t *testing.T) (*service, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err = db.Use(tenancy.Plugin{}); err != nil {
+		t.Fatalf("register tenancy plugin: %v", err)
+	}
+	if err = db.AutoMigrate(&User{}, &audit.Event{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewService(NewRepository(db), NewBcryptHasher(4), audit.NewService(audit.NewRepository(db))), db
+}
+
+// auditEvents returns the audit events of the user in order
+func auditEvents(t *testing.T, db *gorm.DB, id uint) []audit.Event {
+	t.Helper()
+	var events []audit.Event
+	if err := db.WithContext(tenancy.AllTenants(context.Background())).Where("resource = ? AND resource_id = ?", AuditResource, id).Order("id").Find(&events).Error; err != nil {
+		t.Fatalf("find audit events: %v", err)
+	}
+	return events
+}
+
+func TestAccountChangesAreAudited(t *testing.T) {
+	users, db := newAuditedService(t)
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+
+	email := "road@acme.example.com"
+	created, err := users.CreateUser(ctx, User{Name: "Road Runner", Email: &email, Version: 1}, func(tx *gorm.DB, user User) error {
+		if user.ID == 0 {
+			return errors.New("user is not saved yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	changed, err := users.SetPassword(ctx, created.ID, "Secr3tPassword", func(*gorm.DB, User) error { return nil })
+	if err != nil {
+		t.Fatalf("set password: %v", err)
+	}
+	if changed.PasswordHash == "" || changed.Version != 2 {
+		t.Fatalf("set password: hash %q at version %d, want a hash at version 2", changed.PasswordHash, changed.Version)
+	}
+
+	verified, ok, err := users.VerifyEmail(ctx, created.ID, email)
+	if err != nil || !ok || !verified.EmailVerified() {
+		t.Fatalf("verify email: verified = %v, %v (%v); want the email verified", ok, verified.EmailVerified(), err)
+	}
+	if _, ok, err = users.VerifyEmail(ctx, created.ID, email); err != nil || ok {
+		t.Fatalf("verify email again: verified = %v (%v), want false", ok, err)
+	}
+
+	events := auditEvents(t, db, created.ID)
+	actions := make([]string, 0, len(events))
+	for _, event := range events {
+		actions = append(actions, event.Action)
+		if strings.Contains(event.Changes, changed.PasswordHash) {
+			t.Errorf("%s event holds the password hash: %s", event.Action, event.Changes)
+		}
+	}
+	if strings.Join(actions, ",") != "create,update,update" {
+		t.Fatalf("audit actions = %v, want create, update, update", actions)
+	}
+	if !strings.Contains(events[2].Changes, `"email_verified_at":{"before":null`) {
+		t.Errorf("verify event changes = %s, want email_verified_at set", events[2].Changes)
+	}
+}
+
+func TestCreateUserRollsBackWithItsCallback(t *testing.T) {
+	users, db := newAuditedService(t)
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+
+	email := "coyote@acme.example.com"
+	failed := errors.New("identity taken")
+	_, err := users.CreateUser(ctx, User{Name: "Wile E.", Email: &email, Version: 1}, func(*gorm.DB, User) error {
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("create: err = %v, want %v", err, failed)
+	}
+
+	if _, err = users.repository.FindByEmail(ctx, email); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("find user: err = %v, want ErrUserNotFound", err)
+	}
+	var count int64
+	db.WithContext(tenancy.AllTenants(ctx)).Model(&audit.Event{}).Count(&count)
+	if count != 0 {
+		t.Errorf("audit events = %d, want 0", count)
+	}
+}
+
+func TestSetPasswordRereadsAChangedUser(t *testing.T) {
+	users, _ := newAuditedService(t)
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+	created, err := users.RegisterUser(ctx, &AddUserForm{Name: "Road Runner", Email: "road@acme.example.com", Password: "OldPassw0rd"})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	// Move the user to version 2 so the stale reads see version 1
+	if _, err = users.UpdateUser(ctx, created.ID, created.Version, &UpdateUserForm{Name: "Roadrunner", Email: "road@acme.example.com"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		stale int
+		err   error
+		calls int
+	}{
+		{"read again after a change", passwordAttempts - 1, nil, 1},
+		{"changed on every attempt", passwordAttempts, ErrVersionMismatch, 0},
+	}
+	for _, tc := range cases {
+		stale := &staleRepository{Repository: users.repository, stale: tc.stale}
+		withStale := *users
+		withStale.repository = stale
+		calls := 0
+		_, err := withStale.SetPassword(ctx, created.ID, "NewPassw0rd", func(*gorm.DB, User) error {
+			calls++
+			return nil
+		})
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.err)
+		}
+		if calls != tc.calls {
+			t.Errorf("%s: callback ran %d times, want %d", tc.name, calls, tc.calls)
+		}
+	}
+}
+
+func TestSetPasswordRollsBackWithItsCallback(t *testing.T) {
+	users, _ := newAuditedService(t)
+	ctx := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 1, Slug: "acme"})
+	created, err := users.RegisterUser(ctx, &AddUserForm{Name: "Road Runner", Email: "road@acme.example.com", Password: "OldPassw0rd"})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	failed := errors.New("token already used")
+	if _, err = users.SetPassword(ctx, created.ID, "NewPassw0rd", func(*gorm.DB, User) error { return failed }); !errors.Is(err, failed) {
+		t.Fatalf("set password: err = %v, want %v", err, failed)
+	}
+
+	stored, err := users.GetUserByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	if stored.PasswordHash != created.PasswordHash || stored.Version != created.Version {
+		t.Errorf("user changed to version %d, want the old password at version %d", stored.Version, created.Version)
+	}
+}
@@ -0,0 +1,14 @@
+package verification
+
+import "errors"
+
+var (
+	// ErrInvalidToken is returned for malformed, tampered or expired tokens and
+	// for tokens issued for an email the user no longer has
+	ErrInvalidToken = errors.New("invalid or expired verification token")
+	// ErrAlreadyVerified is returned when the email is already verified, which
+	// includes using a verification token a second time
+	ErrAlreadyVerified = errors.New("email is already verified")
+	// ErrNoEmail is returned when the user has no email to verify
+	ErrNoEmail = errors.New("user has no email address")
+)
@@ -0,0 +1,151 @@
+// Package verification confirms that users own the email address they
+// registered with by mailing them a signed, single-use link
+package verification
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/internal/mailer"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// templateName is the email template used for verification messages
+const templateName = "verify_email"
+
+// Options configures verification tokens and the emailed link
+type Options struct {
+	SecretKey   string
+	TTL         time.Duration
+	VerifyURL   string
+	ServiceName string
+}
+
+// emailData is passed to the verification email templates
+type emailData struct {
+	Name        string
+	Email       string
+	VerifyURL   string
+	ExpiresIn   string
+	ServiceName string
+}
+
+type Service interface {
+	SendVerification(ctx context.Context, u user.User) error
+	Resend(ctx context.Context, userID uint) error
+	Verify(ctx context.Context, token string) (user.User, error)
+	EmailVerified(ctx context.Context, userID uint) (bool, error)
+}
+
+// Accounts marks emails verified, recording the change in the audit log
+type Accounts interface {
+	VerifyEmail(ctx context.Context, id uint, email string) (user.User, bool, error)
+}
+
+type service struct {
+	users     user.Repository
+	accounts  Accounts
+	mailer    mailer.Mailer
+	templates *mailer.Templates
+	signer    *tokenSigner
+	opts      Options
+}
+
+func NewService(users user.Repository, accounts Accounts, m mailer.Mailer, templates *mailer.Templates, opts Options) (*service, error) {
+	if opts.SecretKey == "" {
+		return nil, errors.New("secret key is required to sign verification tokens")
+	}
+
+	return &service{
+		users:     users,
+		accounts:  accounts,
+		mailer:    m,
+		templates: templates,
+		signer:    newTokenSigner(opts.SecretKey, opts.TTL, time.Now),
+		opts:      opts,
+	}, nil
+}
+
+// SendVerification emails the user a link confirming their current address
+func (s *service) SendVerification(ctx context.Context, u user.User) error {
+	email := u.EmailAddress()
+	if email == "" {
+		return ErrNoEmail
+	}
+	if u.EmailVerified() {
+		return ErrAlreadyVerified
+	}
+
+	token, err := s.signer.sign(u.ID, email)
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(tenancy.ExpandURL(ctx, s.opts.VerifyURL))
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	msg, err := s.templates.Render(templateName, emailData{
+		Name:        u.Name,
+		Email:       email,
+		VerifyURL:   link.String(),
+		ExpiresIn:   mailer.FormatDuration(s.opts.TTL),
+		ServiceName: s.opts.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = email
+
+	return s.mailer.Send(msg)
+}
+
+// Resend mails a fresh verification link to an unverified user
+func (s *service) Resend(ctx context.Context, userID uint) error {
+	u, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.SendVerification(ctx, u)
+}
+
+// Verify marks the email in the token as verified. A token only works once,
+// and only while the email it was sent to is still the user's address.
+func (s *service) Verify(ctx context.Context, token string) (user.User, error) {
+	userID, email, err := s.signer.parse(token)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	u, verified, err := s.accounts.VerifyEmail(ctx, userID, email)
+	if errors.Is(err, user.ErrUserNotFound) {
+		return u, ErrInvalidToken
+	}
+	if err != nil {
+		return u, err
+	}
+	if !verified {
+		if u.EmailAddress() == email && u.EmailVerified() {
+			return u, ErrAlreadyVerified
+		}
+		return u, ErrInvalidToken
+	}
+
+	return u, nil
+}
+
+// EmailVerified reports whether the user has confirmed their current email
+func (s *service) EmailVerified(ctx context.Context, userID uint) (bool, error) {
+	u, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return u.EmailVerified(), nil
+}
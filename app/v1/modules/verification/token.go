@@ -0,0 +1,73 @@
+package verification
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenAudience marks verification tokens so they are never mistaken for
+// other signed tokens
+const tokenAudience = "email-verification"
+
+// tokenClaims binds a verification token to a user and the email it was sent to
+type tokenClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// tokenSigner signs and checks verification tokens with a key derived from
+// the application secret, so they cannot be replayed as access tokens even
+// when those are signed with the same secret
+type tokenSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+func newTokenSigner(secret string, ttl time.Duration, now func() time.Time) *tokenSigner {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(tokenAudience))
+	return &tokenSigner{key: mac.Sum(nil), ttl: ttl, now: now}
+}
+
+// sign issues a token for the user's current email
+func (s *tokenSigner) sign(userID uint, email string) (string, error) {
+	now := s.now()
+	claims := tokenClaims{
+		Email: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{tokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+}
+
+// parse checks the signature and expiry and returns the user and email
+func (s *tokenSigner) parse(token string) (uint, string, error) {
+	claims := new(tokenClaims)
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(tokenAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 || claims.Email == "" {
+		return 0, "", errors.Join(ErrInvalidToken, err)
+	}
+	return uint(userID), claims.Email, nil
+}
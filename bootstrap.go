@@ -1,9 +1,24 @@
 package main
 
 import (
+	"context"
+
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/apikey"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/identity"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/lockout"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/passwordreset"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/refreshtoken"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/session"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/tenant"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/twofactor"
 	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
 	"github.com/ranggaaprilio/boilerGo/config"
+	"github.com/ranggaaprilio/boilerGo/internal/idempotency"
 	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"gorm.io/gorm"
 )
 
 // Bootstrap initializes the application's database and performs necessary migrations
@@ -23,13 +38,80 @@ func Bootstrap() error {
 	bootstrapLogger.Info("Running database migrations...")
 
 	// Run migrations for all models
+	if err := db.AutoMigrate(&tenant.Tenant{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate Tenant model", "error", err)
+		return err
+	}
+
+	if err := dropGlobalConstraints(db); err != nil {
+		bootstrapLogger.Error("Failed to drop constraints replaced by tenancy", "error", err)
+		return err
+	}
+
 	if err := db.AutoMigrate(&user.User{}); err != nil {
 		bootstrapLogger.Error("Failed to migrate User model", "error", err)
 		return err
 	}
 
+	if err := db.AutoMigrate(&refreshtoken.RefreshToken{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate RefreshToken model", "error", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&session.Session{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate Session model", "error", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&identity.Identity{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate Identity model", "error", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&passwordreset.PasswordResetToken{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate PasswordResetToken model", "error", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&twofactor.TwoFactor{}, &twofactor.RecoveryCode{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate two-factor models", "error", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&lockout.LoginAttempt{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate LoginAttempt model", "error", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&rbac.Permission{}, &rbac.Role{}, &rbac.UserRole{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate RBAC models", "error", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&apikey.APIKey{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate APIKey model", "error", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&idempotency.Record{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate idempotency Record model", "error", err)
+		return err
+	}
+
+	// Audit events are always written with a tenant, so they are not in
+	// tenantScopedModels; reassigning them would break their hash chain
+	if err := db.AutoMigrate(&audit.Event{}); err != nil {
+		bootstrapLogger.Error("Failed to migrate audit Event model", "error", err)
+		return err
+	}
+
 	bootstrapLogger.Info("Database migrations completed successfully")
 
+	if err := assignDefaultTenant(db, config.Loadconf().Tenancy.DefaultTenant, bootstrapLogger); err != nil {
+		bootstrapLogger.Error("Failed to assign rows to the default tenant", "error", err)
+		return err
+	}
+
 	// Add any seed data or additional bootstrap logic here
 	if err := seedData(db, bootstrapLogger); err != nil {
 		bootstrapLogger.Error("Failed to seed data", "error", err)
@@ -41,13 +123,79 @@ func Bootstrap() error {
 }
 
 // seedData adds initial data to the database if needed
-func seedData(db interface{}, logger *appLogger.LogrusLogger) error {
+func seedData(db *gorm.DB, logger *appLogger.LogrusLogger) error {
 	// Add any initial data seeding logic here
 	logger.Info("Checking for seed data requirements...")
 
-	// Example: Create default admin user, default settings, etc.
-	// This is where you'd add any initial data your application needs
+	// Create the known permissions and the built-in roles
+	rbacService := rbac.NewService(rbac.NewRepository(db), user.NewRepository(db))
+	if err := rbacService.EnsureDefaults(context.Background()); err != nil {
+		return err
+	}
 
 	logger.Info("Seed data check completed")
 	return nil
 }
+
+// tenantScopedModels are the models whose rows belong to a tenant
+var tenantScopedModels = []interface{}{
+	&user.User{},
+	&refreshtoken.RefreshToken{},
+	&session.Session{},
+	&identity.Identity{},
+	&passwordreset.PasswordResetToken{},
+	&twofactor.TwoFactor{},
+	&twofactor.RecoveryCode{},
+	&rbac.UserRole{},
+	&apikey.APIKey{},
+}
+
+// dropGlobalConstraints removes what per-tenant constraints replaced: the
+// unique indexes on user emails and identity subjects across all tenants,
+// and login counters keyed without a tenant. Counters only live for the
+// login protection window, so they are dropped rather than migrated.
+func dropGlobalConstraints(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if migrator.HasIndex(&user.User{}, "idx_users_email") {
+		if err := migrator.DropIndex(&user.User{}, "idx_users_email"); err != nil {
+			return err
+		}
+	}
+	if migrator.HasIndex(&identity.Identity{}, "idx_identity_subject") {
+		if err := migrator.DropIndex(&identity.Identity{}, "idx_identity_subject"); err != nil {
+			return err
+		}
+	}
+	if migrator.HasTable(&lockout.LoginAttempt{}) && !migrator.HasColumn(&lockout.LoginAttempt{}, "TenantID") {
+		return migrator.DropTable(&lockout.LoginAttempt{})
+	}
+	return nil
+}
+
+// assignDefaultTenant creates the default tenant and gives it the rows that
+// have no tenant, such as those created before tenancy was introduced
+func assignDefaultTenant(db *gorm.DB, slug string, logger *appLogger.LogrusLogger) error {
+	if slug == "" {
+		logger.Info("No default tenant configured, rows without a tenant stay unassigned")
+		return nil
+	}
+
+	ctx := tenancy.AllTenants(context.Background())
+	defaultTenant, err := tenant.NewService(tenant.NewRepository(db)).Ensure(ctx, slug, "Default")
+	if err != nil {
+		return err
+	}
+
+	for _, model := range tenantScopedModels {
+		result := db.WithContext(ctx).Unscoped().Model(model).
+			Where("tenant_id = ?", 0).
+			UpdateColumn("tenant_id", defaultTenant.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			logger.Info("Assigned rows to the default tenant", "table", result.Statement.Table, "count", result.RowsAffected)
+		}
+	}
+	return nil
+}
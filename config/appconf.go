@@ -2,18 +2,26 @@ package config
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
+	"time"
 
 	"github.com/ranggaaprilio/boilerGo/exception"
 	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
 	"github.com/spf13/viper"
 )
 
 // Configurations represents the main configuration structure
 type Configurations struct {
-	Server   ServerConfigurations `mapstructure:"server" validate:"required"`
-	Database DbConfigurations     `mapstructure:"database" validate:"required"`
-	App      AppConfigurations    `mapstructure:"app"`
+	Server      ServerConfigurations      `mapstructure:"server" validate:"required"`
+	Database    DbConfigurations          `mapstructure:"database" validate:"required"`
+	App         AppConfigurations         `mapstructure:"app"`
+	Auth        AuthConfigurations        `mapstructure:"auth"`
+	Mail        MailConfigurations        `mapstructure:"mail"`
+	Storage     StorageConfigurations     `mapstructure:"storage"`
+	Tenancy     TenancyConfigurations     `mapstructure:"tenancy"`
+	Idempotency IdempotencyConfigurations `mapstructure:"idempotency"`
 }
 
 // ServerConfigurations holds server-related settings
@@ -27,20 +35,207 @@ type ServerConfigurations struct {
 
 // DbConfigurations holds database-related settings
 type DbConfigurations struct {
+	// Driver is "mysql", "postgres" or "sqlite"
+	Driver     string `mapstructure:"driver" default:"mysql"`
 	DbUsername string `mapstructure:"dbusername" validate:"required"`
 	DbPassword string `mapstructure:"dbpassword" validate:"required"`
 	DbHost     string `mapstructure:"dbhost" validate:"required"`
 	DbPort     string `mapstructure:"dbport" validate:"required"`
-	DbName     string `mapstructure:"dbname" validate:"required"`
-	DbSSL      string `mapstructure:"dbssl" default:"disable"`
+	// DbName is the database, or for SQLite the database file or ":memory:"
+	DbName string `mapstructure:"dbname" validate:"required"`
+	// DbSSL is a PostgreSQL sslmode: "disable", "allow", "prefer",
+	// "require", "verify-ca" or "verify-full". MySQL connections use the
+	// matching tls setting. SQLite ignores it.
+	DbSSL string `mapstructure:"dbssl" default:"disable"`
 }
 
 // AppConfigurations holds general application settings
 type AppConfigurations struct {
-	LogLevel    string `mapstructure:"log_level" default:"info"`
-	Debug       bool   `mapstructure:"debug" default:"false"`
-	SecretKey   string `mapstructure:"secret_key"`
-	ServiceName string `mapstructure:"service_name" default:"BoilerGo"`
+	LogLevel    string                   `mapstructure:"log_level" default:"info"`
+	Debug       bool                     `mapstructure:"debug" default:"false"`
+	SecretKey   string                   `mapstructure:"secret_key"`
+	ServiceName string                   `mapstructure:"service_name" default:"BoilerGo"`
+	AdminToken  string                   `mapstructure:"admin_token"`
+	Pagination  PaginationConfigurations `mapstructure:"pagination"`
+}
+
+// PaginationConfigurations holds list endpoint paging limits
+type PaginationConfigurations struct {
+	DefaultPageSize int `mapstructure:"default_page_size" default:"20"`
+	MaxPageSize     int `mapstructure:"max_page_size" default:"100"`
+}
+
+// AuthConfigurations holds authentication and credential settings
+type AuthConfigurations struct {
+	BcryptCost     int           `mapstructure:"bcrypt_cost" default:"12"`
+	Issuer         string        `mapstructure:"issuer" default:"boilergo"`
+	AccessTokenTTL time.Duration `mapstructure:"access_token_ttl" default:"15m"`
+	SigningMethod  string        `mapstructure:"signing_method" default:"HS256"`
+	PrivateKeyFile string        `mapstructure:"private_key_file"`
+	PublicKeyFile  string        `mapstructure:"public_key_file"`
+	// RefreshTokenTTL is how long a refresh token stays usable after it is issued
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl" default:"720h"`
+	// RefreshCleanupInterval is how often expired refresh tokens are deleted
+	RefreshCleanupInterval time.Duration `mapstructure:"refresh_cleanup_interval" default:"1h"`
+	// VerificationTokenTTL is how long an email verification link stays valid
+	VerificationTokenTTL time.Duration `mapstructure:"verification_token_ttl" default:"24h"`
+	// VerificationURL is the link sent in verification emails; the token is
+	// appended as the token query parameter and {tenant} is replaced with the
+	// user's tenant
+	VerificationURL string `mapstructure:"verification_url" default:"http://localhost:8080/api/v1/auth/verify"`
+	// PasswordResetTokenTTL is how long a password reset link stays valid
+	PasswordResetTokenTTL time.Duration `mapstructure:"password_reset_token_ttl" default:"1h"`
+	// PasswordResetURL is the page linked from password reset emails; the token
+	// is appended as the token query parameter and {tenant} is replaced with
+	// the user's tenant
+	PasswordResetURL string `mapstructure:"password_reset_url" default:"http://localhost:3000/reset-password"`
+	// TwoFactorChallengeTTL is how long a user has to enter their two-factor
+	// code after the password step of a login
+	TwoFactorChallengeTTL time.Duration `mapstructure:"two_factor_challenge_ttl" default:"5m"`
+	// LoginProtection throttles repeated failed logins
+	LoginProtection LoginProtectionConfigurations `mapstructure:"login_protection"`
+	// Session configures cookie sessions for server-rendered pages
+	Session SessionConfigurations `mapstructure:"session"`
+	// OIDC configures login through external OpenID Connect providers
+	OIDC OIDCConfigurations `mapstructure:"oidc"`
+}
+
+// OIDCConfigurations holds the OpenID Connect providers users can log in
+// with and the settings shared by their login flows
+type OIDCConfigurations struct {
+	// StateTTL is how long the user has to finish logging in at the provider
+	StateTTL time.Duration `mapstructure:"state_ttl" default:"10m"`
+	// PostLoginURL is where the browser is sent once a provider login or
+	// account link completes
+	PostLoginURL string                       `mapstructure:"post_login_url" default:"/"`
+	Providers    []OIDCProviderConfigurations `mapstructure:"providers"`
+}
+
+// OIDCProviderConfigurations holds the client registration at one OpenID
+// Connect provider
+type OIDCProviderConfigurations struct {
+	// Name identifies the provider in URLs and linked identities, so it must
+	// not change once users have linked accounts
+	Name        string `mapstructure:"name"`
+	DisplayName string `mapstructure:"display_name"`
+	// Issuer is the provider URL its discovery document is fetched from
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// RedirectURL is the callback registered at the provider, normally
+	// ending in /api/v1/auth/oidc/{name}/callback
+	RedirectURL string `mapstructure:"redirect_url"`
+	// Scopes requested besides openid; defaults to email and profile
+	Scopes []string `mapstructure:"scopes"`
+}
+
+// SessionConfigurations holds the settings of cookie-based server-side
+// sessions
+type SessionConfigurations struct {
+	// Store is "memory" for a single instance or "database" to share
+	// sessions between instances
+	Store        string `mapstructure:"store" default:"database"`
+	CookieName   string `mapstructure:"cookie_name" default:"boilergo_session"`
+	CookieDomain string `mapstructure:"cookie_domain"`
+	CookiePath   string `mapstructure:"cookie_path" default:"/"`
+	// Secure restricts the cookie to HTTPS; only disable it for local
+	// development over plain HTTP
+	Secure bool `mapstructure:"secure" default:"true"`
+	// SameSite is "lax", "strict" or "none"
+	SameSite string `mapstructure:"same_site" default:"lax"`
+	// IdleTimeout ends a session that has not been used for this long. Every
+	// request made with the session pushes its expiry back.
+	IdleTimeout time.Duration `mapstructure:"idle_timeout" default:"30m"`
+	// MaxLifetime ends a session this long after login however active it is
+	MaxLifetime time.Duration `mapstructure:"max_lifetime" default:"24h"`
+}
+
+// LoginProtectionConfigurations holds the brute-force protection thresholds
+// applied to failed logins, counted per account and per client IP
+type LoginProtectionConfigurations struct {
+	// Store is "memory" for a single instance or "database" to share
+	// counters between instances
+	Store string `mapstructure:"store" default:"database"`
+	// Window is how long a failed login is remembered
+	Window time.Duration `mapstructure:"window" default:"15m"`
+	// DelayAfter is the number of failures before attempts are slowed down
+	DelayAfter int `mapstructure:"delay_after" default:"3"`
+	// BaseDelay is the wait after the first delayed failure; it doubles with
+	// every further failure up to MaxDelay
+	BaseDelay time.Duration `mapstructure:"base_delay" default:"1s"`
+	MaxDelay  time.Duration `mapstructure:"max_delay" default:"1m"`
+	// AccountLockThreshold and IPLockThreshold are the failures after which
+	// logins are refused for LockDuration
+	AccountLockThreshold int           `mapstructure:"account_lock_threshold" default:"10"`
+	IPLockThreshold      int           `mapstructure:"ip_lock_threshold" default:"50"`
+	LockDuration         time.Duration `mapstructure:"lock_duration" default:"15m"`
+}
+
+// TenancyConfigurations holds how requests are matched to a tenant
+type TenancyConfigurations struct {
+	// Header carries the tenant slug, as in "X-Tenant: acme". Empty disables it.
+	Header string `mapstructure:"header" default:"X-Tenant"`
+	// BaseDomain enables subdomains: a request to acme.<base_domain> is for
+	// tenant acme. Empty disables it.
+	BaseDomain string `mapstructure:"base_domain"`
+	// DefaultTenant serves requests that name no tenant, and owns rows that
+	// existed before tenancy. Empty makes naming a tenant mandatory.
+	DefaultTenant string `mapstructure:"default_tenant" default:"default"`
+}
+
+// IdempotencyConfigurations holds how long POST requests retried with the
+// same Idempotency-Key are answered with the first response
+type IdempotencyConfigurations struct {
+	// Store is "memory" for a single instance or "database" to recognise
+	// retries reaching any instance
+	Store string `mapstructure:"store" default:"database"`
+	// TTL is how long a response is replayed for retries
+	TTL time.Duration `mapstructure:"ttl" default:"24h"`
+	// LockTimeout is how long a request may run before a retry of it is
+	// handled again rather than refused as in flight. It frees keys of
+	// requests cut short by a crash.
+	LockTimeout time.Duration `mapstructure:"lock_timeout" default:"1m"`
+}
+
+// MailConfigurations holds outgoing email settings
+type MailConfigurations struct {
+	// Driver is "file" to write messages to OutboxDir or "smtp" to send them
+	Driver       string `mapstructure:"driver" default:"file"`
+	From         string `mapstructure:"from" default:"BoilerGo <no-reply@example.com>"`
+	OutboxDir    string `mapstructure:"outbox_dir" default:"storage/outbox"`
+	TemplatesDir string `mapstructure:"templates_dir" default:"templates/email"`
+	SMTPHost     string `mapstructure:"smtp_host"`
+	SMTPPort     int    `mapstructure:"smtp_port" default:"587"`
+	SMTPUsername string `mapstructure:"smtp_username"`
+	SMTPPassword string `mapstructure:"smtp_password"`
+}
+
+// StorageConfigurations holds where uploaded files are kept and the limits
+// applied to uploads
+type StorageConfigurations struct {
+	// Driver is "local" to keep files in LocalDir or "s3" for an
+	// S3-compatible bucket
+	Driver   string           `mapstructure:"driver" default:"local"`
+	LocalDir string           `mapstructure:"local_dir" default:"storage/files"`
+	S3       S3Configurations `mapstructure:"s3"`
+	// MaxUploadSize is the largest accepted file in bytes
+	MaxUploadSize int64 `mapstructure:"max_upload_size" default:"5242880"`
+	// URLTTL is how long a signed download URL stays valid
+	URLTTL time.Duration `mapstructure:"url_ttl" default:"15m"`
+	// ThumbnailSize is the width and height of avatar thumbnails in pixels
+	ThumbnailSize int `mapstructure:"thumbnail_size" default:"128"`
+}
+
+// S3Configurations holds the bucket of an S3-compatible service such as
+// Amazon S3 or MinIO
+type S3Configurations struct {
+	// Endpoint is the host and port of the service, such as localhost:9000
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region" default:"us-east-1"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl" default:"true"`
 }
 
 // ConfigLoader handles configuration loading and validation
@@ -117,19 +312,64 @@ func (cl *ConfigLoader) setupViper() {
 // setupEnvironmentBindings maps environment variables to config keys
 func (cl *ConfigLoader) setupEnvironmentBindings() {
 	envMappings := map[string]string{
-		"server.name":         "SERVER_NAME",
-		"server.port":         "SERVER_PORT",
-		"server.environment":  "ENVIRONMENT",
-		"database.dbusername": "DB_USER",
-		"database.dbpassword": "DB_PASSWORD",
-		"database.dbhost":     "DB_HOST",
-		"database.dbport":     "DB_PORT",
-		"database.dbname":     "DB_NAME",
-		"database.dbssl":      "DB_SSL",
-		"app.log_level":       "LOG_LEVEL",
-		"app.debug":           "DEBUG",
-		"app.secret_key":      "SECRET_KEY",
-		"app.service_name":    "SERVICE_NAME",
+		"server.name":                      "SERVER_NAME",
+		"server.port":                      "SERVER_PORT",
+		"server.environment":               "ENVIRONMENT",
+		"database.driver":                  "DB_DRIVER",
+		"database.dbusername":              "DB_USER",
+		"database.dbpassword":              "DB_PASSWORD",
+		"database.dbhost":                  "DB_HOST",
+		"database.dbport":                  "DB_PORT",
+		"database.dbname":                  "DB_NAME",
+		"database.dbssl":                   "DB_SSL",
+		"app.log_level":                    "LOG_LEVEL",
+		"app.debug":                        "DEBUG",
+		"app.secret_key":                   "SECRET_KEY",
+		"app.service_name":                 "SERVICE_NAME",
+		"app.admin_token":                  "ADMIN_TOKEN",
+		"app.pagination.default_page_size": "DEFAULT_PAGE_SIZE",
+		"app.pagination.max_page_size":     "MAX_PAGE_SIZE",
+		"auth.bcrypt_cost":                 "BCRYPT_COST",
+		"auth.issuer":                      "AUTH_ISSUER",
+		"auth.access_token_ttl":            "ACCESS_TOKEN_TTL",
+		"auth.signing_method":              "JWT_SIGNING_METHOD",
+		"auth.private_key_file":            "JWT_PRIVATE_KEY_FILE",
+		"auth.public_key_file":             "JWT_PUBLIC_KEY_FILE",
+		"auth.refresh_token_ttl":           "REFRESH_TOKEN_TTL",
+		"auth.refresh_cleanup_interval":    "REFRESH_CLEANUP_INTERVAL",
+		"auth.verification_token_ttl":      "VERIFICATION_TOKEN_TTL",
+		"auth.verification_url":            "VERIFICATION_URL",
+		"auth.password_reset_token_ttl":    "PASSWORD_RESET_TOKEN_TTL",
+		"auth.password_reset_url":          "PASSWORD_RESET_URL",
+		"auth.two_factor_challenge_ttl":    "TWO_FACTOR_CHALLENGE_TTL",
+		"auth.login_protection.store":      "LOGIN_PROTECTION_STORE",
+		"auth.session.store":               "SESSION_STORE",
+		"auth.session.cookie_domain":       "SESSION_COOKIE_DOMAIN",
+		"auth.session.secure":              "SESSION_COOKIE_SECURE",
+		"auth.oidc.post_login_url":         "OIDC_POST_LOGIN_URL",
+		"tenancy.header":                   "TENANCY_HEADER",
+		"tenancy.base_domain":              "TENANCY_BASE_DOMAIN",
+		"tenancy.default_tenant":           "TENANCY_DEFAULT_TENANT",
+		"idempotency.store":                "IDEMPOTENCY_STORE",
+		"idempotency.ttl":                  "IDEMPOTENCY_TTL",
+		"mail.driver":                      "MAIL_DRIVER",
+		"mail.from":                        "MAIL_FROM",
+		"mail.outbox_dir":                  "MAIL_OUTBOX_DIR",
+		"mail.templates_dir":               "MAIL_TEMPLATES_DIR",
+		"mail.smtp_host":                   "SMTP_HOST",
+		"mail.smtp_port":                   "SMTP_PORT",
+		"mail.smtp_username":               "SMTP_USERNAME",
+		"mail.smtp_password":               "SMTP_PASSWORD",
+		"storage.driver":                   "STORAGE_DRIVER",
+		"storage.local_dir":                "STORAGE_LOCAL_DIR",
+		"storage.s3.endpoint":              "S3_ENDPOINT",
+		"storage.s3.region":                "S3_REGION",
+		"storage.s3.bucket":                "S3_BUCKET",
+		"storage.s3.access_key":            "S3_ACCESS_KEY",
+		"storage.s3.secret_key":            "S3_SECRET_KEY",
+		"storage.s3.use_ssl":               "S3_USE_SSL",
+		"storage.max_upload_size":          "STORAGE_MAX_UPLOAD_SIZE",
+		"storage.url_ttl":                  "STORAGE_URL_TTL",
 	}
 
 	for configKey, envVar := range envMappings {
@@ -146,12 +386,63 @@ func (cl *ConfigLoader) setDefaults() {
 	viper.SetDefault("server.read_timeout", 30)
 	viper.SetDefault("server.write_timeout", 30)
 	viper.SetDefault("server.environment", "development")
+	viper.SetDefault("database.driver", "mysql")
 	viper.SetDefault("database.dbssl", "disable")
 	viper.SetDefault("app.log_level", "info")
 	viper.SetDefault("app.debug", false)
 	viper.SetDefault("app.service_name", "BoilerGo")
+	viper.SetDefault("app.pagination.default_page_size", 20)
+	viper.SetDefault("app.pagination.max_page_size", 100)
+	viper.SetDefault("auth.bcrypt_cost", 12)
+	viper.SetDefault("auth.issuer", "boilergo")
+	viper.SetDefault("auth.access_token_ttl", "15m")
+	viper.SetDefault("auth.signing_method", "HS256")
+	viper.SetDefault("auth.refresh_token_ttl", "720h")
+	viper.SetDefault("auth.refresh_cleanup_interval", "1h")
+	viper.SetDefault("auth.verification_token_ttl", "24h")
+	viper.SetDefault("auth.verification_url", "http://localhost:8080/api/v1/auth/verify")
+	viper.SetDefault("auth.password_reset_token_ttl", "1h")
+	viper.SetDefault("auth.password_reset_url", "http://localhost:3000/reset-password")
+	viper.SetDefault("auth.two_factor_challenge_ttl", "5m")
+	viper.SetDefault("auth.login_protection.store", "database")
+	viper.SetDefault("auth.login_protection.window", "15m")
+	viper.SetDefault("auth.login_protection.delay_after", 3)
+	viper.SetDefault("auth.login_protection.base_delay", "1s")
+	viper.SetDefault("auth.login_protection.max_delay", "1m")
+	viper.SetDefault("auth.login_protection.account_lock_threshold", 10)
+	viper.SetDefault("auth.login_protection.ip_lock_threshold", 50)
+	viper.SetDefault("auth.login_protection.lock_duration", "15m")
+	viper.SetDefault("auth.session.store", "database")
+	viper.SetDefault("auth.session.cookie_name", "boilergo_session")
+	viper.SetDefault("auth.session.cookie_path", "/")
+	viper.SetDefault("auth.session.secure", true)
+	viper.SetDefault("auth.session.same_site", "lax")
+	viper.SetDefault("auth.session.idle_timeout", "30m")
+	viper.SetDefault("auth.session.max_lifetime", "24h")
+	viper.SetDefault("auth.oidc.state_ttl", "10m")
+	viper.SetDefault("auth.oidc.post_login_url", "/")
+	viper.SetDefault("tenancy.header", "X-Tenant")
+	viper.SetDefault("tenancy.default_tenant", "default")
+	viper.SetDefault("idempotency.store", "database")
+	viper.SetDefault("idempotency.ttl", "24h")
+	viper.SetDefault("idempotency.lock_timeout", "1m")
+	viper.SetDefault("mail.driver", "file")
+	viper.SetDefault("mail.from", "BoilerGo <no-reply@example.com>")
+	viper.SetDefault("mail.outbox_dir", "storage/outbox")
+	viper.SetDefault("mail.templates_dir", "templates/email")
+	viper.SetDefault("mail.smtp_port", 587)
+	viper.SetDefault("storage.driver", "local")
+	viper.SetDefault("storage.local_dir", "storage/files")
+	viper.SetDefault("storage.s3.region", "us-east-1")
+	viper.SetDefault("storage.s3.use_ssl", true)
+	viper.SetDefault("storage.max_upload_size", 5242880)
+	viper.SetDefault("storage.url_ttl", "15m")
+	viper.SetDefault("storage.thumbnail_size", 128)
 }
 
+// MinSecretKeyLength is the shortest app.secret_key accepted
+const MinSecretKeyLength = 32
+
 // validateConfiguration performs basic validation on the loaded configuration
 func (cl *ConfigLoader) validateConfiguration(config *Configurations) error {
 	// Validate server configuration
@@ -169,30 +460,218 @@ func (cl *ConfigLoader) validateConfiguration(config *Configurations) error {
 	}
 
 	// Validate database configuration
-	if config.Database.DbHost == "" {
+	if err := validateDatabase(config.Database); err != nil {
+		return err
+	}
+
+	// Validate pagination limits
+	if config.App.Pagination.DefaultPageSize < 1 || config.App.Pagination.MaxPageSize < config.App.Pagination.DefaultPageSize {
+		return fmt.Errorf("pagination max_page_size must be at least default_page_size, and both must be positive")
+	}
+
+	// Validate the application secret. Besides HS256 access tokens it keys
+	// verification and password reset tokens, two-factor challenges and
+	// secrets, OIDC state and download URLs, whatever the signing method.
+	if len(config.App.SecretKey) < MinSecretKeyLength {
+		return fmt.Errorf("app secret_key must be at least %d characters", MinSecretKeyLength)
+	}
+
+	// Validate token signing settings
+	switch config.Auth.SigningMethod {
+	case "HS256":
+	case "RS256", "EdDSA":
+		if config.Auth.PrivateKeyFile == "" {
+			return fmt.Errorf("auth private_key_file is required to sign tokens with %s", config.Auth.SigningMethod)
+		}
+	default:
+		return fmt.Errorf("auth signing_method must be HS256, RS256 or EdDSA")
+	}
+
+	if config.Auth.AccessTokenTTL <= 0 {
+		return fmt.Errorf("auth access_token_ttl must be positive")
+	}
+
+	if config.Auth.RefreshTokenTTL <= config.Auth.AccessTokenTTL {
+		return fmt.Errorf("auth refresh_token_ttl must be longer than access_token_ttl")
+	}
+
+	if config.Auth.RefreshCleanupInterval <= 0 {
+		return fmt.Errorf("auth refresh_cleanup_interval must be positive")
+	}
+
+	if config.Auth.VerificationTokenTTL <= 0 {
+		return fmt.Errorf("auth verification_token_ttl must be positive")
+	}
+
+	if config.Auth.PasswordResetTokenTTL <= 0 {
+		return fmt.Errorf("auth password_reset_token_ttl must be positive")
+	}
+
+	if config.Auth.TwoFactorChallengeTTL <= 0 {
+		return fmt.Errorf("auth two_factor_challenge_ttl must be positive")
+	}
+
+	// Validate login protection thresholds
+	protection := config.Auth.LoginProtection
+	if protection.Store != "memory" && protection.Store != "database" {
+		return fmt.Errorf("auth login_protection store must be memory or database, got %q", protection.Store)
+	}
+	if protection.Window <= 0 || protection.LockDuration <= 0 {
+		return fmt.Errorf("auth login_protection window and lock_duration must be positive")
+	}
+	if protection.DelayAfter < 1 || protection.AccountLockThreshold < 1 || protection.IPLockThreshold < 1 {
+		return fmt.Errorf("auth login_protection delay_after and lock thresholds must be at least 1")
+	}
+	if protection.BaseDelay <= 0 || protection.MaxDelay < protection.BaseDelay {
+		return fmt.Errorf("auth login_protection max_delay must be at least base_delay, and both must be positive")
+	}
+
+	session := config.Auth.Session
+	if session.Store != "memory" && session.Store != "database" {
+		return fmt.Errorf("auth session store must be memory or database, got %q", session.Store)
+	}
+	if session.CookieName == "" {
+		return fmt.Errorf("auth session cookie_name cannot be empty")
+	}
+	switch session.SameSite {
+	case "lax", "strict":
+	case "none":
+		if !session.Secure {
+			return fmt.Errorf("auth session same_site none requires secure cookies")
+		}
+	default:
+		return fmt.Errorf("auth session same_site must be lax, strict or none, got %q", session.SameSite)
+	}
+	if session.IdleTimeout <= 0 || session.MaxLifetime < session.IdleTimeout {
+		return fmt.Errorf("auth session max_lifetime must be at least idle_timeout, and both must be positive")
+	}
+
+	if err := validateOIDC(config.Auth.OIDC); err != nil {
+		return err
+	}
+
+	// Validate tenant resolution
+	tenancyConf := config.Tenancy
+	if tenancyConf.Header == "" && tenancyConf.BaseDomain == "" && tenancyConf.DefaultTenant == "" {
+		return fmt.Errorf("tenancy needs a header, base_domain or default_tenant to find the tenant of a request")
+	}
+	if tenancyConf.DefaultTenant != "" && !tenancy.ValidSlug(tenancyConf.DefaultTenant) {
+		return fmt.Errorf("tenancy default_tenant %q must be lowercase letters, digits and -", tenancyConf.DefaultTenant)
+	}
+
+	// Validate idempotency key handling
+	idempotency := config.Idempotency
+	if idempotency.Store != "memory" && idempotency.Store != "database" {
+		return fmt.Errorf("idempotency store must be memory or database, got %q", idempotency.Store)
+	}
+	if idempotency.LockTimeout <= 0 || idempotency.TTL < idempotency.LockTimeout {
+		return fmt.Errorf("idempotency ttl must be at least lock_timeout, and both must be positive")
+	}
+
+	// Validate mail settings
+	switch config.Mail.Driver {
+	case "file":
+		if config.Mail.OutboxDir == "" {
+			return fmt.Errorf("mail outbox_dir is required for the file driver")
+		}
+	case "smtp":
+		if config.Mail.SMTPHost == "" {
+			return fmt.Errorf("mail smtp_host is required for the smtp driver")
+		}
+	default:
+		return fmt.Errorf("mail driver must be file or smtp")
+	}
+
+	// Validate file storage settings
+	storage := config.Storage
+	switch storage.Driver {
+	case "local":
+		if storage.LocalDir == "" {
+			return fmt.Errorf("storage local_dir is required for the local driver")
+		}
+	case "s3":
+		if storage.S3.Endpoint == "" || storage.S3.Bucket == "" || storage.S3.AccessKey == "" || storage.S3.SecretKey == "" {
+			return fmt.Errorf("storage s3 endpoint, bucket, access_key and secret_key are required for the s3 driver")
+		}
+	default:
+		return fmt.Errorf("storage driver must be local or s3")
+	}
+	if storage.MaxUploadSize <= 0 || storage.URLTTL <= 0 || storage.ThumbnailSize <= 0 {
+		return fmt.Errorf("storage max_upload_size, url_ttl and thumbnail_size must be positive")
+	}
+
+	return nil
+}
+
+// validateDatabase checks the settings the configured database driver needs.
+// SQLite only needs the database file.
+func validateDatabase(conf DbConfigurations) error {
+	switch conf.Driver {
+	case DriverMySQL, DriverPostgres:
+	case DriverSQLite:
+		if conf.DbName == "" {
+			return fmt.Errorf("database name cannot be empty, it is the database file for sqlite")
+		}
+		return nil
+	default:
+		return fmt.Errorf("database driver must be mysql, postgres or sqlite, got %q", conf.Driver)
+	}
+
+	if conf.DbHost == "" {
 		return fmt.Errorf("database host cannot be empty")
 	}
 
-	if config.Database.DbPort == "" {
+	if conf.DbPort == "" {
 		return fmt.Errorf("database port cannot be empty")
 	}
 
-	if config.Database.DbUsername == "" {
+	if conf.DbUsername == "" {
 		return fmt.Errorf("database username cannot be empty")
 	}
 
-	if config.Database.DbName == "" {
+	if conf.DbName == "" {
 		return fmt.Errorf("database name cannot be empty")
 	}
 
+	if _, ok := sslModes[conf.DbSSL]; !ok {
+		return fmt.Errorf("database dbssl must be disable, allow, prefer, require, verify-ca or verify-full, got %q", conf.DbSSL)
+	}
+
 	// Validate database port is a valid number
-	if _, err := strconv.Atoi(config.Database.DbPort); err != nil {
+	if _, err := strconv.Atoi(conf.DbPort); err != nil {
 		return fmt.Errorf("database port must be a valid number: %v", err)
 	}
 
 	return nil
 }
 
+// oidcProviderName restricts provider names to what is safe in URL paths
+var oidcProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// validateOIDC checks the OpenID Connect settings and that provider names are
+// unique
+func validateOIDC(conf OIDCConfigurations) error {
+	if conf.StateTTL <= 0 {
+		return fmt.Errorf("auth oidc state_ttl must be positive")
+	}
+
+	seen := map[string]bool{}
+	for _, provider := range conf.Providers {
+		if !oidcProviderName.MatchString(provider.Name) {
+			return fmt.Errorf("auth oidc provider name %q must be lowercase letters, digits, - or _", provider.Name)
+		}
+		if seen[provider.Name] {
+			return fmt.Errorf("auth oidc provider %q is configured twice", provider.Name)
+		}
+		seen[provider.Name] = true
+
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return fmt.Errorf("auth oidc provider %q needs issuer, client_id and redirect_url", provider.Name)
+		}
+	}
+	return nil
+}
+
 // IsProduction returns true if the application is running in production mode
 func (c *Configurations) IsProduction() bool {
 	return c.Server.Environment == "production"
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSecretKeyIsRequiredWithEverySigningMethod(t *testing.T) {
+	for _, method := range []string{"HS256", "RS256", "EdDSA"} {
+		for _, secret := range []string{"", "too-short"} {
+			var conf Configurations
+			conf.Server.Name = "boilergo"
+			conf.Server.Port = "8080"
+			conf.Database = DbConfigurations{Driver: DriverSQLite, DbName: ":memory:"}
+			conf.App.Pagination = PaginationConfigurations{DefaultPageSize: 20, MaxPageSize: 100}
+			conf.App.SecretKey = secret
+			conf.Auth.SigningMethod = method
+			conf.Auth.PrivateKeyFile = "private.pem"
+
+			err := NewConfigLoader().validateConfiguration(&conf)
+			if err == nil || !strings.Contains(err.Error(), "secret_key") {
+				t.Errorf("%s with secret %q: error = %v, want secret_key to be refused", method, secret, err)
+			}
+		}
+	}
+}
@@ -6,7 +6,7 @@ import (
 
 	"github.com/ranggaaprilio/boilerGo/exception"
 	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
-	"gorm.io/driver/mysql"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -42,22 +42,22 @@ func DbInit() {
 
 	// Log connection attempt
 	dbLogger.Info("Attempting to connect to database",
+		"driver", conf.Database.Driver,
 		"host", conf.Database.DbHost,
 		"port", conf.Database.DbPort,
-		"user", conf.Database.DbUsername)
+		"user", conf.Database.DbUsername,
+		"name", conf.Database.DbName)
 
-	connectionString := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		conf.Database.DbUsername,
-		conf.Database.DbPassword,
-		conf.Database.DbHost,
-		conf.Database.DbPort,
-		conf.Database.DbName)
+	dialector, err := Dialector(conf.Database)
+	exception.PanicIfNeeded(err)
 
 	// Create a custom logrus writer for GORM
 	logrusWriter := &logrusGormWriter{logger: dbLogger}
 
 	// Configure GORM with custom logger
 	gormConfig := &gorm.Config{
+		// Report constraint violations as gorm.ErrDuplicatedKey and friends
+		TranslateError: true,
 		Logger: logger.New(
 			logrusWriter,
 			logger.Config{
@@ -71,8 +71,13 @@ func DbInit() {
 
 	// Retry loop for database connection
 	for i := 0; i < dbConfig.MaxRetries; i++ {
-		db, err = gorm.Open(mysql.Open(connectionString), gormConfig)
+		db, err = gorm.Open(dialector, gormConfig)
 		if err == nil {
+			// Scope every query on tenant owned models to the request's tenant
+			if err = db.Use(tenancy.Plugin{}); err != nil {
+				exception.PanicIfNeeded(fmt.Errorf("failed to register tenancy plugin: %w", err))
+			}
+
 			// Configure connection pool
 			if sqlDB, poolErr := db.DB(); poolErr == nil {
 				sqlDB.SetMaxIdleConns(dbConfig.MaxIdleConn)
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Database drivers selectable with database.driver
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sslModes are the accepted database.dbssl values, named after PostgreSQL's
+// sslmode, with the MySQL tls parameter each one maps to. MySQL cannot check
+// the certificate chain without the host name, so verify-ca verifies both.
+var sslModes = map[string]string{
+	"disable":     "false",
+	"allow":       "preferred",
+	"prefer":      "preferred",
+	"require":     "skip-verify",
+	"verify-ca":   "true",
+	"verify-full": "true",
+}
+
+// Dialector returns the GORM dialector of the configured database driver
+func Dialector(conf DbConfigurations) (gorm.Dialector, error) {
+	dsn, err := DSN(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	switch conf.Driver {
+	case DriverMySQL:
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	default:
+		return sqlite.Open(dsn), nil
+	}
+}
+
+// DSN builds the connection string of the configured database driver.
+// Credentials are escaped, so they may contain any character. For SQLite
+// dbname is the database file, or ":memory:" for a database that lives as
+// long as the process.
+func DSN(conf DbConfigurations) (string, error) {
+	switch conf.Driver {
+	case DriverMySQL:
+		tls, ok := sslModes[conf.DbSSL]
+		if !ok {
+			return "", fmt.Errorf("unknown database dbssl %q", conf.DbSSL)
+		}
+		mysqlConf := mysqlDriver.NewConfig()
+		mysqlConf.User = conf.DbUsername
+		mysqlConf.Passwd = conf.DbPassword
+		mysqlConf.Net = "tcp"
+		mysqlConf.Addr = net.JoinHostPort(conf.DbHost, conf.DbPort)
+		mysqlConf.DBName = conf.DbName
+		mysqlConf.ParseTime = true
+		mysqlConf.Loc = time.Local
+		mysqlConf.TLSConfig = tls
+		mysqlConf.Params = map[string]string{"charset": "utf8mb4"}
+		return mysqlConf.FormatDSN(), nil
+	case DriverPostgres:
+		if _, ok := sslModes[conf.DbSSL]; !ok {
+			return "", fmt.Errorf("unknown database dbssl %q", conf.DbSSL)
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(conf.DbUsername, conf.DbPassword),
+			Host:     net.JoinHostPort(conf.DbHost, conf.DbPort),
+			Path:     "/" + conf.DbName,
+			RawQuery: url.Values{"sslmode": {conf.DbSSL}}.Encode(),
+		}
+		return dsn.String(), nil
+	case DriverSQLite:
+		// Connections to a plain :memory: DSN each get their own empty
+		// database, so the pool shares one through the shared cache.
+		// Writers wait for each other instead of failing with "database is
+		// locked".
+		if conf.DbName == ":memory:" {
+			return "file::memory:?cache=shared&_pragma=busy_timeout(5000)", nil
+		}
+		return conf.DbName + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", nil
+	default:
+		return "", fmt.Errorf("unknown database driver %q", conf.Driver)
+	}
+}
@@ -1,6 +1,8 @@
 // Package helper provides utility functions and structures for the application
 package helper
 
+import "github.com/ranggaaprilio/boilerGo/internal/validation"
+
 // BadRequestResponse represents a standardized error response for bad requests
 type BadRequestResponse struct {
 	Code    int    `json:"code" example:"400"`
@@ -28,3 +30,80 @@ type NotFoundResponse struct {
 	Message string `json:"message" example:"Not Found"`
 	Data    string `json:"data,omitempty"`
 }
+
+// ConflictResponse represents a standardized error response for conflicts with the current resource state
+type ConflictResponse struct {
+	Code    int    `json:"code" example:"409"`
+	Message string `json:"message" example:"Conflict"`
+	Data    string `json:"data,omitempty"`
+}
+
+// ForbiddenResponse represents a standardized error response for requests lacking the required privileges
+type ForbiddenResponse struct {
+	Code    int    `json:"code" example:"403"`
+	Message string `json:"message" example:"Forbidden"`
+	Data    string `json:"data,omitempty"`
+}
+
+// UnsupportedMediaTypeResponse represents a standardized error response for request bodies in an unsupported format
+type UnsupportedMediaTypeResponse struct {
+	Code    int    `json:"code" example:"415"`
+	Message string `json:"message" example:"Unsupported Media Type"`
+	Data    string `json:"data,omitempty"`
+}
+
+// PayloadTooLargeResponse represents a standardized error response for uploads exceeding the size limit
+type PayloadTooLargeResponse struct {
+	Code    int    `json:"code" example:"413"`
+	Message string `json:"message" example:"Payload Too Large"`
+	Data    string `json:"data,omitempty"`
+}
+
+// UnauthorizedResponse represents a standardized error response for requests without valid credentials
+type UnauthorizedResponse struct {
+	Code    int    `json:"code" example:"401"`
+	Message string `json:"message" example:"Unauthorized"`
+	Data    string `json:"data,omitempty"`
+}
+
+// TooManyRequestsResponse represents a standardized error response for requests refused until a later time
+type TooManyRequestsResponse struct {
+	Code    int    `json:"code" example:"429"`
+	Message string `json:"message" example:"Too Many Requests"`
+	Data    string `json:"data,omitempty"`
+}
+
+// BadGatewayResponse represents a standardized error response for failures of an upstream service
+type BadGatewayResponse struct {
+	Code    int    `json:"code" example:"502"`
+	Message string `json:"message" example:"Bad Gateway"`
+	Data    string `json:"data,omitempty"`
+}
+
+// UnprocessableEntityResponse represents a standardized error response for well-formed requests that cannot be processed
+type UnprocessableEntityResponse struct {
+	Code    int    `json:"code" example:"422"`
+	Message string `json:"message" example:"Unprocessable Entity"`
+	Data    string `json:"data,omitempty"`
+}
+
+// ValidationErrorResponse represents a standardized error response for request data that fails validation
+type ValidationErrorResponse struct {
+	Code    int                     `json:"code" example:"422"`
+	Message string                  `json:"message" example:"Validation failed"`
+	Errors  []validation.FieldError `json:"errors,omitempty"`
+}
+
+// PreconditionFailedResponse represents a standardized error response for conditional requests whose precondition no longer holds
+type PreconditionFailedResponse struct {
+	Code    int    `json:"code" example:"412"`
+	Message string `json:"message" example:"Precondition Failed"`
+	Data    string `json:"data,omitempty"`
+}
+
+// PreconditionRequiredResponse represents a standardized error response for requests that must be made conditional
+type PreconditionRequiredResponse struct {
+	Code    int    `json:"code" example:"428"`
+	Message string `json:"message" example:"Precondition Required"`
+	Data    string `json:"data,omitempty"`
+}
@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PaginationMeta describes the page returned by a list endpoint
+type PaginationMeta struct {
+	Total      int64  `json:"total" example:"120"`
+	Page       int    `json:"page,omitempty" example:"1"`
+	PerPage    int    `json:"per_page" example:"20"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoiY3JlYXRlZF9hdCIsInYiOiIyMDI1LTA2LTE1VDEwOjAwOjAwWiIsImlkIjoyMH0"`
+}
+
+// PaginatedResponse represents a standardized success response for list endpoints
+type PaginatedResponse struct {
+	Code    int            `json:"code" example:"200"`
+	Message string         `json:"message" example:"Success"`
+	Data    interface{}    `json:"data"`
+	Meta    PaginationMeta `json:"meta"`
+}
+
+// Link is a single RFC 8288 web link
+type Link struct {
+	URL string
+	Rel string
+}
+
+// LinkHeader formats links as the value of an RFC 8288 Link header
+func LinkHeader(links []Link) string {
+	parts := make([]string, 0, len(links))
+	for _, link := range links {
+		parts = append(parts, fmt.Sprintf(`<%s>; rel="%s"`, link.URL, link.Rel))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/refreshtoken"
 	"github.com/ranggaaprilio/boilerGo/config"
 	"github.com/ranggaaprilio/boilerGo/internal/logger"
 	"github.com/ranggaaprilio/boilerGo/internal/server"
@@ -48,6 +49,11 @@ func (a *App) Start() error {
 	// Log startup information
 	a.logger.Info("Starting application")
 
+	// Start background jobs, stopped on shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	a.startJobs(jobsCtx)
+
 	// Start server in a goroutine
 	go func() {
 		address := ":" + a.config.Server.Port
@@ -64,6 +70,7 @@ func (a *App) Start() error {
 	<-quit
 
 	a.logger.Info("Shutdown signal received")
+	stopJobs()
 
 	// Give outstanding requests a deadline for completion
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -79,6 +86,15 @@ func (a *App) Start() error {
 	return nil
 }
 
+// startJobs launches the periodic maintenance jobs
+func (a *App) startJobs(ctx context.Context) {
+	refreshTokens := refreshtoken.NewService(
+		refreshtoken.NewRepository(config.CreateCon()),
+		a.config.Auth.RefreshTokenTTL,
+	)
+	go refreshtoken.RunCleanup(ctx, refreshTokens, a.config.Auth.RefreshCleanupInterval)
+}
+
 // GetServer returns the echo server instance
 func (a *App) GetServer() *echo.Echo {
 	return a.server
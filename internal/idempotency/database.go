@@ -0,0 +1,72 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// claimAttempts bounds how often Claim retries after replacing an expired
+// holder that another request replaced first
+const claimAttempts = 3
+
+// databaseStore keeps records in the idempotency_records table so retries
+// reaching another instance of the API are still recognised
+type databaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *databaseStore {
+	return &databaseStore{db}
+}
+
+// Claim relies on the unique index over tenant, owner and key: of several
+// concurrent inserts only one succeeds
+func (s *databaseStore) Claim(ctx context.Context, record *Record, now time.Time) (Record, bool, error) {
+	db := s.db.WithContext(ctx)
+	for i := 0; i < claimAttempts; i++ {
+		record.ID = 0
+		err := db.Create(record).Error
+		if err == nil {
+			return *record, true, nil
+		}
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return Record{}, false, err
+		}
+
+		var held Record
+		err = db.Where("owner = ? AND idempotency_key = ?", record.Owner, record.Key).First(&held).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Released between the insert and the lookup
+			continue
+		}
+		if err != nil {
+			return Record{}, false, err
+		}
+		if !held.Expired(now) {
+			return held, false, nil
+		}
+		if err = db.Where("id = ? AND expires_at <= ?", held.ID, now).Delete(&Record{}).Error; err != nil {
+			return Record{}, false, err
+		}
+	}
+	return Record{}, false, ErrRequestInFlight
+}
+
+func (s *databaseStore) Complete(ctx context.Context, record Record) error {
+	return s.db.WithContext(ctx).Model(&record).
+		Where("completed = ?", false).
+		Select("completed", "status_code", "header", "body", "expires_at").
+		Updates(&record).Error
+}
+
+func (s *databaseStore) Release(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Where("completed = ?", false).Delete(&Record{}, id).Error
+}
+
+func (s *databaseStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&Record{})
+	return result.RowsAffected, result.Error
+}
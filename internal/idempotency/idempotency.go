@@ -0,0 +1,153 @@
+// Package idempotency makes POST requests safe to retry. A client sends a
+// unique Idempotency-Key with a request; the first request with the key is
+// handled and its response kept, and retries with the same key get that
+// response again instead of repeating the work.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	appLogger "github.com/ranggaaprilio/boilerGo/internal/logger"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+const (
+	// MaxKeyLength is the longest Idempotency-Key accepted
+	MaxKeyLength = 255
+	// MaxRequestSize is the largest request body fingerprinted. Larger
+	// requests carrying a key are refused, since their body would have to be
+	// held in memory to be compared.
+	MaxRequestSize = 1 << 20
+	// MaxResponseSize is the largest response body kept for replay. Larger
+	// responses are kept without their body.
+	MaxResponseSize = 1 << 20
+)
+
+var (
+	// ErrKeyReused is returned when a key comes back with a different request
+	ErrKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrRequestInFlight is returned while the first request with a key is
+	// still being handled
+	ErrRequestInFlight = errors.New("a request with this idempotency key is still being processed")
+)
+
+// Options configures how long keys are held, see
+// config.IdempotencyConfigurations
+type Options struct {
+	TTL         time.Duration
+	LockTimeout time.Duration
+}
+
+type Service interface {
+	// Begin claims the key for a request with the fingerprint. It returns a
+	// record that is not yet Completed when the caller should handle the
+	// request, and the completed record to replay when it was handled
+	// before.
+	Begin(ctx context.Context, owner, key, fingerprint string) (Record, error)
+	// Complete keeps the response of a request begun with Begin
+	Complete(ctx context.Context, record Record, status int, header http.Header, body []byte) error
+	// Release gives up the key of a request that could not be handled, so
+	// a retry handles it afresh
+	Release(ctx context.Context, record Record) error
+}
+
+type service struct {
+	store  Store
+	opts   Options
+	logger *appLogger.LogrusLogger
+	now    func() time.Time
+
+	// lastPrune limits expired record cleanup to once per TTL
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+}
+
+func NewService(store Store, opts Options) *service {
+	return &service{
+		store:  store,
+		opts:   opts,
+		logger: appLogger.SimpleLogger("idempotency"),
+		now:    time.Now,
+	}
+}
+
+// Fingerprint identifies a request by its method, URI and body, so a key
+// reused for anything else can be told apart from a retry
+func Fingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + uri + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *service) Begin(ctx context.Context, owner, key, fingerprint string) (Record, error) {
+	now := s.now()
+	s.pruneExpired(ctx, now)
+
+	record := Record{
+		Owner:       owner,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(s.opts.LockTimeout),
+	}
+	held, claimed, err := s.store.Claim(ctx, &record, now)
+	if err != nil {
+		return Record{}, err
+	}
+	if claimed {
+		return held, nil
+	}
+
+	switch {
+	case held.Fingerprint != fingerprint:
+		s.logger.Warn("Idempotency key reused for a different request", "owner", owner, "record_id", held.ID)
+		return Record{}, ErrKeyReused
+	case !held.Completed:
+		return Record{}, ErrRequestInFlight
+	default:
+		return held, nil
+	}
+}
+
+func (s *service) Complete(ctx context.Context, record Record, status int, header http.Header, body []byte) error {
+	record.Completed = true
+	record.StatusCode = status
+	record.Header = header
+	record.Body = body
+	record.ExpiresAt = s.now().Add(s.opts.TTL)
+	if err := s.store.Complete(ctx, record); err != nil {
+		s.logger.Warn("Failed to keep idempotent response", "record_id", record.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *service) Release(ctx context.Context, record Record) error {
+	if err := s.store.Release(ctx, record.ID); err != nil {
+		s.logger.Warn("Failed to release idempotency key", "record_id", record.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// pruneExpired deletes expired records of every tenant, at most once per
+// TTL. Failures are only logged since pruning is housekeeping.
+func (s *service) pruneExpired(ctx context.Context, now time.Time) {
+	s.pruneMu.Lock()
+	if now.Sub(s.lastPrune) < s.opts.TTL {
+		s.pruneMu.Unlock()
+		return
+	}
+	s.lastPrune = now
+	s.pruneMu.Unlock()
+
+	if _, err := s.store.DeleteExpired(tenancy.AllTenants(ctx), now); err != nil {
+		s.logger.Warn("Failed to prune expired idempotency records", "error", err)
+	}
+}
@@ -0,0 +1,87 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// memoryStore keeps records in process memory. Records are lost on restart
+// and not shared between instances, so a retry reaching another instance is
+// handled as a new request. Keys are kept per tenant like the database
+// store's.
+type memoryStore struct {
+	mu      sync.Mutex
+	nextID  uint
+	records map[memoryKey]Record
+}
+
+type memoryKey struct {
+	tenantID uint
+	owner    string
+	key      string
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[memoryKey]Record)}
+}
+
+func (s *memoryStore) Claim(ctx context.Context, record *Record, now time.Time) (Record, bool, error) {
+	t, err := tenancy.Require(ctx)
+	if err != nil {
+		return Record{}, false, err
+	}
+	key := memoryKey{t.ID, record.Owner, record.Key}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if held, ok := s.records[key]; ok && !held.Expired(now) {
+		return held, false, nil
+	}
+	s.nextID++
+	record.ID = s.nextID
+	record.TenantID = t.ID
+	record.CreatedAt = now
+	s.records[key] = *record
+	return *record, true, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memoryKey{record.TenantID, record.Owner, record.Key}
+	if held, ok := s.records[key]; ok && held.ID == record.ID && !held.Completed && tenancy.Matches(ctx, held.TenantID) {
+		s.records[key] = record
+	}
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, held := range s.records {
+		if held.ID == id && !held.Completed && tenancy.Matches(ctx, held.TenantID) {
+			delete(s.records, key)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, held := range s.records {
+		if held.Expired(now) && tenancy.Matches(ctx, held.TenantID) {
+			delete(s.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
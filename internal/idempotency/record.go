@@ -0,0 +1,37 @@
+package idempotency
+
+import (
+	"net/http"
+	"time"
+)
+
+// Record is a request made with an Idempotency-Key and, once it has been
+// handled, the response to replay for retries
+type Record struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	TenantID  uint `gorm:"not null;uniqueIndex:idx_idempotency_key,priority:1"`
+	// Owner is the caller the key belongs to, so callers never see each
+	// other's responses
+	Owner string `gorm:"type:varchar(64);not null;uniqueIndex:idx_idempotency_key,priority:2"`
+	Key   string `gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_key,priority:3"`
+	// Fingerprint is the SHA-256 of the request's method, URI and body
+	Fingerprint string `gorm:"type:char(64);not null"`
+	// Completed is false while the first request is still being handled
+	Completed  bool        `gorm:"not null"`
+	StatusCode int         `gorm:"not null"`
+	Header     http.Header `gorm:"type:text;serializer:json"`
+	Body       []byte
+	// ExpiresAt is when the key can be used for a new request. A request in
+	// flight holds its key for the lock timeout, a completed one for the TTL.
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+func (Record) TableName() string {
+	return "idempotency_records"
+}
+
+// Expired reports whether the record no longer holds its key at now
+func (r Record) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
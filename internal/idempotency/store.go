@@ -0,0 +1,21 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps idempotency records. Keys are per tenant and owner, and
+// implementations must make Claim atomic so only one request wins a key.
+type Store interface {
+	// Claim saves the record unless an unexpired record holds its key, in
+	// which case that record is returned and claimed is false. Expired
+	// holders are replaced.
+	Claim(ctx context.Context, record *Record, now time.Time) (held Record, claimed bool, err error)
+	// Complete saves the response of a claimed record and its new expiry. A
+	// record that has meanwhile been replaced is left alone.
+	Complete(ctx context.Context, record Record) error
+	// Release deletes a claimed record so the key can be used again
+	Release(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -9,6 +9,8 @@ import (
 // LogrusLogger wraps logrus.Logger with additional functionality
 type LogrusLogger struct {
 	*logrus.Logger
+	// entry carries the fields added with the With* methods
+	entry     *logrus.Entry
 	component string
 }
 
@@ -46,17 +48,17 @@ func NewLogrusLogger(logLevel string, isDevelopment bool, serviceName string) *L
 	logger.SetOutput(os.Stdout)
 
 	// Add service name to all logs
-	logger = logger.WithField("service", serviceName).Logger
-
 	return &LogrusLogger{
 		Logger: logger,
+		entry:  logger.WithField("service", serviceName),
 	}
 }
 
 // WithComponent returns a logger with component information
 func (l *LogrusLogger) WithComponent(component string) *LogrusLogger {
 	return &LogrusLogger{
-		Logger:    l.Logger.WithField("component", component).Logger,
+		Logger:    l.Logger,
+		entry:     l.fields().WithField("component", component),
 		component: component,
 	}
 }
@@ -64,7 +66,8 @@ func (l *LogrusLogger) WithComponent(component string) *LogrusLogger {
 // WithFields returns a logger with additional fields
 func (l *LogrusLogger) WithFields(fields logrus.Fields) *LogrusLogger {
 	return &LogrusLogger{
-		Logger:    l.Logger.WithFields(fields).Logger,
+		Logger:    l.Logger,
+		entry:     l.fields().WithFields(fields),
 		component: l.component,
 	}
 }
@@ -72,7 +75,8 @@ func (l *LogrusLogger) WithFields(fields logrus.Fields) *LogrusLogger {
 // WithField returns a logger with an additional field
 func (l *LogrusLogger) WithField(key string, value interface{}) *LogrusLogger {
 	return &LogrusLogger{
-		Logger:    l.Logger.WithField(key, value).Logger,
+		Logger:    l.Logger,
+		entry:     l.fields().WithField(key, value),
 		component: l.component,
 	}
 }
@@ -80,54 +84,43 @@ func (l *LogrusLogger) WithField(key string, value interface{}) *LogrusLogger {
 // WithError returns a logger with error information
 func (l *LogrusLogger) WithError(err error) *LogrusLogger {
 	return &LogrusLogger{
-		Logger:    l.Logger.WithError(err).Logger,
+		Logger:    l.Logger,
+		entry:     l.fields().WithError(err),
 		component: l.component,
 	}
 }
 
 // Fatal logs a fatal message and exits
 func (l *LogrusLogger) Fatal(msg string, args ...interface{}) {
-	if len(args) > 0 {
-		l.WithFields(argsToFields(args...)).Fatal(msg)
-	} else {
-		l.Logger.Fatal(msg)
-	}
+	l.fields().WithFields(argsToFields(args...)).Fatal(msg)
 }
 
 // Error logs an error message
 func (l *LogrusLogger) Error(msg string, args ...interface{}) {
-	if len(args) > 0 {
-		l.WithFields(argsToFields(args...)).Error(msg)
-	} else {
-		l.Logger.Error(msg)
-	}
+	l.fields().WithFields(argsToFields(args...)).Error(msg)
 }
 
 // Warn logs a warning message
 func (l *LogrusLogger) Warn(msg string, args ...interface{}) {
-	if len(args) > 0 {
-		l.WithFields(argsToFields(args...)).Warn(msg)
-	} else {
-		l.Logger.Warn(msg)
-	}
+	l.fields().WithFields(argsToFields(args...)).Warn(msg)
 }
 
 // Info logs an info message
 func (l *LogrusLogger) Info(msg string, args ...interface{}) {
-	if len(args) > 0 {
-		l.WithFields(argsToFields(args...)).Info(msg)
-	} else {
-		l.Logger.Info(msg)
-	}
+	l.fields().WithFields(argsToFields(args...)).Info(msg)
 }
 
 // Debug logs a debug message
 func (l *LogrusLogger) Debug(msg string, args ...interface{}) {
-	if len(args) > 0 {
-		l.WithFields(argsToFields(args...)).Debug(msg)
-	} else {
-		l.Logger.Debug(msg)
+	l.fields().WithFields(argsToFields(args...)).Debug(msg)
+}
+
+// fields returns the entry holding the logger's fields
+func (l *LogrusLogger) fields() *logrus.Entry {
+	if l.entry == nil {
+		return logrus.NewEntry(l.Logger)
 	}
+	return l.entry
 }
 
 // argsToFields converts key-value pairs to logrus.Fields
@@ -154,7 +147,8 @@ func SimpleLogger(component string) *LogrusLogger {
 	logger.SetOutput(os.Stdout)
 
 	return &LogrusLogger{
-		Logger:    logger.WithField("component", component).Logger,
+		Logger:    logger,
+		entry:     logger.WithField("component", component),
 		component: component,
 	}
 }
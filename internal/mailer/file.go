@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes every message as an .eml file into an outbox directory
+// instead of sending it. Useful in development and tests.
+type FileMailer struct {
+	dir  string
+	from *mail.Address
+}
+
+// NewFileMailer returns a mailer writing to dir, creating it if needed
+func NewFileMailer(dir string, from *mail.Address) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir, from}, nil
+}
+
+// Send writes the message to the outbox. File names start with the time so
+// a directory listing shows messages in the order they were sent.
+func (m *FileMailer) Send(msg Message) error {
+	now := time.Now()
+	_, data, err := msg.build(m.from, now)
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err = rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o644)
+}
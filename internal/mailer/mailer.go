@@ -0,0 +1,47 @@
+// Package mailer sends email through a pluggable Mailer: SMTP in production
+// and a file outbox for development and tests
+package mailer
+
+import (
+	"fmt"
+	"net/mail"
+
+	"github.com/ranggaaprilio/boilerGo/config"
+)
+
+// Message is an email ready to send. HTML is optional; when set the message
+// is sent as multipart/alternative with Text as the plain part.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New builds the mailer selected by the mail configuration
+func New(conf config.MailConfigurations) (Mailer, error) {
+	from, err := mail.ParseAddress(conf.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail from address: %w", err)
+	}
+
+	switch conf.Driver {
+	case "smtp":
+		return NewSMTPMailer(SMTPOptions{
+			Host:     conf.SMTPHost,
+			Port:     conf.SMTPPort,
+			Username: conf.SMTPUsername,
+			Password: conf.SMTPPassword,
+			From:     from,
+		}), nil
+	case "file":
+		return NewFileMailer(conf.OutboxDir, from)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", conf.Driver)
+	}
+}
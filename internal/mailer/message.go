@@ -0,0 +1,92 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"time"
+)
+
+// build renders the message as an RFC 5322 email. The recipient is parsed
+// so header injection through the address is impossible.
+func (m Message) build(from *mail.Address, now time.Time) (*mail.Address, []byte, error) {
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domainOf(from.Address)))
+	header("MIME-Version", "1.0")
+
+	if m.HTML == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err = writeQuotedPrintable(&buf, m.Text); err != nil {
+			return nil, nil, err
+		}
+		return to, buf.Bytes(), nil
+	}
+
+	body := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+body.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, content string }{
+		{`text/plain; charset="utf-8"`, m.Text},
+		{`text/html; charset="utf-8"`, m.HTML},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = writeQuotedPrintable(w, part.content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err = body.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	return to, buf.Bytes(), nil
+}
+
+// writeQuotedPrintable writes content using quoted-printable encoding
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// domainOf returns the domain part of an email address
+func domainOf(address string) string {
+	for i := len(address) - 1; i >= 0; i-- {
+		if address[i] == '@' {
+			return address[i+1:]
+		}
+	}
+	return "localhost"
+}
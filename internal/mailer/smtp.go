@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPOptions configures the SMTP server messages are relayed through
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     *mail.Address
+}
+
+// SMTPMailer sends messages through an SMTP server, upgrading the connection
+// with STARTTLS when the server offers it
+type SMTPMailer struct {
+	opts SMTPOptions
+}
+
+// NewSMTPMailer returns a mailer relaying through the given server. PLAIN
+// authentication is used when a username is set.
+func NewSMTPMailer(opts SMTPOptions) *SMTPMailer {
+	return &SMTPMailer{opts}
+}
+
+// Send delivers the message to the SMTP server
+func (m *SMTPMailer) Send(msg Message) error {
+	to, data, err := msg.build(m.opts.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.opts.Username != "" {
+		auth = smtp.PlainAuth("", m.opts.Username, m.opts.Password, m.opts.Host)
+	}
+
+	addr := net.JoinHostPort(m.opts.Host, strconv.Itoa(m.opts.Port))
+	return smtp.SendMail(addr, auth, m.opts.From.Address, []string{to.Address}, data)
+}
@@ -0,0 +1,115 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Templates renders messages from template files so the copy can be edited
+// without a rebuild. A template called name consists of:
+//
+//	name.subject.tmpl  the subject line (required)
+//	name.txt.tmpl      the plain text body (required)
+//	name.html.tmpl     the HTML body (optional, HTML-escaped)
+//
+// Files are read on every render, so edits apply immediately.
+type Templates struct {
+	dir string
+}
+
+// NewTemplates returns a renderer reading templates from dir
+func NewTemplates(dir string) *Templates {
+	return &Templates{dir}
+}
+
+// Render executes the named template with data and returns the message
+// without a recipient
+func (t *Templates) Render(name string, data interface{}) (Message, error) {
+	subject, err := t.renderText(name+".subject.tmpl", data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	text, err := t.renderText(name+".txt.tmpl", data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	html, err := t.renderHTML(name+".html.tmpl", data)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject),
+		Text:    text,
+		HTML:    html,
+	}, nil
+}
+
+// renderText executes a text template file
+func (t *Templates) renderText(file string, data interface{}) (string, error) {
+	content, err := os.ReadFile(filepath.Join(t.dir, file))
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(file).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderHTML executes an HTML template file, escaping data for HTML
+func (t *Templates) renderHTML(file string, data interface{}) (string, error) {
+	content, err := os.ReadFile(filepath.Join(t.dir, file))
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := htmltemplate.New(file).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// FormatDuration formats a duration for email copy, e.g. "24 hours" or
+// "30 minutes"
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	case d >= time.Minute && d%time.Minute == 0:
+		return plural(int(d/time.Minute), "minute")
+	default:
+		return d.String()
+	}
+}
+
+// plural formats a count with its unit
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
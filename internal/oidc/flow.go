@@ -0,0 +1,75 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// flowAudience marks flow tokens so they are never accepted as access tokens
+// or other signed tokens
+const flowAudience = "oidc-login"
+
+// flowClaims is what a login needs to remember between Begin and Complete.
+// The token stays in an HttpOnly cookie, which binds the callback to the
+// browser that started the login and keeps the PKCE verifier away from the
+// redirect.
+type flowClaims struct {
+	Provider   string `json:"provider"`
+	State      string `json:"state"`
+	Nonce      string `json:"nonce"`
+	Verifier   string `json:"verifier"`
+	LinkUserID uint   `json:"link_user_id,omitempty"`
+	TenantID   uint   `json:"tenant_id,omitempty"`
+	TenantSlug string `json:"tenant,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// flowSigner signs flow tokens with a key derived from the application secret
+type flowSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+func newFlowSigner(secret string, ttl time.Duration) *flowSigner {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(flowAudience))
+	return &flowSigner{key: mac.Sum(nil), ttl: ttl, now: time.Now}
+}
+
+// sign issues a flow token that expires after the state TTL
+func (s *flowSigner) sign(claims flowClaims) (string, time.Time, error) {
+	now := s.now()
+	expiresAt := now.Add(s.ttl)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Audience:  jwt.ClaimStrings{flowAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// parse checks the signature and expiry and returns the flow
+func (s *flowSigner) parse(token string) (flowClaims, error) {
+	claims := new(flowClaims)
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(flowAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return flowClaims{}, ErrInvalidState
+	}
+	return *claims, nil
+}
@@ -0,0 +1,247 @@
+// Package oidc logs users in through external OpenID Connect providers with
+// the authorization code flow and PKCE. Provider endpoints and signing keys
+// come from each issuer's discovery document.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/ranggaaprilio/boilerGo/config"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+	"golang.org/x/oauth2"
+)
+
+// defaultHTTPTimeout bounds requests to providers when no client is given
+const defaultHTTPTimeout = 10 * time.Second
+
+var (
+	// ErrUnknownProvider is returned for provider names that are not configured
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	// ErrInvalidState is returned when the callback does not belong to a
+	// login started by this browser, or the login took too long
+	ErrInvalidState = errors.New("invalid or expired login state")
+	// ErrLoginDenied is returned when the user cancelled or the provider
+	// refused the login
+	ErrLoginDenied = errors.New("login was cancelled or refused by the identity provider")
+	// ErrProviderFailed is returned when the provider cannot be reached or
+	// rejects the code exchange
+	ErrProviderFailed = errors.New("identity provider request failed")
+	// ErrInvalidIDToken is returned when the ID token fails validation
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+// Claims is what the provider asserted about the user in a verified ID token
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// ProviderInfo describes a configured provider
+type ProviderInfo struct {
+	Name        string
+	DisplayName string
+}
+
+// Options configures the client
+type Options struct {
+	Providers []config.OIDCProviderConfigurations
+	// SecretKey derives the key signing the flow state
+	SecretKey string
+	// StateTTL is how long the user has to finish logging in at the provider
+	StateTTL time.Duration
+	// HTTPClient talks to the providers; nil uses a client with a short
+	// timeout
+	HTTPClient *http.Client
+}
+
+// Flow is a started login. The browser is sent to AuthURL while Token is kept
+// in a cookie and handed back to Complete with the callback.
+type Flow struct {
+	AuthURL   string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Callback holds the query parameters the provider redirected back with
+type Callback struct {
+	Code  string
+	State string
+	Error string
+}
+
+// Result is a completed login
+type Result struct {
+	Provider string
+	Claims   Claims
+	// LinkUserID is the user who started the flow to link the identity to
+	// their account, or 0 for a login
+	LinkUserID uint
+	// Tenant is the tenant the flow was started for. The provider redirects
+	// to the same callback for every tenant, so the callback acts for this
+	// tenant rather than the one its request resolves to.
+	Tenant tenancy.Tenant
+}
+
+// Client runs login flows against the configured providers
+type Client struct {
+	providers map[string]*provider
+	infos     []ProviderInfo
+	flows     *flowSigner
+	http      *http.Client
+}
+
+func NewClient(opts Options) (*Client, error) {
+	if opts.SecretKey == "" {
+		return nil, errors.New("secret key is required to sign OIDC state")
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	c := &Client{
+		providers: map[string]*provider{},
+		flows:     newFlowSigner(opts.SecretKey, opts.StateTTL),
+		http:      httpClient,
+	}
+	for _, conf := range opts.Providers {
+		c.providers[conf.Name] = newProvider(conf, httpClient)
+
+		info := ProviderInfo{Name: conf.Name, DisplayName: conf.DisplayName}
+		if info.DisplayName == "" {
+			info.DisplayName = conf.Name
+		}
+		c.infos = append(c.infos, info)
+	}
+	return c, nil
+}
+
+// Providers returns the configured providers in configuration order
+func (c *Client) Providers() []ProviderInfo {
+	return c.infos
+}
+
+// Begin starts a login at the provider. A non-zero linkUserID marks the flow
+// as linking the provider identity to that user instead of logging in. The
+// flow remembers the context's tenant.
+func (c *Client) Begin(ctx context.Context, providerName string, linkUserID uint) (Flow, error) {
+	p, ok := c.providers[providerName]
+	if !ok {
+		return Flow{}, ErrUnknownProvider
+	}
+
+	endpoints, err := p.discover()
+	if err != nil {
+		return Flow{}, err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return Flow{}, err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return Flow{}, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	t, _ := tenancy.FromContext(ctx)
+	token, expiresAt, err := c.flows.sign(flowClaims{
+		Provider:   providerName,
+		State:      state,
+		Nonce:      nonce,
+		Verifier:   verifier,
+		LinkUserID: linkUserID,
+		TenantID:   t.ID,
+		TenantSlug: t.Slug,
+	})
+	if err != nil {
+		return Flow{}, err
+	}
+
+	authURL := endpoints.oauth.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	return Flow{AuthURL: authURL, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Complete checks the callback against the flow token kept since Begin,
+// exchanges the code with the PKCE verifier and validates the ID token's
+// signature, issuer, audience, expiry and nonce
+func (c *Client) Complete(ctx context.Context, providerName, flowToken string, callback Callback) (Result, error) {
+	p, ok := c.providers[providerName]
+	if !ok {
+		return Result{}, ErrUnknownProvider
+	}
+
+	flow, err := c.flows.parse(flowToken)
+	if err != nil || flow.Provider != providerName ||
+		subtle.ConstantTimeCompare([]byte(flow.State), []byte(callback.State)) != 1 {
+		return Result{}, ErrInvalidState
+	}
+	if callback.Error != "" || callback.Code == "" {
+		return Result{}, ErrLoginDenied
+	}
+
+	endpoints, err := p.discover()
+	if err != nil {
+		return Result{}, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.http)
+	token, err := endpoints.oauth.Exchange(ctx, callback.Code, oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrProviderFailed, err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return Result{}, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+	idToken, err := endpoints.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(flow.Nonce)) != 1 {
+		return Result{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err = idToken.Claims(&claims); err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	return Result{
+		Provider: providerName,
+		Claims: Claims{
+			Subject:       idToken.Subject,
+			Email:         claims.Email,
+			EmailVerified: claims.EmailVerified,
+			Name:          claims.Name,
+		},
+		LinkUserID: flow.LinkUserID,
+		Tenant:     tenancy.Tenant{ID: flow.TenantID, Slug: flow.TenantSlug},
+	}, nil
+}
+
+// randomToken returns 32 random bytes encoded for use in URLs
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,195 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ranggaaprilio/boilerGo/config"
+	"github.com/ranggaaprilio/boilerGo/internal/oidc/oidctest"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+const callbackURL = "http://app.test/api/v1/auth/oidc/mock/callback"
+
+// newTestClient starts a mock provider and a client registered with it
+func newTestClient(t *testing.T) (*Client, *oidctest.Provider) {
+	t.Helper()
+	idp := oidctest.NewProvider("boilergo", "s3cret")
+	t.Cleanup(idp.Close)
+
+	client, err := NewClient(Options{
+		Providers:  []config.OIDCProviderConfigurations{idp.Config("mock", callbackURL)},
+		SecretKey:  "test-secret",
+		StateTTL:   time.Minute,
+		HTTPClient: idp.Client(),
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return client, idp
+}
+
+// authorize follows the login at the provider the way a browser would and
+// returns what the provider redirected back with
+func authorize(t *testing.T, idp *oidctest.Provider, authURL string) Callback {
+	t.Helper()
+	browser := idp.Client()
+	browser.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := browser.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("redirect location: %v", err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != callbackURL {
+		t.Fatalf("redirected to %s, want %s", got, callbackURL)
+	}
+	query := location.Query()
+	return Callback{Code: query.Get("code"), State: query.Get("state"), Error: query.Get("error")}
+}
+
+func TestLoginFlowReturnsVerifiedClaims(t *testing.T) {
+	client, idp := newTestClient(t)
+	idp.SetUser(oidctest.User{Subject: "abc-123", Email: "jane@example.com", EmailVerified: true, Name: "Jane"})
+	tenantContext := tenancy.WithTenant(context.Background(), tenancy.Tenant{ID: 3, Slug: "acme"})
+
+	flow, err := client.Begin(tenantContext, "mock", 7)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	query := mustQuery(t, flow.AuthURL)
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL lacks a PKCE challenge: %s", flow.AuthURL)
+	}
+	if query.Get("nonce") == "" || query.Get("state") == "" {
+		t.Fatalf("authorization URL lacks state or nonce: %s", flow.AuthURL)
+	}
+
+	result, err := client.Complete(context.Background(), "mock", flow.Token, authorize(t, idp, flow.AuthURL))
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	want := Claims{Subject: "abc-123", Email: "jane@example.com", EmailVerified: true, Name: "Jane"}
+	if result.Claims != want || result.Provider != "mock" || result.LinkUserID != 7 {
+		t.Fatalf("result = %+v, want claims %+v for mock linking user 7", result, want)
+	}
+	if result.Tenant != (tenancy.Tenant{ID: 3, Slug: "acme"}) {
+		t.Fatalf("result tenant = %+v, want the tenant the flow began for", result.Tenant)
+	}
+}
+
+func TestCompleteRejectsStateFromAnotherFlow(t *testing.T) {
+	client, idp := newTestClient(t)
+
+	first, err := client.Begin(context.Background(), "mock", 0)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	second, err := client.Begin(context.Background(), "mock", 0)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+
+	callback := authorize(t, idp, first.AuthURL)
+	_, err = client.Complete(context.Background(), "mock", second.Token, callback)
+	if !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("Complete with another flow's cookie: err = %v, want ErrInvalidState", err)
+	}
+
+	_, err = client.Complete(context.Background(), "mock", "not-a-token", callback)
+	if !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("Complete with a forged cookie: err = %v, want ErrInvalidState", err)
+	}
+}
+
+func TestCompleteReportsDeniedLogin(t *testing.T) {
+	client, idp := newTestClient(t)
+	idp.Deny(true)
+
+	flow, err := client.Begin(context.Background(), "mock", 0)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+
+	_, err = client.Complete(context.Background(), "mock", flow.Token, authorize(t, idp, flow.AuthURL))
+	if !errors.Is(err, ErrLoginDenied) {
+		t.Fatalf("err = %v, want ErrLoginDenied", err)
+	}
+}
+
+func TestCodeCannotBeExchangedTwice(t *testing.T) {
+	client, idp := newTestClient(t)
+
+	flow, err := client.Begin(context.Background(), "mock", 0)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	callback := authorize(t, idp, flow.AuthURL)
+
+	if _, err = client.Complete(context.Background(), "mock", flow.Token, callback); err != nil {
+		t.Fatalf("first Complete: %v", err)
+	}
+	_, err = client.Complete(context.Background(), "mock", flow.Token, callback)
+	if !errors.Is(err, ErrProviderFailed) {
+		t.Fatalf("replayed code: err = %v, want ErrProviderFailed", err)
+	}
+}
+
+func TestCompleteRejectsTamperedIDTokens(t *testing.T) {
+	cases := map[string]func(jwt.MapClaims){
+		"wrong nonce":    func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+	}
+
+	for name, tamper := range cases {
+		t.Run(name, func(t *testing.T) {
+			client, idp := newTestClient(t)
+			idp.TamperIDToken(tamper)
+
+			flow, err := client.Begin(context.Background(), "mock", 0)
+			if err != nil {
+				t.Fatalf("Begin: %v", err)
+			}
+
+			_, err = client.Complete(context.Background(), "mock", flow.Token, authorize(t, idp, flow.AuthURL))
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestUnknownProvider(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	if _, err := client.Begin(context.Background(), "nope", 0); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("Begin: err = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func mustQuery(t *testing.T, raw string) url.Values {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %s: %v", raw, err)
+	}
+	return u.Query()
+}
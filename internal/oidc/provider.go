@@ -0,0 +1,84 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/ranggaaprilio/boilerGo/config"
+	"golang.org/x/oauth2"
+)
+
+// defaultScopes are requested besides openid when a provider sets none
+var defaultScopes = []string{"email", "profile"}
+
+// endpoints is what a provider's discovery document resolves to
+type endpoints struct {
+	oauth    *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+// provider fetches its discovery document on first use, so the API starts
+// even while a provider is unreachable. A failed discovery is retried by the
+// next login.
+type provider struct {
+	conf config.OIDCProviderConfigurations
+	// ctx carries the HTTP client and outlives requests because the
+	// discovered key set fetches rotated signing keys with it
+	ctx context.Context
+
+	mu         sync.Mutex
+	discovered *endpoints
+}
+
+func newProvider(conf config.OIDCProviderConfigurations, client *http.Client) *provider {
+	return &provider{
+		conf: conf,
+		ctx:  gooidc.ClientContext(context.Background(), client),
+	}
+}
+
+// discover returns the provider's endpoints, fetching them if needed
+func (p *provider) discover() (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovered != nil {
+		return p.discovered, nil
+	}
+
+	remote, err := gooidc.NewProvider(p.ctx, p.conf.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: discovery of %s: %v", ErrProviderFailed, p.conf.Name, err)
+	}
+
+	scopes := p.conf.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	p.discovered = &endpoints{
+		oauth: &oauth2.Config{
+			ClientID:     p.conf.ClientID,
+			ClientSecret: p.conf.ClientSecret,
+			RedirectURL:  p.conf.RedirectURL,
+			Endpoint:     remote.Endpoint(),
+			Scopes:       append([]string{gooidc.ScopeOpenID}, withoutOpenID(scopes)...),
+		},
+		verifier: remote.Verifier(&gooidc.Config{ClientID: p.conf.ClientID}),
+	}
+	return p.discovered, nil
+}
+
+// withoutOpenID drops openid from configured scopes since it is always added
+func withoutOpenID(scopes []string) []string {
+	var out []string
+	for _, scope := range scopes {
+		if scope != gooidc.ScopeOpenID {
+			out = append(out, scope)
+		}
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+// Package principal describes who is making the current request
+package principal
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Echo context keys the principal and its permissions are stored under
+const (
+	contextKey     = "principal"
+	loaderKey      = "principal.permission_loader"
+	permissionsKey = "principal.permissions"
+)
+
+// Principal holds what is known about the caller of a request
+type Principal struct {
+	Admin bool
+	// UserID is the authenticated user, or 0 for anonymous callers
+	UserID uint
+	// APIKey is set when the user authenticated with an API key rather than
+	// an access token. The key's Scopes then cap the user's permissions.
+	APIKey bool
+	Scopes []string
+	// SessionID is the cookie session the user authenticated with, or 0 for
+	// other credentials
+	SessionID uint
+}
+
+// Authenticated reports whether the caller is a logged in user
+func (p Principal) Authenticated() bool {
+	return p.UserID != 0
+}
+
+// PermissionLoader returns the permissions granted to a user
+type PermissionLoader interface {
+	UserPermissions(ctx context.Context, userID uint) ([]string, error)
+}
+
+// Set stores the principal on the request context
+func Set(c echo.Context, p Principal) {
+	c.Set(contextKey, p)
+	c.Set(permissionsKey, nil)
+}
+
+// From returns the principal of the request, or the zero value for anonymous callers
+func From(c echo.Context) Principal {
+	p, _ := c.Get(contextKey).(Principal)
+	return p
+}
+
+// UsePermissionLoader sets where HasPermission looks permissions up for this request
+func UsePermissionLoader(c echo.Context, loader PermissionLoader) {
+	c.Set(loaderKey, loader)
+}
+
+// HasPermission reports whether the caller holds a permission. Admins hold
+// every permission and anonymous callers none. API key callers also need the
+// permission among the key's scopes. A user's permissions are loaded once per
+// request and reused by later checks.
+func HasPermission(c echo.Context, permission string) (bool, error) {
+	p := From(c)
+	if p.Admin {
+		return true, nil
+	}
+	if !p.Authenticated() || (p.APIKey && !p.hasScope(permission)) {
+		return false, nil
+	}
+
+	permissions, err := permissionsOf(c, p.UserID)
+	if err != nil {
+		return false, err
+	}
+	return permissions[permission], nil
+}
+
+// hasScope reports whether the permission is among the principal's scopes
+func (p Principal) hasScope(permission string) bool {
+	for _, scope := range p.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionsOf returns the cached permissions of the request, loading them on first use
+func permissionsOf(c echo.Context, userID uint) (map[string]bool, error) {
+	if cached, ok := c.Get(permissionsKey).(map[string]bool); ok {
+		return cached, nil
+	}
+
+	permissions := map[string]bool{}
+	if loader, ok := c.Get(loaderKey).(PermissionLoader); ok {
+		names, err := loader.UserPermissions(c.Request().Context(), userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			permissions[name] = true
+		}
+	}
+
+	c.Set(permissionsKey, permissions)
+	return permissions, nil
+}
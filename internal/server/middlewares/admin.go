@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+// HeaderAdminToken is the request header carrying the admin token
+const HeaderAdminToken = "X-Admin-Token"
+
+// AdminToken marks the request principal as admin when the X-Admin-Token header
+// matches the configured token. Admins pass every permission check. It never
+// rejects a request. An empty token means nobody is treated as admin.
+func AdminToken(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if hasAdminToken(c, token) {
+				p := principal.From(c)
+				p.Admin = true
+				principal.Set(c, p)
+			}
+			return next(c)
+		}
+	}
+}
+
+// hasAdminToken reports whether the request carries the configured admin token
+func hasAdminToken(c echo.Context, token string) bool {
+	provided := c.Request().Header.Get(HeaderAdminToken)
+	if token == "" || provided == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// RequireAdmin rejects requests that do not carry the admin token, for
+// operator endpoints that no user permission grants. Use it after AdminToken.
+func RequireAdmin() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !principal.From(c).Admin {
+				return c.JSON(http.StatusForbidden, helper.ForbiddenResponse{
+					Code:    http.StatusForbidden,
+					Message: "Admin token required",
+				})
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+// AuditActor records the request principal and request ID on the request
+// context, so the changes it makes are attributed to them in the audit log.
+// Use it after the middlewares that identify the caller.
+func AuditActor() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := principal.From(c)
+			actor := audit.Actor{
+				Type:      audit.ActorAnonymous,
+				ID:        p.UserID,
+				RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+			}
+			switch {
+			case p.Admin:
+				actor.Type = audit.ActorAdmin
+			case p.APIKey:
+				actor.Type = audit.ActorAPIKey
+			case p.Authenticated():
+				actor.Type = audit.ActorUser
+			}
+
+			req := c.Request()
+			c.SetRequest(req.WithContext(audit.WithActor(req.Context(), actor)))
+			return next(c)
+		}
+	}
+}
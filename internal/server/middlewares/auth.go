@@ -0,0 +1,145 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// HeaderAPIKey is the request header that can carry an API key instead of
+// "Authorization: ApiKey <key>"
+const HeaderAPIKey = "X-API-Key"
+
+// Authorization schemes accepted by Authenticate
+const (
+	schemeBearer = "Bearer"
+	schemeAPIKey = "ApiKey"
+)
+
+// TokenVerifier validates an access token and returns the user and tenant it
+// was issued to
+type TokenVerifier interface {
+	VerifyAccessToken(token string) (uint, uint, error)
+}
+
+// APIKeyVerifier validates an API key of the context's tenant and returns its
+// owner and scopes
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (uint, []string, error)
+}
+
+// Authenticate identifies the caller from an optional credential: a bearer
+// access token, or an API key sent as "Authorization: ApiKey <key>" or in the
+// X-API-Key header. Requests without credentials continue anonymously;
+// malformed, expired, tampered or revoked credentials are rejected with 401,
+// as are access tokens issued for another tenant than the request's.
+func Authenticate(tokens TokenVerifier, keys APIKeyVerifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			apiKey := c.Request().Header.Get(HeaderAPIKey)
+			if header == "" && apiKey == "" {
+				return next(c)
+			}
+			if header != "" && apiKey != "" {
+				return unauthorized(c, "Send either an Authorization or an X-API-Key header, not both")
+			}
+
+			scheme := schemeAPIKey
+			credential := apiKey
+			if header != "" {
+				var ok bool
+				scheme, credential, ok = parseAuthorization(header)
+				if !ok {
+					return unauthorized(c, "Malformed Authorization header")
+				}
+			}
+
+			p := principal.From(c)
+			switch scheme {
+			case schemeBearer:
+				userID, tenantID, err := tokens.VerifyAccessToken(credential)
+				if err != nil {
+					return unauthorized(c, "Invalid or expired token")
+				}
+				if !tenancy.Matches(c.Request().Context(), tenantID) {
+					return unauthorized(c, "Token was issued for another tenant")
+				}
+				p.UserID = userID
+			case schemeAPIKey:
+				userID, scopes, err := keys.VerifyAPIKey(c.Request().Context(), credential)
+				if err != nil {
+					return unauthorized(c, "Invalid, expired or revoked API key")
+				}
+				p.UserID = userID
+				p.APIKey = true
+				p.Scopes = scopes
+			default:
+				return unauthorized(c, "Unsupported Authorization scheme")
+			}
+
+			principal.Set(c, p)
+			return next(c)
+		}
+	}
+}
+
+// RequireAuth rejects requests that are not made by an authenticated user
+func RequireAuth() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !principal.From(c).Authenticated() {
+				return unauthorized(c, "Authentication required")
+			}
+			return next(c)
+		}
+	}
+}
+
+// DenyAPIKeys rejects requests authenticated with an API key, for endpoints
+// that must only be used by a logged in person
+func DenyAPIKeys() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if principal.From(c).APIKey {
+				return c.JSON(http.StatusForbidden, helper.ForbiddenResponse{
+					Code:    http.StatusForbidden,
+					Message: "Not available to API key clients",
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// parseAuthorization splits an Authorization header into its canonical scheme
+// and credential
+func parseAuthorization(header string) (string, string, bool) {
+	scheme, credential, found := strings.Cut(header, " ")
+	credential = strings.TrimSpace(credential)
+	if !found || credential == "" {
+		return "", "", false
+	}
+
+	switch {
+	case strings.EqualFold(scheme, schemeBearer):
+		return schemeBearer, credential, true
+	case strings.EqualFold(scheme, schemeAPIKey):
+		return schemeAPIKey, credential, true
+	}
+	return scheme, credential, true
+}
+
+// unauthorized writes a 401 response listing the accepted schemes
+func unauthorized(c echo.Context, message string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="api", ApiKey realm="api"`)
+	return c.JSON(http.StatusUnauthorized, helper.UnauthorizedResponse{
+		Code:    http.StatusUnauthorized,
+		Message: message,
+	})
+}
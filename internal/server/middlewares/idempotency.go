@@ -0,0 +1,209 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/idempotency"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header naming a retryable request
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response replayed for a retry
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to
+// retry. The first request with a key is handled and its response kept;
+// retries with the same key and request get that response replayed. A key
+// reused for a different request is rejected with 422, and a retry while the
+// first request is still running with 409. Server errors are not kept, so
+// the request runs again when retried. Keys belong to the tenant and caller
+// that used them.
+//
+// Request bodies are read into memory to be fingerprinted and responses are
+// kept up to idempotency.MaxResponseSize, so only use it on endpoints with
+// small requests, not on streamed uploads. Responses are kept as they were
+// sent, so only use it on endpoints whose responses carry no credentials.
+func Idempotency(keys idempotency.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if key == "" || req.Method != http.MethodPost {
+				return next(c)
+			}
+			if len(key) > idempotency.MaxKeyLength {
+				return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Idempotency-Key must be at most " + strconv.Itoa(idempotency.MaxKeyLength) + " characters",
+				})
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, idempotency.MaxRequestSize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return c.JSON(http.StatusRequestEntityTooLarge, helper.PayloadTooLargeResponse{
+					Code:    http.StatusRequestEntityTooLarge,
+					Message: "Requests with an Idempotency-Key must be at most " + strconv.Itoa(idempotency.MaxRequestSize>>10) + " KiB",
+				})
+			}
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Failed to read request body",
+					Data:    err.Error(),
+				})
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			// The outcome is recorded even when the client gives up waiting
+			ctx := context.WithoutCancel(req.Context())
+			fingerprint := idempotency.Fingerprint(req.Method, req.URL.RequestURI(), body)
+			record, err := keys.Begin(ctx, idempotencyOwner(principal.From(c)), key, fingerprint)
+			switch {
+			case errors.Is(err, idempotency.ErrKeyReused):
+				return c.JSON(http.StatusUnprocessableEntity, helper.UnprocessableEntityResponse{
+					Code:    http.StatusUnprocessableEntity,
+					Message: "Idempotency-Key was already used for a different request",
+				})
+			case errors.Is(err, idempotency.ErrRequestInFlight):
+				return c.JSON(http.StatusConflict, helper.ConflictResponse{
+					Code:    http.StatusConflict,
+					Message: "A request with this Idempotency-Key is still being processed",
+				})
+			case err != nil:
+				c.Logger().Errorf("failed to check idempotency key: %v", err)
+				return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Oops sorry, Failed to check idempotency key",
+				})
+			case record.Completed:
+				return replay(c, record)
+			}
+
+			return handleIdempotent(ctx, c, next, keys, record)
+		}
+	}
+}
+
+// handleIdempotent runs the request and keeps its response under the
+// claimed record, or releases the key when no response worth keeping was
+// produced
+func handleIdempotent(ctx context.Context, c echo.Context, next echo.HandlerFunc, keys idempotency.Service, record idempotency.Record) error {
+	res := c.Response()
+	before := res.Header().Clone()
+	recorder := &bodyRecorder{ResponseWriter: res.Writer, limit: idempotency.MaxResponseSize}
+	res.Writer = recorder
+
+	completed := false
+	defer func() {
+		res.Writer = recorder.ResponseWriter
+		if !completed {
+			// The handler panicked; let a retry try again
+			_ = keys.Release(ctx, record)
+		}
+	}()
+
+	if err := next(c); err != nil {
+		// Render the error here so the response can be kept
+		c.Error(err)
+	}
+	completed = true
+
+	// The response has been sent; failures to keep it are logged by the
+	// service and only cost the retry a 409 until the lock times out
+	if res.Status >= http.StatusInternalServerError {
+		_ = keys.Release(ctx, record)
+		return nil
+	}
+	header := addedHeaders(before, res.Header())
+	if recorder.truncated {
+		// Keep the outcome so the work is not repeated, but not a body
+		// that was cut short
+		header.Del(echo.HeaderContentType)
+	}
+	_ = keys.Complete(ctx, record, res.Status, header, recorder.body.Bytes())
+	return nil
+}
+
+// replay sends a kept response again
+func replay(c echo.Context, record idempotency.Record) error {
+	res := c.Response()
+	for name, values := range record.Header {
+		res.Header()[name] = values
+	}
+	res.Header().Set(HeaderIdempotentReplayed, "true")
+	res.WriteHeader(record.StatusCode)
+	_, err := res.Write(record.Body)
+	return err
+}
+
+// idempotencyOwner names the caller keys are kept for. API keys act as their
+// user and share the user's keys.
+func idempotencyOwner(p principal.Principal) string {
+	switch {
+	case p.Admin:
+		return "admin"
+	case p.Authenticated():
+		return "user:" + strconv.FormatUint(uint64(p.UserID), 10)
+	default:
+		return "anonymous"
+	}
+}
+
+// unreplayedHeaders are never kept: cookies may carry credentials, and the
+// encoding is applied afresh to each response by the compression middleware
+var unreplayedHeaders = map[string]bool{
+	echo.HeaderSetCookie:       true,
+	echo.HeaderContentEncoding: true,
+	echo.HeaderContentLength:   true,
+}
+
+// addedHeaders returns the response headers the handler set. Headers set
+// before it, such as the request ID, belong to each request.
+func addedHeaders(before, after http.Header) http.Header {
+	added := http.Header{}
+	for name, values := range after {
+		if unreplayedHeaders[name] || slices.Equal(before[name], values) {
+			continue
+		}
+		added[name] = values
+	}
+	return added
+}
+
+// bodyRecorder copies what is written to the client, up to limit bytes.
+// Once the body grows past the limit the copy is dropped and truncated set.
+type bodyRecorder struct {
+	http.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	switch {
+	case r.truncated:
+	case r.body.Len()+len(b) > r.limit:
+		r.truncated = true
+		r.body = bytes.Buffer{}
+	default:
+		r.body.Write(b)
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *bodyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
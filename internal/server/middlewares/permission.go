@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+// Permissions makes the loader available to permission checks made while
+// handling the request. The loader is only called when a check needs it.
+func Permissions(loader principal.PermissionLoader) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal.UsePermissionLoader(c, loader)
+			return next(c)
+		}
+	}
+}
+
+// RequirePermission rejects anonymous callers with 401 and callers lacking the
+// permission with 403. Admins pass every check.
+func RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := principal.From(c)
+			if !p.Admin && !p.Authenticated() {
+				return unauthorized(c, "Authentication required")
+			}
+
+			allowed, err := principal.HasPermission(c, permission)
+			if err != nil {
+				c.Logger().Errorf("failed to check permission: %v", err)
+				return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Oops sorry, Failed to check permissions",
+				})
+			}
+			if !allowed {
+				return c.JSON(http.StatusForbidden, helper.ForbiddenResponse{
+					Code:    http.StatusForbidden,
+					Message: "Missing permission " + permission,
+				})
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+// HeaderCSRFToken is the request header carrying the session's CSRF token
+const HeaderCSRFToken = "X-CSRF-Token"
+
+// SessionVerifier validates a session cookie value and returns the session's
+// user, ID and CSRF token. Only sessions of the context's tenant are valid.
+type SessionVerifier interface {
+	VerifySession(ctx context.Context, token string) (uint, uint, string, error)
+}
+
+// SessionCookie identifies the caller from a session cookie when no other
+// credential did. Requests with unsafe methods must also echo the session's
+// CSRF token in the X-CSRF-Token header; forged cross-site requests, which
+// carry the cookie but not the token, are rejected with 403. Unknown or
+// expired sessions continue anonymously, so stale cookies never lock a
+// browser out of the login endpoints.
+func SessionCookie(sessions SessionVerifier, cookieName string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := principal.From(c)
+			if p.Authenticated() {
+				return next(c)
+			}
+
+			cookie, err := c.Cookie(cookieName)
+			if err != nil || cookie.Value == "" {
+				return next(c)
+			}
+
+			userID, sessionID, csrfToken, err := sessions.VerifySession(c.Request().Context(), cookie.Value)
+			if err != nil {
+				return next(c)
+			}
+
+			if !safeMethod(c.Request().Method) && !validCSRFToken(c, csrfToken) {
+				return c.JSON(http.StatusForbidden, helper.ForbiddenResponse{
+					Code:    http.StatusForbidden,
+					Message: "Missing or invalid CSRF token",
+				})
+			}
+
+			p.UserID = userID
+			p.SessionID = sessionID
+			principal.Set(c, p)
+			return next(c)
+		}
+	}
+}
+
+// validCSRFToken reports whether the request carries the session's CSRF token
+func validCSRFToken(c echo.Context, expected string) bool {
+	provided := c.Request().Header.Get(HeaderCSRFToken)
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) == 1
+}
+
+// safeMethod reports whether the HTTP method does not change state
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/tenancy"
+)
+
+// TenantResolver looks tenants up by slug or ID, returning
+// tenancy.ErrUnknownTenant for tenants that do not exist
+type TenantResolver interface {
+	TenantBySlug(ctx context.Context, slug string) (tenancy.Tenant, error)
+	TenantByID(ctx context.Context, id uint) (tenancy.Tenant, error)
+}
+
+// TenantOptions configures where Tenant looks for the tenant of a request
+type TenantOptions struct {
+	// Header carries the tenant slug; empty disables it
+	Header string
+	// BaseDomain enables subdomains of it as tenant slugs; empty disables it
+	BaseDomain string
+	// DefaultTenant is the slug used when nothing else names a tenant
+	DefaultTenant string
+}
+
+// Tenant resolves the tenant a request acts for and puts it on the request
+// context, where repositories pick it up. The tenant is taken from the first
+// of: the tenant header, the subdomain of the base domain, the tenant claim
+// of a valid bearer token, and the default tenant. Unknown tenants are
+// rejected with 404, and requests naming none without a default with 400.
+func Tenant(resolver TenantResolver, tokens TokenVerifier, opts TenantOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := req.Context()
+
+			var (
+				t   tenancy.Tenant
+				err error
+			)
+			if slug := requestedTenant(c, opts); slug != "" {
+				t, err = resolver.TenantBySlug(ctx, slug)
+			} else if tenantID := tokenTenant(c, tokens); tenantID != 0 {
+				t, err = resolver.TenantByID(ctx, tenantID)
+			} else if opts.DefaultTenant != "" {
+				t, err = resolver.TenantBySlug(ctx, opts.DefaultTenant)
+			} else {
+				return c.JSON(http.StatusBadRequest, helper.BadRequestResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Tenant required",
+				})
+			}
+
+			if errors.Is(err, tenancy.ErrUnknownTenant) {
+				return c.JSON(http.StatusNotFound, helper.NotFoundResponse{
+					Code:    http.StatusNotFound,
+					Message: "Tenant not found",
+				})
+			}
+			if err != nil {
+				c.Logger().Errorf("failed to resolve tenant: %v", err)
+				return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Oops sorry, Failed to resolve tenant",
+				})
+			}
+
+			c.SetRequest(req.WithContext(tenancy.WithTenant(ctx, t)))
+			return next(c)
+		}
+	}
+}
+
+// requestedTenant returns the slug named by the tenant header or subdomain
+func requestedTenant(c echo.Context, opts TenantOptions) string {
+	if opts.Header != "" {
+		if slug := strings.TrimSpace(c.Request().Header.Get(opts.Header)); slug != "" {
+			return strings.ToLower(slug)
+		}
+	}
+	if opts.BaseDomain == "" {
+		return ""
+	}
+
+	host := c.Request().Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	sub, found := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(opts.BaseDomain))
+	if !found || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
+
+// tokenTenant returns the tenant claim of a valid bearer token, or 0. Invalid
+// tokens are left for Authenticate to reject.
+func tokenTenant(c echo.Context, tokens TokenVerifier) uint {
+	scheme, credential, ok := parseAuthorization(c.Request().Header.Get(echo.HeaderAuthorization))
+	if !ok || scheme != schemeBearer {
+		return 0
+	}
+	_, tenantID, err := tokens.VerifyAccessToken(credential)
+	if err != nil {
+		return 0
+	}
+	return tenantID
+}
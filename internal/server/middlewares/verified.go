@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/helper"
+	"github.com/ranggaaprilio/boilerGo/internal/principal"
+)
+
+// EmailVerificationChecker reports whether a user has verified their email
+type EmailVerificationChecker interface {
+	EmailVerified(ctx context.Context, userID uint) (bool, error)
+}
+
+// RequireVerifiedEmail rejects authenticated users whose email is not yet
+// verified with 403. Use it after RequireAuth.
+func RequireVerifiedEmail(checker EmailVerificationChecker) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			verified, err := checker.EmailVerified(c.Request().Context(), principal.From(c).UserID)
+			if err != nil {
+				c.Logger().Errorf("failed to check email verification: %v", err)
+				return c.JSON(http.StatusInternalServerError, helper.InternalServerErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Oops sorry, Failed to check email verification",
+				})
+			}
+			if !verified {
+				return c.JSON(http.StatusForbidden, helper.ForbiddenResponse{
+					Code:    http.StatusForbidden,
+					Message: "Email address must be verified",
+				})
+			}
+			return next(c)
+		}
+	}
+}
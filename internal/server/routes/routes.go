@@ -3,49 +3,261 @@ package routes
 import (
 	"encoding/json"
 	"io/ioutil"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/apikey"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/audit"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/auth"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/avatar"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/identity"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/lockout"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/passwordreset"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/privacy"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/refreshtoken"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/session"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/tenant"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/twofactor"
 	"github.com/ranggaaprilio/boilerGo/app/v1/modules/user"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/verification"
 	"github.com/ranggaaprilio/boilerGo/config"
 	"github.com/ranggaaprilio/boilerGo/exception"
+	"github.com/ranggaaprilio/boilerGo/internal/idempotency"
+	"github.com/ranggaaprilio/boilerGo/internal/mailer"
+	"github.com/ranggaaprilio/boilerGo/internal/oidc"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
 	"github.com/ranggaaprilio/boilerGo/internal/server/routes/v1"
+	"github.com/ranggaaprilio/boilerGo/internal/storage"
+	"gorm.io/gorm"
 )
 
 // SetupRoutes configures all application routes
-func SetupRoutes(e *echo.Echo) {
+func SetupRoutes(e *echo.Echo, conf config.Configurations) {
 	// Setup Swagger documentation
 	SetupSwagger(e)
 
 	// Setup API versioned routes
-	setupV1Routes(e)
+	setupV1Routes(e, conf)
 
 	// Export routes to JSON file for documentation
 	exportRoutes(e)
 }
 
 // setupV1Routes configures version 1 API routes
-func setupV1Routes(e *echo.Echo) {
-	// Create v1 group
-	v1 := e.Group("/api/v1")
+func setupV1Routes(e *echo.Echo, conf config.Configurations) {
+	// Initialize shared dependencies
+	db := config.CreateCon()
+	userRepository := user.NewRepository(db)
+	hasher := user.NewBcryptHasher(conf.Auth.BcryptCost)
+	tokenManager, err := auth.NewTokenManager(auth.TokenOptions{
+		SigningMethod:  conf.Auth.SigningMethod,
+		SecretKey:      conf.App.SecretKey,
+		PrivateKeyFile: conf.Auth.PrivateKeyFile,
+		PublicKeyFile:  conf.Auth.PublicKeyFile,
+		Issuer:         conf.Auth.Issuer,
+		AccessTTL:      conf.Auth.AccessTokenTTL,
+	})
+	exception.PanicIfNeeded(err)
+	rbacService := rbac.NewService(rbac.NewRepository(db), userRepository)
+	apiKeyService := apikey.NewService(apikey.NewRepository(db), rbacService)
+	mail, err := mailer.New(conf.Mail)
+	exception.PanicIfNeeded(err)
+	templates := mailer.NewTemplates(conf.Mail.TemplatesDir)
+	sessionStore := newSessionStore(conf.Auth.Session, db)
+	sessionService := newSessionService(conf.Auth.Session, sessionStore)
+
+	// Users are changed through the user service so every change is recorded
+	// in the audit log
+	auditRepository := audit.NewRepository(db)
+	auditService := audit.NewService(auditRepository)
+	userService := user.NewService(userRepository, hasher, auditService)
+	userService.AddDependent(refreshtoken.UserDependent{})
+	userService.AddDependent(session.NewUserDependent(sessionStore))
+	userService.AddDependent(apikey.UserDependent{})
+	userService.AddDependent(twofactor.UserDependent{})
+	userService.AddDependent(user.NewDependentTable(&rbac.UserRole{}))
+	userService.AddDependent(user.NewDependentTable(&identity.Identity{}))
+	userService.AddDependent(user.NewDependentTable(&passwordreset.PasswordResetToken{}))
+
+	verificationService, err := verification.NewService(userRepository, userService, mail, templates, verification.Options{
+		SecretKey:   conf.App.SecretKey,
+		TTL:         conf.Auth.VerificationTokenTTL,
+		VerifyURL:   conf.Auth.VerificationURL,
+		ServiceName: conf.App.ServiceName,
+	})
+	exception.PanicIfNeeded(err)
+
+	tenantService := tenant.NewService(tenant.NewRepository(db))
+
+	// Create v1 group. The tenant is resolved first so every later lookup is
+	// scoped to it, then callers are identified; each route decides what it
+	// requires.
+	v1 := e.Group("/api/v1",
+		middlewares.Tenant(tenantService, tokenManager, middlewares.TenantOptions{
+			Header:        conf.Tenancy.Header,
+			BaseDomain:    conf.Tenancy.BaseDomain,
+			DefaultTenant: conf.Tenancy.DefaultTenant,
+		}),
+		middlewares.AdminToken(conf.App.AdminToken),
+		middlewares.Authenticate(tokenManager, apiKeyService),
+		middlewares.SessionCookie(sessionService, conf.Auth.Session.CookieName),
+		middlewares.Permissions(rbacService),
+		middlewares.AuditActor(),
+	)
+	requireAuth := middlewares.RequireAuth()
+	idempotent := middlewares.Idempotency(newIdempotencyService(conf.Idempotency, db))
 
 	// Setup welcome routes
 	routes.SetupWelcomeRoutes(v1)
 
+	// Setup auth routes
+	refreshTokenService := refreshtoken.NewService(refreshtoken.NewRepository(db), conf.Auth.RefreshTokenTTL)
+	twoFactorService, err := twofactor.NewService(twofactor.NewRepository(db), userRepository, twofactor.NewTOTP(conf.App.ServiceName, time.Now), conf.App.SecretKey)
+	exception.PanicIfNeeded(err)
+	lockoutService := newLockoutService(conf.Auth.LoginProtection, db)
+	authService, err := auth.NewService(userRepository, hasher, tokenManager, refreshTokenService, auth.TwoFactorOptions{
+		Verifier:     twoFactorService,
+		SecretKey:    conf.App.SecretKey,
+		ChallengeTTL: conf.Auth.TwoFactorChallengeTTL,
+	}, lockoutService)
+	exception.PanicIfNeeded(err)
+	passwordResetService, err := passwordreset.NewService(passwordreset.NewRepository(db), userRepository, userService, []passwordreset.Revoker{
+		refreshtoken.PasswordResetRevoker{},
+		session.NewUserDependent(sessionStore),
+	}, mail, templates, passwordreset.Options{
+		SecretKey:   conf.App.SecretKey,
+		TTL:         conf.Auth.PasswordResetTokenTTL,
+		ResetURL:    conf.Auth.PasswordResetURL,
+		ServiceName: conf.App.ServiceName,
+	})
+	exception.PanicIfNeeded(err)
+	routes.SetupAuthRoutes(v1, handler.NewAuthHandler(authService, verificationService, passwordResetService), requireAuth)
+
+	// Setup session routes
+	sessionHandler := handler.NewSessionHandler(authService, sessionService, conf.Auth.Session)
+	routes.SetupSessionRoutes(v1, sessionHandler, requireAuth)
+
+	// Setup OpenID Connect routes
+	oidcClient, err := oidc.NewClient(oidc.Options{
+		Providers: conf.Auth.OIDC.Providers,
+		SecretKey: conf.App.SecretKey,
+		StateTTL:  conf.Auth.OIDC.StateTTL,
+	})
+	exception.PanicIfNeeded(err)
+	identityService := identity.NewService(identity.NewRepository(db), userRepository, userService)
+	routes.SetupOIDCRoutes(v1, handler.NewOIDCHandler(oidcClient, identityService, sessionHandler, conf.Auth.OIDC), requireAuth)
+
+	// Setup two-factor routes
+	routes.SetupTwoFactorRoutes(v1, handler.NewTwoFactorHandler(twoFactorService), requireAuth)
+
+	// Setup lockout routes
+	routes.SetupLockoutRoutes(v1, handler.NewLockoutHandler(lockoutService))
+
+	// Setup audit routes
+	routes.SetupAuditRoutes(v1, handler.NewAuditHandler(auditService, conf.App.Pagination))
+
 	// Setup user routes
-	setupUserRoutes(v1)
+	setupUserRoutes(v1, conf, userService, verificationService, requireAuth, idempotent)
+
+	// Setup avatar and file download routes
+	fileStorage, err := storage.New(conf.Storage)
+	exception.PanicIfNeeded(err)
+	signer, err := storage.NewURLSigner(conf.App.SecretKey, "/api/v1/files", conf.Storage.URLTTL, time.Now)
+	exception.PanicIfNeeded(err)
+	avatarService := avatar.NewService(userService, fileStorage, signer, avatar.Options{ThumbnailSize: conf.Storage.ThumbnailSize})
+	routes.SetupAvatarRoutes(v1, handler.NewAvatarHandler(avatarService, conf.Storage.MaxUploadSize), requireAuth)
+	routes.SetupFileRoutes(e, handler.NewFileHandler(fileStorage, signer))
+
+	// Setup privacy routes
+	privacyService := newPrivacyService(db, userRepository, sessionStore, fileStorage, auditService, auditRepository)
+	routes.SetupPrivacyRoutes(v1, handler.NewPrivacyHandler(privacyService), requireAuth)
+
+	// Setup tenant routes
+	routes.SetupTenantRoutes(v1, handler.NewTenantHandler(tenantService), idempotent)
+
+	// Setup role routes
+	routes.SetupRoleRoutes(v1, handler.NewRoleHandler(rbacService), idempotent)
+
+	// Setup API key routes
+	routes.SetupAPIKeyRoutes(v1, handler.NewAPIKeyHandler(apiKeyService), requireAuth, middlewares.RequireVerifiedEmail(verificationService))
 }
 
 // setupUserRoutes configures user-related routes
-func setupUserRoutes(v1 *echo.Group) {
+func setupUserRoutes(v1 *echo.Group, conf config.Configurations, userService user.Service, verificationService verification.Service, requireAuth, idempotent echo.MiddlewareFunc) {
 	// Initialize user dependencies
-	db := config.CreateCon()
-	userRepository := user.NewRepository(db)
-	userService := user.NewService(userRepository)
-	userHandler := handler.NewUserHandler(userService)
+	userHandler := handler.NewUserHandler(userService, verificationService, conf.App.Pagination)
 
 	// Setup user routes
-	routes.SetupUserRoutes(v1, userHandler)
+	routes.SetupUserRoutes(v1, userHandler, requireAuth, idempotent)
+}
+
+// newPrivacyService creates the data subject request service with every
+// module that stores data about users. A new model with a user_id column
+// joins exports and erasures by being registered here.
+func newPrivacyService(db *gorm.DB, userRepository user.Repository, sessionStore session.Store, fileStorage storage.Storage, auditService audit.Service, auditRepository audit.Repository) privacy.Service {
+	privacyService := privacy.NewService(userRepository, auditService)
+	privacyService.Register("roles", privacy.NewTable(db, &rbac.UserRole{}))
+	privacyService.Register("identities", privacy.NewTable(db, &identity.Identity{}))
+	privacyService.Register("api_keys", privacy.NewTable(db, &apikey.APIKey{}, "key_hash"))
+	privacyService.Register("sessions", session.NewPersonalData(sessionStore))
+	privacyService.Register("refresh_tokens", privacy.NewTable(db, &refreshtoken.RefreshToken{}, "token_hash"))
+	privacyService.Register("password_reset_tokens", privacy.NewTable(db, &passwordreset.PasswordResetToken{}, "token_hash"))
+	privacyService.Register("two_factor", privacy.NewTable(db, &twofactor.TwoFactor{}, "secret", "last_used_step"))
+	privacyService.Register("recovery_codes", privacy.NewTable(db, &twofactor.RecoveryCode{}, "code_hash"))
+	privacyService.Register("avatar", avatar.NewPersonalData(userRepository, fileStorage))
+	privacyService.Register("audit", audit.NewPersonalData(auditRepository, user.AuditResource))
+	return privacyService
+}
+
+// newLockoutService creates the failed login throttle with the configured
+// counter store
+func newLockoutService(conf config.LoginProtectionConfigurations, db *gorm.DB) lockout.Service {
+	var store lockout.Store = lockout.NewDatabaseStore(db)
+	if conf.Store == "memory" {
+		store = lockout.NewMemoryStore()
+	}
+
+	return lockout.NewService(store, lockout.Options{
+		Window:               conf.Window,
+		DelayAfter:           conf.DelayAfter,
+		BaseDelay:            conf.BaseDelay,
+		MaxDelay:             conf.MaxDelay,
+		AccountLockThreshold: conf.AccountLockThreshold,
+		IPLockThreshold:      conf.IPLockThreshold,
+		LockDuration:         conf.LockDuration,
+	})
+}
+
+// newIdempotencyService creates the Idempotency-Key handling with the
+// configured record store
+func newIdempotencyService(conf config.IdempotencyConfigurations, db *gorm.DB) idempotency.Service {
+	var store idempotency.Store = idempotency.NewDatabaseStore(db)
+	if conf.Store == "memory" {
+		store = idempotency.NewMemoryStore()
+	}
+
+	return idempotency.NewService(store, idempotency.Options{
+		TTL:         conf.TTL,
+		LockTimeout: conf.LockTimeout,
+	})
+}
+
+// newSessionStore creates the configured session store
+func newSessionStore(conf config.SessionConfigurations, db *gorm.DB) session.Store {
+	if conf.Store == "memory" {
+		return session.NewMemoryStore()
+	}
+	return session.NewDatabaseStore(db)
+}
+
+// newSessionService creates the cookie session service on the session store
+func newSessionService(conf config.SessionConfigurations, store session.Store) session.Service {
+	return session.NewService(store, session.Options{
+		IdleTimeout: conf.IdleTimeout,
+		MaxLifetime: conf.MaxLifetime,
+	})
 }
 
 // exportRoutes saves all routes to a JSON file for documentation
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupAPIKeyRoutes configures API key management endpoints for API v1. Keys
+// are managed by logged in users with a verified email only, never by other
+// API keys.
+func SetupAPIKeyRoutes(v1 *echo.Group, apiKeyHandler *handler.APIKeyHandler, requireAuth, requireVerified echo.MiddlewareFunc) {
+	// API key routes group
+	keys := v1.Group("/api-keys", requireAuth, middlewares.DenyAPIKeys(), requireVerified)
+
+	// API key endpoints
+	keys.GET("", apiKeyHandler.ListKeys)
+	keys.POST("", apiKeyHandler.CreateKey)
+	keys.POST("/:id/rotate", apiKeyHandler.RotateKey)
+	keys.DELETE("/:id", apiKeyHandler.RevokeKey)
+}
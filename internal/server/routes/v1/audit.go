@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupAuditRoutes configures the audit log endpoints for API v1. The log is
+// written by the services that change data, so it is read-only here.
+func SetupAuditRoutes(v1 *echo.Group, auditHandler *handler.AuditHandler) {
+	// Audit endpoints
+	v1.GET("/audit", auditHandler.ListEvents, middlewares.RequirePermission(rbac.PermAuditRead))
+}
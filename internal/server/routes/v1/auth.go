@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupAuthRoutes configures authentication endpoints for API v1
+func SetupAuthRoutes(v1 *echo.Group, authHandler *handler.AuthHandler, requireAuth echo.MiddlewareFunc) {
+	// Auth routes group
+	auth := v1.Group("/auth")
+
+	// Auth endpoints
+	auth.POST("/login", authHandler.Login)
+	auth.POST("/login/2fa", authHandler.LoginSecondFactor)
+	auth.POST("/refresh", authHandler.Refresh)
+	auth.POST("/logout", authHandler.Logout)
+	auth.POST("/logout-all", authHandler.LogoutAll, requireAuth, middlewares.DenyAPIKeys())
+	auth.GET("/verify", authHandler.VerifyEmail)
+	auth.POST("/verify/resend", authHandler.ResendVerification, requireAuth, middlewares.DenyAPIKeys())
+	auth.POST("/password/forgot", authHandler.ForgotPassword)
+	auth.POST("/password/reset", authHandler.ResetPassword)
+}
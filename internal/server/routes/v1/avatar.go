@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupAvatarRoutes configures the avatar endpoints for API v1. Users manage
+// their own avatar; managing another user's needs the user permissions.
+func SetupAvatarRoutes(v1 *echo.Group, avatarHandler *handler.AvatarHandler, requireAuth echo.MiddlewareFunc) {
+	users := v1.Group("/users")
+
+	// Endpoints for the authenticated user
+	users.PUT("/me/avatar", avatarHandler.UploadCurrentUserAvatar, requireAuth)
+	users.GET("/me/avatar", avatarHandler.GetCurrentUserAvatar, requireAuth)
+	users.DELETE("/me/avatar", avatarHandler.DeleteCurrentUserAvatar, requireAuth)
+
+	// Permission protected endpoints
+	users.PUT("/:id/avatar", avatarHandler.UploadUserAvatar, middlewares.RequirePermission(rbac.PermUsersWrite))
+	users.GET("/:id/avatar", avatarHandler.GetUserAvatar, middlewares.RequirePermission(rbac.PermUsersRead))
+	users.DELETE("/:id/avatar", avatarHandler.DeleteUserAvatar, middlewares.RequirePermission(rbac.PermUsersWrite))
+}
+
+// SetupFileRoutes configures the signed file downloads. They are registered
+// on the server rather than the v1 group: the signature is the only
+// credential, so neither a tenant nor a login is required.
+func SetupFileRoutes(e *echo.Echo, fileHandler *handler.FileHandler) {
+	e.GET("/api/v1/files/*", fileHandler.Download)
+}
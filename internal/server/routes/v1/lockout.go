@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupLockoutRoutes configures the failed login counter endpoints for API v1
+func SetupLockoutRoutes(v1 *echo.Group, lockoutHandler *handler.LockoutHandler) {
+	// Lockout endpoints
+	lockouts := v1.Group("/security/lockouts", middlewares.RequirePermission(rbac.PermSecurityManage))
+	lockouts.GET("", lockoutHandler.ListLockouts)
+	lockouts.DELETE("/:kind/:value", lockoutHandler.Unlock)
+}
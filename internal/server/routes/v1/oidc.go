@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupOIDCRoutes configures OpenID Connect login and linked identity
+// endpoints for API v1
+func SetupOIDCRoutes(v1 *echo.Group, oidcHandler *handler.OIDCHandler, requireAuth echo.MiddlewareFunc) {
+	// Provider login endpoints, visited by the browser
+	providers := v1.Group("/auth/oidc")
+	providers.GET("/providers", oidcHandler.ListProviders)
+	providers.GET("/:provider/login", oidcHandler.Login)
+	providers.GET("/:provider/callback", oidcHandler.Callback)
+	providers.GET("/:provider/link", oidcHandler.Link, requireAuth, middlewares.DenyAPIKeys())
+
+	// Linked identity endpoints for the caller
+	identities := v1.Group("/auth/identities", requireAuth, middlewares.DenyAPIKeys())
+	identities.GET("", oidcHandler.ListIdentities)
+	identities.DELETE("/:id", oidcHandler.UnlinkIdentity)
+}
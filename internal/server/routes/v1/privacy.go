@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupPrivacyRoutes configures the data subject request endpoints for API
+// v1. Users export and erase their own data; erasing it is a decision for
+// the person, so API keys cannot.
+func SetupPrivacyRoutes(v1 *echo.Group, privacyHandler *handler.PrivacyHandler, requireAuth echo.MiddlewareFunc) {
+	users := v1.Group("/users")
+
+	// Endpoints for the authenticated user
+	users.GET("/me/personal-data", privacyHandler.ExportCurrentUser, requireAuth)
+	users.POST("/me/erasure", privacyHandler.EraseCurrentUser, requireAuth, middlewares.DenyAPIKeys())
+
+	// Endpoints acting on behalf of a user
+	manage := middlewares.RequirePermission(rbac.PermPrivacyManage)
+	users.GET("/:id/personal-data", privacyHandler.ExportUser, manage)
+	users.POST("/:id/erasure", privacyHandler.EraseUser, manage)
+}
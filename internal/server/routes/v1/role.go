@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupRoleRoutes configures role management endpoints for API v1
+func SetupRoleRoutes(v1 *echo.Group, roleHandler *handler.RoleHandler, idempotent echo.MiddlewareFunc) {
+	manage := middlewares.RequirePermission(rbac.PermRolesManage)
+
+	// Role endpoints. Roles are shared by every tenant, so only operators
+	// create them.
+	roles := v1.Group("/roles", manage)
+	roles.GET("", roleHandler.ListRoles)
+	roles.POST("", roleHandler.CreateRole, middlewares.RequireAdmin(), idempotent)
+
+	// Role assignment endpoints
+	userRoles := v1.Group("/users/:id/roles", manage)
+	userRoles.GET("", roleHandler.GetUserRoles)
+	userRoles.PUT("/:role", roleHandler.AssignRole)
+	userRoles.DELETE("/:role", roleHandler.UnassignRole)
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupSessionRoutes configures cookie session endpoints for API v1
+func SetupSessionRoutes(v1 *echo.Group, sessionHandler *handler.SessionHandler, requireAuth echo.MiddlewareFunc) {
+	// Login session endpoints, acting on the request's cookie
+	current := v1.Group("/auth/session")
+	current.POST("", sessionHandler.Login)
+	current.POST("/2fa", sessionHandler.LoginSecondFactor)
+	current.GET("", sessionHandler.CurrentSession)
+	current.DELETE("", sessionHandler.Logout)
+
+	// Session management endpoints for the caller
+	own := v1.Group("/auth/sessions", requireAuth, middlewares.DenyAPIKeys())
+	own.GET("", sessionHandler.ListSessions)
+	own.DELETE("", sessionHandler.RevokeAllSessions)
+	own.DELETE("/:id", sessionHandler.RevokeSession)
+
+	// Session management endpoints for administrators
+	users := v1.Group("/users/:id/sessions", middlewares.RequirePermission(rbac.PermSecurityManage))
+	users.GET("", sessionHandler.ListUserSessions)
+	users.DELETE("", sessionHandler.RevokeUserSessions)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupTenantRoutes configures tenant management endpoints for API v1
+func SetupTenantRoutes(v1 *echo.Group, tenantHandler *handler.TenantHandler, idempotent echo.MiddlewareFunc) {
+	// Tenant endpoints
+	tenants := v1.Group("/tenants", middlewares.RequireAdmin())
+	tenants.GET("", tenantHandler.ListTenants)
+	tenants.POST("", tenantHandler.CreateTenant, idempotent)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
+)
+
+// SetupTwoFactorRoutes configures two-factor management endpoints for API v1.
+// They act on the logged in user and are not available to API keys.
+func SetupTwoFactorRoutes(v1 *echo.Group, twoFactorHandler *handler.TwoFactorHandler, requireAuth echo.MiddlewareFunc) {
+	// Two-factor routes group
+	twoFactor := v1.Group("/auth/2fa", requireAuth, middlewares.DenyAPIKeys())
+
+	// Two-factor endpoints
+	twoFactor.GET("", twoFactorHandler.Status)
+	twoFactor.POST("/enroll", twoFactorHandler.Enroll)
+	twoFactor.POST("/confirm", twoFactorHandler.Confirm)
+	twoFactor.POST("/disable", twoFactorHandler.Disable)
+	twoFactor.POST("/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+}
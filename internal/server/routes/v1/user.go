@@ -3,14 +3,31 @@ package routes
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/ranggaaprilio/boilerGo/app/v1/handler"
+	"github.com/ranggaaprilio/boilerGo/app/v1/modules/rbac"
+	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
 )
 
-// SetupUserRoutes configures user-related endpoints for API v1
-func SetupUserRoutes(v1 *echo.Group, userHandler *handler.UserHandler) {
+// SetupUserRoutes configures user-related endpoints for API v1. Registration
+// is made safe to retry with idempotent; imports are streamed, so they are
+// left out.
+func SetupUserRoutes(v1 *echo.Group, userHandler *handler.UserHandler, requireAuth, idempotent echo.MiddlewareFunc) {
 	// User routes group
 	users := v1.Group("/users")
 
-	// User endpoints
-	users.POST("", userHandler.RegisterUser)
-	users.GET("/:id", userHandler.GetUser)
+	// Public endpoints
+	users.POST("", userHandler.RegisterUser, idempotent)
+
+	// Endpoints for the authenticated user
+	users.GET("/me", userHandler.GetCurrentUser, requireAuth)
+
+	// Permission protected endpoints
+	users.GET("", userHandler.ListUsers, middlewares.RequirePermission(rbac.PermUsersRead))
+	users.GET("/export", userHandler.ExportUsers, middlewares.RequirePermission(rbac.PermUsersExport))
+	users.POST("/import", userHandler.ImportUsers, middlewares.RequirePermission(rbac.PermUsersImport))
+	users.GET("/:id", userHandler.GetUser, middlewares.RequirePermission(rbac.PermUsersRead))
+	users.PUT("/:id", userHandler.UpdateUser, middlewares.RequirePermission(rbac.PermUsersWrite))
+	users.PATCH("/:id", userHandler.PatchUser, middlewares.RequirePermission(rbac.PermUsersWrite))
+	users.DELETE("/:id", userHandler.DeleteUser, middlewares.RequirePermission(rbac.PermUsersDelete))
+	users.POST("/:id/restore", userHandler.RestoreUser, middlewares.RequirePermission(rbac.PermUsersRestore))
+	users.DELETE("/:id/purge", userHandler.PurgeUser, middlewares.RequirePermission(rbac.PermUsersPurge))
 }
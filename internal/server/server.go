@@ -4,13 +4,13 @@ import (
 	"net/http"
 	"runtime"
 
-	validator "github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/ranggaaprilio/boilerGo/config"
 	"github.com/ranggaaprilio/boilerGo/internal/health"
 	"github.com/ranggaaprilio/boilerGo/internal/server/middlewares"
 	"github.com/ranggaaprilio/boilerGo/internal/server/routes"
+	"github.com/ranggaaprilio/boilerGo/internal/validation"
 )
 
 // Server represents the HTTP server
@@ -21,15 +21,13 @@ type Server struct {
 
 // CustomValidator wraps the validator
 type CustomValidator struct {
-	validator *validator.Validate
+	validator *validation.Validator
 }
 
-// Validate validates the struct
+// Validate validates the struct. Failed rules are returned as a
+// *validation.Error so handlers can answer with translated per-field errors.
 func (cv *CustomValidator) Validate(i interface{}) error {
-	if err := cv.validator.Struct(i); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-	}
-	return nil
+	return cv.validator.Validate(i)
 }
 
 // New creates a new server instance
@@ -37,23 +35,24 @@ func New(conf config.Configurations) *echo.Echo {
 	e := echo.New()
 
 	// Setup custom validator
-	e.Validator = &CustomValidator{validator: validator.New()}
+	e.Validator = &CustomValidator{validator: validation.NewValidator()}
 
 	// Setup health checks
 	healthService := health.NewHealthService()
 	healthService.RegisterDefaultCheckers()
 
 	// Setup middlewares
-	setupMiddlewares(e, healthService)
+	setupMiddlewares(e, healthService, conf.Tenancy.Header)
 
 	// Setup routes
-	routes.SetupRoutes(e)
+	routes.SetupRoutes(e, conf)
 
 	return e
 }
 
-// setupMiddlewares configures all middlewares
-func setupMiddlewares(e *echo.Echo, healthService *health.HealthService) {
+// setupMiddlewares configures all middlewares. Browsers may send the tenant
+// header cross-origin, so it is allowed by CORS.
+func setupMiddlewares(e *echo.Echo, healthService *health.HealthService, tenantHeader string) {
 	// Custom server header
 	e.Use(middlewares.ServerHeader())
 
@@ -84,14 +83,25 @@ func setupMiddlewares(e *echo.Echo, healthService *health.HealthService) {
 	e.Use(middleware.Recover())
 
 	// CORS handling
+	allowHeaders := []string{
+		echo.HeaderOrigin,
+		echo.HeaderContentType,
+		echo.HeaderAccept,
+		echo.HeaderAuthorization,
+		middlewares.HeaderAdminToken,
+		middlewares.HeaderAPIKey,
+		middlewares.HeaderIdempotencyKey,
+		"If-Match",
+		"If-None-Match",
+	}
+	if tenantHeader != "" {
+		allowHeaders = append(allowHeaders, tenantHeader)
+	}
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{
-			echo.HeaderOrigin,
-			echo.HeaderContentType,
-			echo.HeaderAccept,
-			echo.HeaderAuthorization,
-		},
+		AllowHeaders: allowHeaders,
+		// Clients read the ETag to send it back as If-Match
+		ExposeHeaders: []string{"ETag"},
 		AllowMethods: []string{
 			http.MethodGet,
 			http.MethodHead,
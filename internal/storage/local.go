@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps objects as files below a directory. The content type is
+// not stored; it is derived from the key's extension when reading.
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage returns a storage writing below dir, creating it if needed
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir}, nil
+}
+
+// Put writes the object to a temporary file and renames it into place, so
+// readers never see a partly written object
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("wrote %d bytes of %s, expected %d", written, key, size)
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+// Get opens the file of the object
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, Object{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Object{}, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, Object{}, err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return file, Object{Key: key, Size: info.Size(), ContentType: contentType}, nil
+}
+
+// Delete removes the file of the object
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a key to a file below the storage directory, refusing keys that
+// would leave it
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || path.Clean("/"+key) != "/"+key || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MemoryStorage keeps objects in memory. Useful in tests.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+}
+
+// NewMemoryStorage returns an empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: make(map[string]memoryObject)}
+}
+
+// Put stores a copy of the object
+func (s *MemoryStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("read %d bytes of %s, expected %d", len(data), key, size)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = memoryObject{data, contentType}
+	return nil
+}
+
+// Get returns a reader over the stored object
+func (s *MemoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, Object{}, ErrNotFound
+	}
+
+	info := Object{Key: key, Size: int64(len(object.data)), ContentType: object.contentType}
+	return io.NopCloser(bytes.NewReader(object.data)), info, nil
+}
+
+// Delete forgets the object
+func (s *MemoryStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// Keys returns the keys of the stored objects in no particular order
+func (s *MemoryStorage) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// unsignedPayload tells S3 the body is not part of the signature, so uploads
+// can be streamed without hashing them first
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Options configures the bucket an S3Storage keeps objects in
+type S3Options struct {
+	// Endpoint is the host and port of the service, such as
+	// "s3.eu-west-1.amazonaws.com" or "localhost:9000" for MinIO
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// S3Storage keeps objects in a bucket of an S3-compatible service such as
+// Amazon S3 or MinIO. Requests use path-style URLs and are signed with
+// Signature Version 4.
+type S3Storage struct {
+	opts   S3Options
+	scheme string
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Storage returns a storage keeping objects in the configured bucket.
+// It does not contact the service; the bucket must already exist.
+func NewS3Storage(opts S3Options) (*S3Storage, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" || opts.AccessKey == "" || opts.SecretKey == "" {
+		return nil, fmt.Errorf("s3 storage needs an endpoint, bucket, access key and secret key")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+
+	scheme := "http"
+	if opts.UseSSL {
+		scheme = "https"
+	}
+	return &S3Storage{opts: opts, scheme: scheme, client: &http.Client{}, now: time.Now}, nil
+}
+
+// Put uploads the object
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Get downloads the object
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	res, err := s.do(req)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return res.Body, Object{Key: key, Size: res.ContentLength, ContentType: res.Header.Get("Content-Type")}, nil
+}
+
+// Delete removes the object. S3 answers deletes of missing objects with
+// success as well.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// request builds a request for the object's path-style URL
+func (s *S3Storage) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if key == "" {
+		return nil, fmt.Errorf("invalid object key %q", key)
+	}
+
+	objectPath := "/" + s.opts.Bucket + "/" + key
+	target := &url.URL{
+		Scheme:  s.scheme,
+		Host:    s.opts.Endpoint,
+		Path:    objectPath,
+		RawPath: uriEncode(objectPath),
+	}
+	return http.NewRequestWithContext(ctx, method, target.String(), body)
+}
+
+// do signs and sends a request. Missing objects are reported as ErrNotFound
+// and other failures with the service's error message.
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, res.Status, strings.TrimSpace(string(message)))
+}
+
+// sign adds the Signature Version 4 Authorization header. Only the host and
+// the x-amz-* headers are signed.
+func (s *S3Storage) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + unsignedPayload,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := day + "/" + s.opts.Region + "/s3/aws4_request"
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashedRequest[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), day)
+	for _, part := range []string{s.opts.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.opts.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode percent-encodes everything but unreserved characters and
+// slashes, as Signature Version 4 expects of object paths
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
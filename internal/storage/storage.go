@@ -0,0 +1,52 @@
+// Package storage keeps uploaded files in a pluggable Storage: a local
+// directory in development and an S3-compatible bucket in production
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ranggaaprilio/boilerGo/config"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored file
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+}
+
+// Storage stores files under slash separated keys such as
+// "avatars/1/3f2a.png". Keys are chosen by the application, never by clients.
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any object there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object under key. The caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Delete removes the object under key. Deleting a missing object succeeds.
+	Delete(ctx context.Context, key string) error
+}
+
+// New builds the storage selected by the storage configuration
+func New(conf config.StorageConfigurations) (Storage, error) {
+	switch conf.Driver {
+	case "local":
+		return NewLocalStorage(conf.LocalDir)
+	case "s3":
+		return NewS3Storage(S3Options{
+			Endpoint:  conf.S3.Endpoint,
+			Region:    conf.S3.Region,
+			Bucket:    conf.S3.Bucket,
+			AccessKey: conf.S3.AccessKey,
+			SecretKey: conf.S3.SecretKey,
+			UseSSL:    conf.S3.UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", conf.Driver)
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+)
+
+var (
+	// ErrNoFile is returned when a multipart upload has no file in the expected field
+	ErrNoFile = errors.New("no file uploaded")
+
+	// ErrTooLarge is returned when an uploaded file exceeds the size limit
+	ErrTooLarge = errors.New("file is too large")
+
+	// ErrUnsupportedType is returned when the content of an uploaded file is
+	// not one of the allowed types
+	ErrUnsupportedType = errors.New("file type is not allowed")
+)
+
+// Limits restricts the files an upload accepts
+type Limits struct {
+	// MaxSize is the largest accepted file in bytes
+	MaxSize int64
+	// Types are the accepted media types, such as "image/png"
+	Types []string
+}
+
+// Upload is a file read from a multipart request
+type Upload struct {
+	Data []byte
+	// ContentType is sniffed from Data; the type the client declared is ignored
+	ContentType string
+	Filename    string
+}
+
+// ReadUpload reads the file in the given field of a multipart/form-data
+// request. Parts are streamed, so at most limits.MaxSize bytes of the file
+// are buffered and a larger file is refused without being read in full. The
+// type is taken from the file's content, never from its name or the declared
+// Content-Type, so a script named avatar.png is refused.
+func ReadUpload(req *http.Request, field string, limits Limits) (Upload, error) {
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return Upload{}, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return Upload{}, ErrNoFile
+		}
+		if err != nil {
+			return Upload{}, err
+		}
+		if part.FormName() != field {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, limits.MaxSize+1))
+		if err != nil {
+			return Upload{}, err
+		}
+		if int64(len(data)) > limits.MaxSize {
+			return Upload{}, ErrTooLarge
+		}
+		if len(data) == 0 {
+			return Upload{}, ErrNoFile
+		}
+
+		contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+		for _, allowed := range limits.Types {
+			if contentType == allowed {
+				return Upload{Data: data, ContentType: contentType, Filename: part.FileName()}, nil
+			}
+		}
+		return Upload{}, ErrUnsupportedType
+	}
+}
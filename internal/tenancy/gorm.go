@@ -0,0 +1,205 @@
+package tenancy
+
+import (
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// FieldName is the struct field that makes a model tenant scoped. Any model
+// with a TenantID field is scoped as soon as the field is added.
+const FieldName = "TenantID"
+
+var (
+	// ErrNoTenant is returned for queries on tenant scoped models made with a
+	// context that acts for no tenant
+	ErrNoTenant = errors.New("tenant scoped query without a tenant")
+	// ErrTenantMismatch is returned when creating a row that already belongs
+	// to another tenant
+	ErrTenantMismatch = errors.New("row belongs to another tenant")
+)
+
+// Plugin scopes every query on a tenant scoped model to the tenant of the
+// statement's context, so a repository that forgets the filter cannot read
+// or change another tenant's rows. Queries without a tenant fail rather than
+// run unscoped. Repositories pass the request context with
+// db.WithContext(ctx).
+//
+// Only the statement's own table is scoped. Joined tables and raw SQL are
+// not, so joins must start from the scoped table or filter the joined one
+// with the Joined scope.
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "tenancy"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenancy:create", assignTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenancy:query", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenancy:update", scopeUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenancy:delete", scopeTenant); err != nil {
+		return err
+	}
+	return callbacks.Row().Before("gorm:row").Register("tenancy:row", scopeTenant)
+}
+
+// Joined returns a scope that restricts a joined table to the tenant of the
+// statement's context, for queries whose own table is not tenant scoped
+func Joined(table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		ctx := db.Statement.Context
+		if isAllTenants(ctx) {
+			return db
+		}
+		t, ok := FromContext(ctx)
+		if !ok {
+			_ = db.AddError(ErrNoTenant)
+			return db
+		}
+		return db.Where(clause.Eq{Column: clause.Column{Table: table, Name: "tenant_id"}, Value: t.ID})
+	}
+}
+
+// tenantField returns the TenantID field of the statement's model, or nil
+// for models that are not tenant scoped
+func tenantField(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(FieldName)
+}
+
+// statementTenant returns the tenant to scope the statement to, and false
+// when the statement is not scoped
+func statementTenant(db *gorm.DB) (*schema.Field, Tenant, bool) {
+	field := tenantField(db)
+	if field == nil || db.Error != nil || isAllTenants(db.Statement.Context) {
+		return nil, Tenant{}, false
+	}
+
+	t, ok := FromContext(db.Statement.Context)
+	if !ok {
+		_ = db.AddError(ErrNoTenant)
+		return nil, Tenant{}, false
+	}
+	return field, t, true
+}
+
+// scopeTenant restricts the statement to rows of the context's tenant
+func scopeTenant(db *gorm.DB) {
+	field, t, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: t.ID},
+	}})
+}
+
+// scopeUpdate scopes an update and fills in the tenant of a saved struct, so
+// Save on a struct built without TenantID does not move the row to no tenant
+func scopeUpdate(db *gorm.DB) {
+	field, t, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: t.ID},
+	}})
+	if db.Statement.ReflectValue.Kind() == reflect.Struct {
+		setTenant(db, field, db.Statement.ReflectValue, t.ID)
+	}
+}
+
+// assignTenant stamps new rows with the context's tenant and refuses rows
+// that already carry another tenant
+func assignTenant(db *gorm.DB) {
+	field, t, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setTenant(db, field, reflect.Indirect(rv.Index(i)), t.ID)
+		}
+	case reflect.Struct:
+		setTenant(db, field, rv, t.ID)
+	}
+	guardUpsert(db, field, t)
+}
+
+// guardUpsert refuses an upsert that would update the row of another tenant
+// holding the same primary key. Save on a struct with another tenant's ID
+// ends in such an upsert once its scoped update matched nothing.
+func guardUpsert(db *gorm.DB, field *schema.Field, t Tenant) {
+	c, ok := db.Statement.Clauses["ON CONFLICT"]
+	if !ok || db.Error != nil || field.PrimaryKey {
+		return
+	}
+	onConflict, ok := c.Expression.(clause.OnConflict)
+	if !ok || onConflict.DoNothing || (!onConflict.UpdateAll && len(onConflict.DoUpdates) == 0) {
+		return
+	}
+	for _, column := range onConflict.Columns {
+		if column.Name == field.DBName {
+			return
+		}
+	}
+
+	primaryFields := db.Statement.Schema.PrimaryFields
+	if len(primaryFields) != 1 {
+		return
+	}
+	_, ids := schema.GetIdentityFieldValuesMap(db.Statement.Context, db.Statement.ReflectValue, primaryFields)
+	if len(ids) == 0 {
+		return
+	}
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = id[0]
+	}
+
+	var foreign int64
+	err := db.Session(&gorm.Session{NewDB: true}).WithContext(AllTenants(db.Statement.Context)).
+		Table(db.Statement.Table).
+		Where(clause.IN{Column: clause.Column{Name: primaryFields[0].DBName}, Values: values}).
+		Where(clause.Neq{Column: clause.Column{Name: field.DBName}, Value: t.ID}).
+		Count(&foreign).Error
+	if err != nil {
+		_ = db.AddError(err)
+	} else if foreign > 0 {
+		_ = db.AddError(ErrTenantMismatch)
+	}
+}
+
+// setTenant sets the tenant of a row that has none, and flags rows of
+// another tenant
+func setTenant(db *gorm.DB, field *schema.Field, row reflect.Value, tenantID uint) {
+	ctx := db.Statement.Context
+	value, zero := field.ValueOf(ctx, row)
+	if zero {
+		if err := field.Set(ctx, row, tenantID); err != nil {
+			_ = db.AddError(err)
+		}
+		return
+	}
+	if id, ok := value.(uint); !ok || id != tenantID {
+		_ = db.AddError(ErrTenantMismatch)
+	}
+}
@@ -0,0 +1,195 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// note is a tenant scoped model
+type note struct {
+	ID       uint
+	TenantID uint
+	Text     string
+}
+
+// setting is a model without a tenant, shared by every tenant
+type setting struct {
+	ID   uint
+	Name string
+}
+
+var (
+	acme   = Tenant{ID: 1, Slug: "acme"}
+	globex = Tenant{ID: 2, Slug: "globex"}
+)
+
+// newTestDB opens an in-memory database with the plugin and one note per
+// tenant, returning the ID of each tenant's note
+func newTestDB(t *testing.T) (*gorm.DB, uint, uint) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err = db.Use(Plugin{}); err != nil {
+		t.Fatalf("register plugin: %v", err)
+	}
+	if err = db.AutoMigrate(&note{}, &setting{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	acmeNote := note{Text: "acme"}
+	if err = db.WithContext(WithTenant(context.Background(), acme)).Create(&acmeNote).Error; err != nil {
+		t.Fatalf("create acme note: %v", err)
+	}
+	globexNote := note{Text: "globex"}
+	if err = db.WithContext(WithTenant(context.Background(), globex)).Create(&globexNote).Error; err != nil {
+		t.Fatalf("create globex note: %v", err)
+	}
+	if acmeNote.TenantID != acme.ID || globexNote.TenantID != globex.ID {
+		t.Fatalf("created notes belong to tenants %d and %d", acmeNote.TenantID, globexNote.TenantID)
+	}
+	return db, acmeNote.ID, globexNote.ID
+}
+
+func TestQueriesOnlySeeTheirTenant(t *testing.T) {
+	db, acmeID, globexID := newTestDB(t)
+	ctx := WithTenant(context.Background(), acme)
+
+	var own note
+	if err := db.WithContext(ctx).First(&own, acmeID).Error; err != nil {
+		t.Fatalf("own note: %v", err)
+	}
+
+	var other note
+	err := db.WithContext(ctx).First(&other, globexID).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("other tenant's note: err = %v, want ErrRecordNotFound", err)
+	}
+
+	// A query that forgets any filter still sees one tenant
+	var all []note
+	if err = db.WithContext(ctx).Find(&all).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(all) != 1 || all[0].ID != acmeID {
+		t.Fatalf("unfiltered find returned %+v, want only the acme note", all)
+	}
+
+	var count int64
+	if err = db.WithContext(ctx).Model(&note{}).Count(&count).Error; err != nil || count != 1 {
+		t.Fatalf("count = %d, %v; want 1", count, err)
+	}
+}
+
+func TestWritesCannotReachAnotherTenant(t *testing.T) {
+	db, _, globexID := newTestDB(t)
+	ctx := WithTenant(context.Background(), acme)
+
+	result := db.WithContext(ctx).Model(&note{}).Where("id = ?", globexID).Update("text", "hijacked")
+	if result.Error != nil || result.RowsAffected != 0 {
+		t.Fatalf("update other tenant's note: affected %d, %v", result.RowsAffected, result.Error)
+	}
+
+	// Save falls back to an upsert when its update matched nothing
+	saved := note{ID: globexID, Text: "hijacked"}
+	if err := db.WithContext(ctx).Save(&saved).Error; !errors.Is(err, ErrTenantMismatch) {
+		t.Fatalf("save other tenant's note: err = %v, want ErrTenantMismatch", err)
+	}
+
+	result = db.WithContext(ctx).Delete(&note{}, globexID)
+	if result.Error != nil || result.RowsAffected != 0 {
+		t.Fatalf("delete other tenant's note: affected %d, %v", result.RowsAffected, result.Error)
+	}
+
+	var untouched note
+	if err := db.WithContext(WithTenant(context.Background(), globex)).First(&untouched, globexID).Error; err != nil {
+		t.Fatalf("globex note: %v", err)
+	}
+	if untouched.Text != "globex" {
+		t.Fatalf("globex note text = %q, want it unchanged", untouched.Text)
+	}
+}
+
+func TestSaveWritesOwnRows(t *testing.T) {
+	db, acmeID, _ := newTestDB(t)
+	ctx := WithTenant(context.Background(), acme)
+
+	// Neither update nor insert carry TenantID; the plugin fills it in
+	if err := db.WithContext(ctx).Save(&note{ID: acmeID, Text: "edited"}).Error; err != nil {
+		t.Fatalf("save own note: %v", err)
+	}
+	if err := db.WithContext(ctx).Save(&note{ID: 100, Text: "new"}).Error; err != nil {
+		t.Fatalf("save new note: %v", err)
+	}
+
+	var notes []note
+	if err := db.WithContext(ctx).Order("id").Find(&notes).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Text != "edited" || notes[1].ID != 100 || notes[1].TenantID != acme.ID {
+		t.Fatalf("notes = %+v, want the edited note and note 100 of acme", notes)
+	}
+}
+
+func TestCreateRefusesRowsOfAnotherTenant(t *testing.T) {
+	db, _, _ := newTestDB(t)
+
+	err := db.WithContext(WithTenant(context.Background(), acme)).Create(&note{TenantID: globex.ID}).Error
+	if !errors.Is(err, ErrTenantMismatch) {
+		t.Fatalf("err = %v, want ErrTenantMismatch", err)
+	}
+}
+
+func TestQueriesWithoutTenantFail(t *testing.T) {
+	db, acmeID, _ := newTestDB(t)
+
+	var n note
+	if err := db.WithContext(context.Background()).First(&n, acmeID).Error; !errors.Is(err, ErrNoTenant) {
+		t.Fatalf("query without tenant: err = %v, want ErrNoTenant", err)
+	}
+	if err := db.Create(&note{Text: "orphan"}).Error; !errors.Is(err, ErrNoTenant) {
+		t.Fatalf("create without tenant: err = %v, want ErrNoTenant", err)
+	}
+
+	// Models without a tenant are not affected
+	if err := db.Create(&setting{Name: "shared"}).Error; err != nil {
+		t.Fatalf("create shared row: %v", err)
+	}
+}
+
+func TestAllTenantsIsUnscoped(t *testing.T) {
+	db, _, _ := newTestDB(t)
+
+	var all []note
+	if err := db.WithContext(AllTenants(context.Background())).Find(&all).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("found %d notes, want both tenants' notes", len(all))
+	}
+}
+
+func TestJoinedScopesTheJoinedTable(t *testing.T) {
+	db, _, _ := newTestDB(t)
+	if err := db.Create(&setting{Name: "shared"}).Error; err != nil {
+		t.Fatalf("create setting: %v", err)
+	}
+
+	var names []string
+	err := db.WithContext(WithTenant(context.Background(), globex)).Model(&setting{}).
+		Joins("JOIN notes ON notes.id > 0").
+		Scopes(Joined("notes")).
+		Pluck("notes.text", &names).Error
+	if err != nil {
+		t.Fatalf("joined query: %v", err)
+	}
+	if len(names) != 1 || names[0] != "globex" {
+		t.Fatalf("joined query returned %v, want only globex's note", names)
+	}
+}
@@ -0,0 +1,87 @@
+// Package tenancy carries the tenant a request acts for through its context
+// and scopes database access to that tenant
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrUnknownTenant is returned when no tenant has the requested slug or ID
+var ErrUnknownTenant = errors.New("tenant not found")
+
+// slugPattern limits slugs to a DNS label so every tenant can be addressed by
+// subdomain
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidSlug reports whether s can be used as a tenant slug
+func ValidSlug(s string) bool {
+	return slugPattern.MatchString(s)
+}
+
+// Tenant identifies the customer a request acts for
+type Tenant struct {
+	ID   uint
+	Slug string
+}
+
+type (
+	contextKey    struct{}
+	allTenantsKey struct{}
+)
+
+// WithTenant returns a context acting for the tenant
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant the context acts for
+func FromContext(ctx context.Context) (Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(Tenant)
+	return t, ok && t.ID != 0
+}
+
+// AllTenants returns a context whose queries are not scoped to a tenant, for
+// maintenance such as deleting expired rows of every tenant. Rows it creates
+// must carry their TenantID.
+func AllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// isAllTenants reports whether the context opted out of tenant scoping
+func isAllTenants(ctx context.Context) bool {
+	all, _ := ctx.Value(allTenantsKey{}).(bool)
+	return all
+}
+
+// Require returns the tenant the context acts for, or ErrNoTenant
+func Require(ctx context.Context) (Tenant, error) {
+	t, ok := FromContext(ctx)
+	if !ok {
+		return Tenant{}, ErrNoTenant
+	}
+	return t, nil
+}
+
+// Matches reports whether a row of the tenant is visible to the context. It
+// applies the plugin's scoping to stores that do not go through GORM.
+func Matches(ctx context.Context, tenantID uint) bool {
+	if isAllTenants(ctx) {
+		return true
+	}
+	t, ok := FromContext(ctx)
+	return ok && t.ID == tenantID
+}
+
+// URLPlaceholder marks where a configured link takes the tenant's slug, as in
+// "https://{tenant}.example.com/verify"
+const URLPlaceholder = "{tenant}"
+
+// ExpandURL fills the tenant placeholders of a link with the slug of the
+// context's tenant
+func ExpandURL(ctx context.Context, link string) string {
+	t, _ := FromContext(ctx)
+	return strings.ReplaceAll(link, URLPlaceholder, t.Slug)
+}
@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	validator "github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one failed rule on one request field
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Rule    string `json:"rule" example:"max"`
+	Param   string `json:"param,omitempty" example:"320"`
+	Message string `json:"message" example:"email must be a maximum of 320 characters in length"`
+}
+
+// Error is returned when a request struct fails validation. Messages are
+// translated when they are read because the language depends on the request.
+type Error struct {
+	errs validator.ValidationErrors
+	uni  *ut.UniversalTranslator
+}
+
+// Error joins the messages in the fallback language, for logs and reports
+func (e *Error) Error() string {
+	fields := e.translate(e.uni.GetFallback())
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the underlying validator errors
+func (e *Error) Unwrap() error {
+	return e.errs
+}
+
+// Fields returns one entry per failed rule, with messages in the first
+// supported language of the Accept-Language header
+func (e *Error) Fields(acceptLanguage string) []FieldError {
+	return e.translate(findTranslator(e.uni, acceptLanguage))
+}
+
+func (e *Error) translate(trans ut.Translator) []FieldError {
+	fields := make([]FieldError, len(e.errs))
+	for i, fe := range e.errs {
+		fields[i] = FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		}
+	}
+	return fields
+}
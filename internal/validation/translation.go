@@ -0,0 +1,103 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	validator "github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+// passwordMessages are the messages of the custom "password" rule per locale
+var passwordMessages = map[string]string{
+	"en": fmt.Sprintf("{0} must be %d to %d characters long and contain a lowercase letter, an uppercase letter and a digit", MinPasswordLength, MaxPasswordLength),
+	"id": fmt.Sprintf("{0} harus %d sampai %d karakter dan mengandung huruf kecil, huruf besar dan angka", MinPasswordLength, MaxPasswordLength),
+}
+
+// newUniversalTranslator registers the messages of every supported locale on
+// v. English is the fallback for requests without a supported language.
+func newUniversalTranslator(v *validator.Validate) *ut.UniversalTranslator {
+	english := en.New()
+	uni := ut.New(english, english, id.New())
+
+	registrations := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": en_translations.RegisterDefaultTranslations,
+		"id": id_translations.RegisterDefaultTranslations,
+	}
+	for locale, register := range registrations {
+		trans, _ := uni.GetTranslator(locale)
+		_ = register(v, trans)
+		_ = v.RegisterTranslation("password", trans, func(t ut.Translator) error {
+			return t.Add("password", passwordMessages[locale], true)
+		}, func(t ut.Translator, fe validator.FieldError) string {
+			msg, _ := t.T("password", fe.Field())
+			return msg
+		})
+	}
+
+	return uni
+}
+
+// findTranslator returns the translator of the most preferred supported
+// language in an Accept-Language header, or the fallback. A regional tag
+// such as id-ID also matches its base language.
+func findTranslator(uni *ut.UniversalTranslator, acceptLanguage string) ut.Translator {
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if trans, found := uni.GetTranslator(strings.ReplaceAll(tag, "-", "_")); found {
+			return trans
+		}
+		if base, _, ok := strings.Cut(tag, "-"); ok {
+			if trans, found := uni.GetTranslator(base); found {
+				return trans
+			}
+		}
+	}
+	return uni.GetFallback()
+}
+
+// parseAcceptLanguage returns the language tags of the header ordered by
+// quality, dropping wildcards and tags with q=0
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		langs = append(langs, weighted{tag, quality})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].quality > langs[j].quality
+	})
+
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i] = l.tag
+	}
+	return tags
+}
@@ -0,0 +1,89 @@
+// Package validation provides the request validator and the custom rules shared by all handlers
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+
+	ut "github.com/go-playground/universal-translator"
+	validator "github.com/go-playground/validator/v10"
+)
+
+// Password length limits. bcrypt ignores everything past 72 bytes.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// New returns a validator with the application's custom rules registered.
+// Errors name fields the way clients send them, by their json or query tag.
+func New() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(fieldName)
+	_ = v.RegisterValidation("password", strongPassword)
+	return v
+}
+
+// Validator validates request structs and reports failures as *Error
+type Validator struct {
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+}
+
+// NewValidator returns a Validator with the custom rules and the messages of
+// every supported language registered
+func NewValidator() *Validator {
+	v := New()
+	return &Validator{validate: v, uni: newUniversalTranslator(v)}
+}
+
+// Validate validates a struct. Failed rules are returned as *Error; any other
+// error means the value could not be validated at all.
+func (v *Validator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	var errs validator.ValidationErrors
+	if errors.As(err, &errs) {
+		return &Error{errs: errs, uni: v.uni}
+	}
+	return err
+}
+
+// fieldName returns the json name of a struct field, falling back to its
+// query name and then its Go name
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "query"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// strongPassword implements the "password" rule: 8 to 72 bytes containing at
+// least one lowercase letter, one uppercase letter and one digit
+func strongPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return false
+	}
+
+	var hasLower, hasUpper, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+
+	return hasLower && hasUpper && hasDigit
+}
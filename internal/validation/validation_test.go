@@ -0,0 +1,68 @@
+package validation_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ranggaaprilio/boilerGo/internal/validation"
+)
+
+type signupForm struct {
+	Email    string `json:"email" validate:"required,email,max=320"`
+	Password string `json:"password" validate:"required,password"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=name -name"`
+}
+
+func validate(t *testing.T, form signupForm) *validation.Error {
+	t.Helper()
+	err := validation.NewValidator().Validate(form)
+	var verr *validation.Error
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want *validation.Error", err)
+	}
+	return verr
+}
+
+func TestFieldsUseTagNamesAndRules(t *testing.T) {
+	verr := validate(t, signupForm{Email: "not-an-email", Password: "short", Sort: "age"})
+
+	fields := verr.Fields("")
+	want := []validation.FieldError{
+		{Field: "email", Rule: "email"},
+		{Field: "password", Rule: "password"},
+		{Field: "sort", Rule: "oneof", Param: "name -name"},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("Fields() = %+v, want %d entries", fields, len(want))
+	}
+	for i, w := range want {
+		if fields[i].Field != w.Field || fields[i].Rule != w.Rule || fields[i].Param != w.Param {
+			t.Errorf("field %d = %+v, want %+v", i, fields[i], w)
+		}
+	}
+	if got := fields[0].Message; got != "email must be a valid email address" {
+		t.Errorf("english message = %q", got)
+	}
+}
+
+func TestFieldsFollowAcceptLanguage(t *testing.T) {
+	verr := validate(t, signupForm{Password: "Secr3tPassword"})
+
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", "email is a required field"},
+		{"id", "email wajib diisi"},
+		{"id-ID,id;q=0.9", "email wajib diisi"},
+		{"fr;q=1, en;q=0.8, id;q=0.9", "email wajib diisi"},
+		{"id;q=0, en", "email is a required field"},
+		{"de", "email is a required field"},
+	}
+	for _, tc := range cases {
+		fields := verr.Fields(tc.header)
+		if len(fields) != 1 || fields[0].Message != tc.want {
+			t.Errorf("Fields(%q) = %+v, want message %q", tc.header, fields, tc.want)
+		}
+	}
+}
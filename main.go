@@ -14,9 +14,26 @@
 // @host localhost:8080
 // @BasePath /api
 // @schemes http https
+//
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token from /v1/auth/login, sent as "Bearer <token>"
+//
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description Scoped API key from /v1/api-keys. May also be sent as "Authorization: ApiKey <key>"
+//
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name X-Admin-Token
+// @description Break-glass admin token from app.admin_token, passes every permission check
 package main
 
 import (
+	"os"
+
 	_ "github.com/ranggaaprilio/boilerGo/docs" // Import swagger docs
 	"github.com/ranggaaprilio/boilerGo/exception"
 	cmd "github.com/ranggaaprilio/boilerGo/internal/cmd"
@@ -26,6 +43,11 @@ import (
 func main() {
 	defer exception.Catch()
 
+	// "audit verify" checks the audit log instead of starting the server
+	if len(os.Args) == 3 && os.Args[1] == "audit" && os.Args[2] == "verify" {
+		os.Exit(cmd.VerifyAudit(os.Stdout))
+	}
+
 	// Initialize simple logger for main
 	mainLogger := appLogger.SimpleLogger("main")
 